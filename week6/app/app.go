@@ -0,0 +1,182 @@
+// Package app wires the repositories, services, controllers and routes into
+// a gin engine. main runs it against the configured Postgres, Redis and
+// storage, the e2e tests against throwaway ones.
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/counters"
+	"github.com/trung/backend-engineerpro/feed"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/jobs"
+	"github.com/trung/backend-engineerpro/metrics"
+	"github.com/trung/backend-engineerpro/middleware"
+	"github.com/trung/backend-engineerpro/moderation"
+	"github.com/trung/backend-engineerpro/notifications"
+	"github.com/trung/backend-engineerpro/openapi"
+	"github.com/trung/backend-engineerpro/realtime"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/routes"
+	"github.com/trung/backend-engineerpro/scheduler"
+	"github.com/trung/backend-engineerpro/services"
+	"github.com/trung/backend-engineerpro/storage"
+	"github.com/trung/backend-engineerpro/tags"
+	"github.com/trung/backend-engineerpro/trash"
+	"gorm.io/gorm"
+)
+
+type App struct {
+	Config  initializers.Config
+	DB      *gorm.DB
+	Router  *gin.Engine
+	Metrics *prometheus.Registry
+
+	Counters      *counters.Store
+	Feed          *feed.Feed
+	Publisher     *scheduler.Publisher
+	Trash         *trash.Purger
+	Tags          *tags.Service
+	Notifications *notifications.Service
+	Realtime      *realtime.Hub
+	Moderation    *moderation.Service
+
+	AuthService     *services.AuthService
+	UserService     *services.UserService
+	PostService     *services.PostService
+	CommentService  *services.CommentService
+	ReactionService *services.ReactionService
+
+	// Set by Start, see server.go
+	server        *http.Server
+	metricsServer *http.Server
+	addr          net.Addr
+	jobs          *jobs.Group
+	stopJobs      context.CancelFunc
+	errs          chan error
+}
+
+// New builds the app. Redis is reached through redisClient, which should be
+// initializers.RedisClient as well since the cache checks its breaker.
+func New(config initializers.Config, db *gorm.DB, redisClient *redis.Client, blobs storage.BlobStore) *App {
+	a := &App{Config: config, DB: db, Metrics: metrics.NewRegistry(db, redisClient)}
+
+	a.Counters = counters.NewStore(db, redisClient)
+	a.Feed = feed.New(db, redisClient)
+	a.Tags = tags.NewService(db, redisClient)
+	a.Notifications = notifications.NewService(db)
+	a.Publisher = scheduler.NewPublisher(db, a.Feed, a.Tags, a.Notifications)
+
+	// Push feed items, notifications and counter changes to connected clients
+	a.Realtime = realtime.NewHub(redisClient)
+	a.Feed.OnFanOut = a.Realtime.FeedPost
+	a.Notifications.OnNotify = a.Realtime.Notification
+	a.Counters.OnChange = a.Realtime.CounterChanged
+	a.Trash = trash.NewPurger(db, time.Duration(config.TrashRetentionDays)*24*time.Hour)
+	a.Moderation = moderation.NewService(db, moderation.NewWordFilter(config.ModerationWords), config.ReportHideThreshold)
+
+	// Business rules for auth, users, posts, comments and reactions live in
+	// services on top of the repositories
+	users := repository.NewUserRepository(db)
+	posts := repository.NewPostRepository(db)
+	tx := repository.NewTransactor(db)
+	a.AuthService = services.NewAuthService(users, config)
+	a.UserService = services.NewUserService(users, repository.NewFollowRepository(db), repository.NewBlockRepository(db),
+		repository.NewMentionRepository(db), tx, a.Feed, a.Notifications)
+	a.PostService = services.NewPostService(posts, repository.NewBlockRepository(db), tx, tags.Indexer{Tags: a.Tags}, a.Feed, a.Notifications, a.Moderation, a.Counters, a.Trash)
+	a.CommentService = services.NewCommentService(posts, repository.NewCommentRepository(db), tx, tags.Indexer{Tags: a.Tags}, a.Notifications, a.Moderation, a.Counters, a.Trash)
+	a.ReactionService = services.NewReactionService(posts, repository.NewReactionRepository(db), a.Notifications, a.Counters)
+
+	authController := controllers.NewAuthController(a.AuthService)
+	userController := controllers.NewUserController(a.UserService, a.Feed, a.PostService)
+	postController := controllers.NewPostController(a.PostService, a.CommentService, a.ReactionService, redisClient)
+	uploadController := controllers.NewUploadController(db, blobs, config.UploadMaxSize)
+	tagController := controllers.NewTagController(db, a.Tags)
+	notificationController := controllers.NewNotificationController(db, a.Notifications)
+	eventController := controllers.NewEventController(a.Realtime, config.WriteTimeout)
+	conversationController := controllers.NewConversationController(db, a.Realtime)
+	bookmarkController := controllers.NewBookmarkController(db)
+	moderationController := controllers.NewModerationController(db, a.Moderation)
+	docsController := controllers.NewDocsController(openapi.New())
+
+	// Every error a handler reports comes out as problem+json carrying the
+	// request id, and every request is traced and logged with it. ctx.Value falls back to
+	// the request context so handlers can pass the gin context to FromContext.
+	a.Router = gin.New()
+	a.Router.ContextWithFallback = true
+	a.Router.Use(middleware.RequestID(), middleware.Tracing(), middleware.AccessLog(), middleware.Metrics(), middleware.ErrorHandler(), middleware.Recover())
+	a.Router.NoRoute(middleware.NoRoute)
+
+	// With METRICS_ADDR set /metrics is only on that port, see Start
+	if config.MetricsAddr == "" {
+		a.Router.GET("/metrics", gin.WrapH(a.metricsHandler()))
+	}
+
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = []string{"http://localhost:8000", config.ClientOrigin}
+	corsConfig.AllowCredentials = true
+	a.Router.Use(cors.New(corsConfig))
+
+	// Local uploads are served by the app itself, S3 serves its own files
+	if config.UploadDriver != "s3" {
+		a.Router.Static("/uploads", config.UploadDir)
+	}
+
+	router := a.Router.Group("/api")
+	router.GET("/healthchecker", a.healthCheck)
+
+	authRoutes := routes.NewAuthRouteController(authController, a.AuthService)
+	authRoutes.AuthRoute(router)
+	userRoutes := routes.NewRouteUserController(userController, a.AuthService)
+	userRoutes.UserRoute(router)
+	postRoutes := routes.NewRoutePostController(postController, a.AuthService)
+	postRoutes.PostRoute(router)
+	uploadRoutes := routes.NewRouteUploadController(uploadController, a.AuthService)
+	uploadRoutes.UploadRoute(router)
+	tagRoutes := routes.NewRouteTagController(tagController)
+	tagRoutes.TagRoute(router)
+	notificationRoutes := routes.NewRouteNotificationController(notificationController, a.AuthService)
+	notificationRoutes.NotificationRoute(router)
+	eventRoutes := routes.NewRouteEventController(eventController, a.AuthService)
+	eventRoutes.EventRoute(router)
+	conversationRoutes := routes.NewRouteConversationController(conversationController, a.AuthService)
+	conversationRoutes.ConversationRoute(router)
+	bookmarkRoutes := routes.NewRouteBookmarkController(bookmarkController, a.AuthService)
+	bookmarkRoutes.BookmarkRoute(router)
+	moderationRoutes := routes.NewRouteModerationController(moderationController, a.AuthService)
+	moderationRoutes.ModerationRoute(router)
+	docsRoutes := routes.NewRouteDocsController(docsController)
+	docsRoutes.DocsRoute(router)
+	return a
+}
+
+func (a *App) metricsHandler() http.Handler {
+	return promhttp.HandlerFor(a.Metrics, promhttp.HandlerOpts{})
+}
+
+func (a *App) healthCheck(ctx *gin.Context) {
+	message := "Welcome To Engineer Pro project!"
+
+	dbStatus := "up"
+	if sqlDB, err := a.DB.DB(); err != nil || sqlDB.PingContext(ctx.Request.Context()) != nil {
+		dbStatus = "down"
+	}
+	services := gin.H{"database": dbStatus, "redis": initializers.RedisStatus()}
+
+	// Without Postgres nothing works, without Redis we only lose caching
+	if dbStatus != "up" {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "error", "message": "Database is unreachable", "services": services})
+		return
+	}
+	degraded := !initializers.RedisAvailable()
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": message, "degraded": degraded, "services": services})
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/trung/backend-engineerpro/jobs"
+	"go.uber.org/zap"
+)
+
+// Start runs the background jobs and serves the API on PORT, and /metrics on
+// METRICS_ADDR when set. It returns once the ports are bound; errors serving
+// later come out of Errors.
+func (a *App) Start() error {
+	listener, err := net.Listen("tcp", ":"+a.Config.ServerPort)
+	if err != nil {
+		return err
+	}
+	a.addr = listener.Addr()
+	a.errs = make(chan error, 2)
+	a.server = &http.Server{
+		Handler:      a.Router,
+		ReadTimeout:  a.Config.ReadTimeout,
+		WriteTimeout: a.Config.WriteTimeout,
+		IdleTimeout:  a.Config.IdleTimeout,
+	}
+	// Event streams never go idle, ending them lets Shutdown finish
+	a.server.RegisterOnShutdown(a.Realtime.Close)
+
+	if a.Config.MetricsAddr != "" {
+		metricsListener, err := net.Listen("tcp", a.Config.MetricsAddr)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", a.metricsHandler())
+		a.metricsServer = &http.Server{
+			Handler:      mux,
+			ReadTimeout:  a.Config.ReadTimeout,
+			WriteTimeout: a.Config.WriteTimeout,
+		}
+		go a.serve(a.metricsServer, metricsListener)
+		zap.L().Info("metrics listening", zap.Stringer("addr", metricsListener.Addr()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopJobs = cancel
+	a.jobs = jobs.NewGroup(ctx)
+	a.jobs.Every("counters flush", a.Config.CounterFlushInterval, a.Counters.Flush)
+	a.jobs.Every("counters reconcile", a.Config.CounterReconcileInterval, a.Counters.Reconcile)
+	a.jobs.Every("publish scheduled posts", a.Config.SchedulerInterval, a.Publisher.PublishDue)
+	a.jobs.Every("purge trash", a.Config.TrashPurgeInterval, a.Trash.Purge)
+	a.jobs.Go(a.Realtime.Run)
+
+	go a.serve(a.server, listener)
+	zap.L().Info("server listening", zap.Stringer("addr", a.addr))
+	return nil
+}
+
+func (a *App) serve(server *http.Server, listener net.Listener) {
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		a.errs <- err
+	}
+}
+
+// Errors reports a server that stopped serving on its own
+func (a *App) Errors() <-chan error {
+	return a.errs
+}
+
+// Addr is the address the API is served on once started
+func (a *App) Addr() net.Addr {
+	return a.addr
+}
+
+// Stop shuts a started app down: it stops accepting connections and lets the
+// in-flight requests finish, then stops the background jobs. Whatever is
+// still running when ctx is done is cut off. The database and Redis clients
+// are the caller's to close afterwards, the jobs may use them until now.
+func (a *App) Stop(ctx context.Context) error {
+	err := a.server.Shutdown(ctx)
+	if err != nil {
+		// Drop the connections that didn't finish in time
+		a.server.Close()
+	}
+	if a.metricsServer != nil {
+		a.metricsServer.Close()
+	}
+
+	a.stopJobs()
+	if jobsErr := a.jobs.Wait(ctx); jobsErr != nil && err == nil {
+		err = fmt.Errorf("background jobs still running: %w", jobsErr)
+	}
+	return err
+}
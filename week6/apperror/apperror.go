@@ -0,0 +1,165 @@
+// Package apperror holds the errors handlers report with ctx.Error. Each one
+// carries the HTTP status and a stable code, middleware.ErrorHandler turns it
+// into an RFC 7807 problem+json response.
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Codes clients can switch on, the message next to them is for people
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidation           = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeTooLarge             = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeUnprocessable        = "unprocessable_entity"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "service_unavailable"
+)
+
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	// Err is the cause, it is logged but never shown to the client
+	Err error
+}
+
+// FieldError points at one invalid field of the request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// Internal hides err from the client behind a generic message
+func Internal(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Something went wrong", Err: err}
+}
+
+// Invalid describes why the request body could not be bound, field by field
+// when the validator or the JSON decoder says which field it was
+func Invalid(err error) *Error {
+	e := &Error{Status: http.StatusBadRequest, Code: CodeValidation, Message: "The request body is invalid", Err: err}
+
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrors):
+		for _, fieldError := range validationErrors {
+			e.Fields = append(e.Fields, FieldError{
+				Field:   fieldError.Field(),
+				Rule:    fieldError.Tag(),
+				Message: ruleMessage(fieldError),
+			})
+		}
+	case errors.As(err, &typeError):
+		e.Fields = []FieldError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Message: "must be a " + typeError.Type.String(),
+		}}
+	case errors.As(err, &syntaxError):
+		e.Message = "The request body is not valid JSON"
+	case errors.Is(err, io.EOF):
+		e.Message = "The request body is empty"
+	}
+	return e
+}
+
+func ruleMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email address"
+	case "min":
+		return "must be at least " + fieldError.Param() + limitUnit(fieldError)
+	case "max":
+		return "must be at most " + fieldError.Param() + limitUnit(fieldError)
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldError.Param(), " ", ", ")
+	default:
+		return fmt.Sprintf("failed the %q rule", fieldError.Tag())
+	}
+}
+
+// limitUnit says what min and max count: characters of a string, items of a
+// list or nothing for a number
+func limitUnit(fieldError validator.FieldError) string {
+	switch fieldError.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	default:
+		return ""
+	}
+}
+
+func init() {
+	// Report fields by their JSON name, which is what clients send
+	if validate, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
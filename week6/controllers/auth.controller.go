@@ -1,24 +1,21 @@
 package controllers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/apperror"
 	"github.com/trung/backend-engineerpro/models"
-	"github.com/trung/backend-engineerpro/utils"
-	"gorm.io/gorm"
+	"github.com/trung/backend-engineerpro/services"
 )
 
 type AuthController struct {
-	DB *gorm.DB
+	Auth *services.AuthService
 }
 
-func NewAuthController(DB *gorm.DB) AuthController {
-	return AuthController{DB}
+func NewAuthController(Auth *services.AuthService) AuthController {
+	return AuthController{Auth}
 }
 
 // SignUp User
@@ -26,99 +23,49 @@ func (ac *AuthController) SignUpUser(ctx *gin.Context) {
 	var payload *models.SignUpInput
 
 	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		ctx.Error(apperror.Invalid(err))
 		return
 	}
 
-	if payload.Password != payload.PasswordConfirm {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "Passwords do not match"})
+	newUser, err := ac.Auth.SignUp(ctx.Request.Context(), *payload)
+	switch {
+	case errors.Is(err, services.ErrPasswordMismatch), errors.Is(err, services.ErrInvalidUsername):
+		ctx.Error(apperror.BadRequest(err.Error()))
 		return
-	}
-
-	hashedPassword, err := utils.HashPassword(payload.Password)
-	if err != nil {
-		ctx.JSON(http.StatusBadGateway, gin.H{"status": "error", "message": err.Error()})
-		return
-	}
-
-	now := time.Now()
-	newUser := models.User{
-		Name:         payload.Name,
-		Email:        strings.ToLower(payload.Email),
-		Password:     hashedPassword,
-		Role:         "user",
-		Verified:     true,
-		ProfileImage: payload.ProfileImage,
-		Age:          payload.Age,
-		Provider:     "local",
-		CreatedAt:    now,
-		UpdatedAt:    now,
-	}
-
-	result := ac.DB.Create(&newUser)
-
-	if result.Error != nil && strings.Contains(result.Error.Error(), "duplicate key value violates unique") {
-		ctx.JSON(http.StatusConflict, gin.H{"status": "fail", "message": "User with that email already exists"})
+	case errors.Is(err, services.ErrUsernameTaken), errors.Is(err, services.ErrEmailTaken):
+		ctx.Error(apperror.Conflict(err.Error()))
 		return
-	} else if result.Error != nil {
-		ctx.JSON(http.StatusBadGateway, gin.H{"status": "error", "message": "Something bad happened"})
+	case err != nil:
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
-	userResponse := &models.UserResponse{
-		ID:           newUser.ID,
-		Name:         newUser.Name,
-		Email:        newUser.Email,
-		ProfileImage: newUser.ProfileImage,
-		Role:         newUser.Role,
-		Age:          newUser.Age,
-		Provider:     newUser.Provider,
-		CreatedAt:    newUser.CreatedAt,
-		UpdatedAt:    newUser.UpdatedAt,
-	}
-	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": gin.H{"user": userResponse}})
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": gin.H{"user": userResponse(newUser)}})
 }
 
 func (ac *AuthController) SignInUser(ctx *gin.Context) {
 	var payload *models.SignInInput
 
 	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		ctx.Error(apperror.Invalid(err))
 		return
 	}
 
-	var user models.User
-	result := ac.DB.First(&user, "email = ?", strings.ToLower(payload.Email))
-	if result.Error != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "Invalid email or Password"})
+	tokens, err := ac.Auth.SignIn(ctx.Request.Context(), *payload)
+	if errors.Is(err, services.ErrSuspended) {
+		ctx.Error(apperror.Forbidden(err.Error()))
 		return
-	}
-
-	if err := utils.VerifyPassword(user.Password, payload.Password); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "Invalid email or Password"})
+	} else if err != nil {
+		ctx.Error(apperror.BadRequest(err.Error()))
 		return
 	}
 
-	config, _ := initializers.LoadConfig(".")
-
-	// Generate Tokens
-	access_token, err := utils.CreateToken(config.AccessTokenExpiresIn, user.ID, config.AccessTokenPrivateKey)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
-		return
-	}
-
-	refresh_token, err := utils.CreateToken(config.RefreshTokenExpiresIn, user.ID, config.RefreshTokenPrivateKey)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
-		return
-	}
-
-	ctx.SetCookie("access_token", access_token, config.AccessTokenMaxAge*60, "/", "localhost", false, true)
-	ctx.SetCookie("refresh_token", refresh_token, config.RefreshTokenMaxAge*60, "/", "localhost", false, true)
+	config := ac.Auth.Config
+	ctx.SetCookie("access_token", tokens.Access, config.AccessTokenMaxAge*60, "/", "localhost", false, true)
+	ctx.SetCookie("refresh_token", tokens.Refresh, config.RefreshTokenMaxAge*60, "/", "localhost", false, true)
 	ctx.SetCookie("logged_in", "true", config.AccessTokenMaxAge*60, "/", "localhost", false, false)
 
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "access_token": access_token})
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "access_token": tokens.Access})
 }
 
 // Refresh Access Token
@@ -128,31 +75,17 @@ func (ac *AuthController) RefreshAccessToken(ctx *gin.Context) {
 	cookie, err := ctx.Cookie("refresh_token")
 
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": message})
-		return
-	}
-
-	config, _ := initializers.LoadConfig(".")
-
-	sub, err := utils.ValidateToken(cookie, config.RefreshTokenPublicKey)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": err.Error()})
-		return
-	}
-
-	var user models.User
-	result := ac.DB.First(&user, "id = ?", fmt.Sprint(sub))
-	if result.Error != nil {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": "the user belonging to this token no logger exists"})
+		ctx.Error(apperror.Forbidden(message))
 		return
 	}
 
-	access_token, err := utils.CreateToken(config.AccessTokenExpiresIn, user.ID, config.AccessTokenPrivateKey)
+	access_token, err := ac.Auth.Refresh(ctx.Request.Context(), cookie)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": err.Error()})
+		ctx.Error(apperror.Forbidden(err.Error()))
 		return
 	}
 
+	config := ac.Auth.Config
 	ctx.SetCookie("access_token", access_token, config.AccessTokenMaxAge*60, "/", "localhost", false, true)
 	ctx.SetCookie("logged_in", "true", config.AccessTokenMaxAge*60, "/", "localhost", false, false)
 
@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkController struct {
+	DB *gorm.DB
+}
+
+func NewBookmarkController(DB *gorm.DB) BookmarkController {
+	return BookmarkController{DB}
+}
+
+// SavePost bookmarks a published post, saving it again only moves it to the given collection
+func (bc *BookmarkController) SavePost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	var payload models.BookmarkInput
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			ctx.Error(apperror.Invalid(err))
+			return
+		}
+	}
+
+	var post models.Post
+	if err := bc.DB.WithContext(ctx.Request.Context()).Select("id").First(&post, "id = ? AND status = ?", postId, models.PostPublished).Error; err != nil {
+		ctx.Error(apperror.NotFound("No post with that title exists"))
+		return
+	}
+
+	collectionID, ok := bc.collectionID(ctx, payload.CollectionID)
+	if !ok {
+		return
+	}
+
+	bookmark := models.Bookmark{
+		UserID:       currentUser.ID,
+		PostID:       postId,
+		CollectionID: collectionID,
+		CreatedAt:    time.Now(),
+	}
+	err = bc.DB.WithContext(ctx.Request.Context()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+	}).Create(&bookmark).Error
+	if err == nil {
+		// Not every driver hands back the id of the row a conflict updated, look it up
+		bookmark = models.Bookmark{}
+		err = bc.DB.WithContext(ctx.Request.Context()).First(&bookmark, "user_id = ? AND post_id = ?", currentUser.ID, postId).Error
+	}
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": bookmark})
+}
+
+func (bc *BookmarkController) UnsavePost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	if err := bc.DB.WithContext(ctx.Request.Context()).Where("user_id = ? AND post_id = ?", currentUser.ID, postId).Delete(&models.Bookmark{}).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// FindBookmarks lists the saved posts, most recently saved first.
+// ?collection_id=<id> narrows it to one collection, ?collection_id=none to the unsorted ones.
+func (bc *BookmarkController) FindBookmarks(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	// Posts deleted or unpublished since they were saved drop out of the list
+	query := bc.DB.WithContext(ctx.Request.Context()).Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL AND posts.status = ?", models.PostPublished).
+		Where("bookmarks.user_id = ?", currentUser.ID)
+
+	switch collection := ctx.Query("collection_id"); collection {
+	case "":
+	case "none":
+		query = query.Where("bookmarks.collection_id IS NULL")
+	default:
+		if _, err := uuid.Parse(collection); err != nil {
+			ctx.Error(apperror.NotFound("Collection not found"))
+			return
+		}
+		query = query.Where("bookmarks.collection_id = ?", collection)
+	}
+
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		createdAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			ctx.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+		query = query.Where("(bookmarks.created_at, bookmarks.id) < (?, ?)", createdAt, id)
+	}
+
+	var bookmarks []models.Bookmark
+	if err := query.Order("bookmarks.created_at DESC, bookmarks.id DESC").Limit(limit + 1).Find(&bookmarks).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	var nextCursor string
+	if len(bookmarks) > limit {
+		bookmarks = bookmarks[:limit]
+		last := bookmarks[len(bookmarks)-1]
+		nextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	postIDs := make([]uuid.UUID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		postIDs[i] = bookmark.PostID
+	}
+	var posts []models.Post
+	if len(postIDs) > 0 {
+		err := bc.DB.WithContext(ctx.Request.Context()).Where("id IN ?", postIDs).Find(&posts).Error
+		if err == nil {
+			err = attachOriginals(ctx.Request.Context(), bc.DB, posts)
+		}
+		if err != nil {
+			ctx.Error(apperror.Internal(err))
+			return
+		}
+	}
+	postsByID := make(map[uuid.UUID]models.Post, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+
+	data := make([]models.BookmarkResponse, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		data[i] = models.BookmarkResponse{Post: postsByID[bookmark.PostID], CollectionID: bookmark.CollectionID, SavedAt: bookmark.CreatedAt}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(data), "data": data, "next_cursor": nextCursor})
+}
+
+// MoveBookmarks puts saved posts into a collection, an empty collection_id makes them unsorted
+func (bc *BookmarkController) MoveBookmarks(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var payload *models.MoveBookmarksInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+
+	collectionID, ok := bc.collectionID(ctx, payload.CollectionID)
+	if !ok {
+		return
+	}
+
+	result := bc.DB.WithContext(ctx.Request.Context()).Model(&models.Bookmark{}).Where("user_id = ? AND post_id IN ?", currentUser.ID, payload.PostIDs).
+		Update("collection_id", collectionID)
+	if result.Error != nil {
+		ctx.Error(apperror.Internal(result.Error))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"moved": result.RowsAffected}})
+}
+
+// FindCollections lists the current user's collections with how many posts each holds
+func (bc *BookmarkController) FindCollections(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var collections []models.Collection
+	if err := bc.DB.WithContext(ctx.Request.Context()).Where("user_id = ?", currentUser.ID).Order("name").Find(&collections).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	var rows []struct {
+		CollectionID *string
+		Count        int64
+	}
+	err := bc.DB.WithContext(ctx.Request.Context()).Model(&models.Bookmark{}).Select("collection_id, count(*) AS count").
+		Where("user_id = ?", currentUser.ID).Group("collection_id").Scan(&rows).Error
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	counts := make(map[string]int64, len(rows))
+	var unsorted int64
+	for _, row := range rows {
+		if row.CollectionID == nil {
+			unsorted = row.Count
+			continue
+		}
+		counts[*row.CollectionID] = row.Count
+	}
+	for i := range collections {
+		collections[i].BookmarksCount = counts[collections[i].ID]
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(collections), "data": collections, "unsorted": unsorted})
+}
+
+func (bc *BookmarkController) CreateCollection(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var payload *models.CollectionInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+
+	now := time.Now()
+	collection := models.Collection{UserID: currentUser.ID, Name: strings.TrimSpace(payload.Name), CreatedAt: now, UpdatedAt: now}
+	if err := bc.DB.WithContext(ctx.Request.Context()).Create(&collection).Error; err != nil {
+		if duplicateName(err) {
+			ctx.Error(apperror.Conflict("You already have a collection with that name"))
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": collection})
+}
+
+func (bc *BookmarkController) UpdateCollection(ctx *gin.Context) {
+	collection, ok := bc.findCollection(ctx)
+	if !ok {
+		return
+	}
+
+	var payload *models.CollectionInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+
+	err := bc.DB.WithContext(ctx.Request.Context()).Model(&collection).Updates(map[string]interface{}{"name": strings.TrimSpace(payload.Name), "updated_at": time.Now()}).Error
+	if err != nil {
+		if duplicateName(err) {
+			ctx.Error(apperror.Conflict("You already have a collection with that name"))
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": collection})
+}
+
+// DeleteCollection removes the collection, its bookmarks are kept as unsorted
+func (bc *BookmarkController) DeleteCollection(ctx *gin.Context) {
+	collection, ok := bc.findCollection(ctx)
+	if !ok {
+		return
+	}
+
+	err := bc.DB.WithContext(ctx.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).Where("collection_id = ?", collection.ID).Update("collection_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&collection).Error
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (bc *BookmarkController) findCollection(ctx *gin.Context) (models.Collection, bool) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var collection models.Collection
+	if _, err := uuid.Parse(ctx.Param("collectionId")); err != nil {
+		ctx.Error(apperror.NotFound("Collection not found"))
+		return collection, false
+	}
+	if err := bc.DB.WithContext(ctx.Request.Context()).First(&collection, "id = ? AND user_id = ?", ctx.Param("collectionId"), currentUser.ID).Error; err != nil {
+		ctx.Error(apperror.NotFound("Collection not found"))
+		return collection, false
+	}
+	return collection, true
+}
+
+// collectionID checks that a collection id from the request body belongs to
+// the current user, an empty id means no collection
+func (bc *BookmarkController) collectionID(ctx *gin.Context, id string) (*string, bool) {
+	if id == "" {
+		return nil, true
+	}
+
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	var collection models.Collection
+	if _, err := uuid.Parse(id); err != nil {
+		ctx.Error(apperror.NotFound("Collection not found"))
+		return nil, false
+	}
+	if err := bc.DB.WithContext(ctx.Request.Context()).Select("id").First(&collection, "id = ? AND user_id = ?", id, currentUser.ID).Error; err != nil {
+		ctx.Error(apperror.NotFound("Collection not found"))
+		return nil, false
+	}
+	return &collection.ID, true
+}
+
+// duplicateName tells whether err is the unique index on the user's collection
+// names, SQLite reports it differently from Postgres
+func duplicateName(err error) bool {
+	return strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
@@ -0,0 +1,443 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/realtime"
+	"github.com/trung/backend-engineerpro/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ConversationController struct {
+	DB  *gorm.DB
+	Hub *realtime.Hub
+}
+
+func NewConversationController(DB *gorm.DB, Hub *realtime.Hub) ConversationController {
+	return ConversationController{DB, Hub}
+}
+
+// CreateConversation starts a 1:1 conversation when one user is given, or
+// returns the existing one, and a group conversation otherwise
+func (cc *ConversationController) CreateConversation(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var payload *models.CreateConversationInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+
+	memberIDs := []uuid.UUID{currentUser.ID}
+	seen := map[uuid.UUID]bool{currentUser.ID: true}
+	for _, id := range payload.UserIDs {
+		if !seen[id] {
+			seen[id] = true
+			memberIDs = append(memberIDs, id)
+		}
+	}
+	if len(memberIDs) < 2 {
+		ctx.Error(apperror.BadRequest("A conversation needs at least one other user"))
+		return
+	}
+	if len(memberIDs) > models.MaxGroupMembers {
+		ctx.Error(apperror.BadRequest("A group conversation can have at most 50 members"))
+		return
+	}
+
+	var found int64
+	if err := cc.DB.WithContext(ctx.Request.Context()).Model(&models.User{}).Where("id IN ?", memberIDs).Count(&found).Error; err != nil || int(found) != len(memberIDs) {
+		ctx.Error(apperror.NotFound("User not found"))
+		return
+	}
+
+	var blocks int64
+	err := cc.DB.WithContext(ctx.Request.Context()).Model(&models.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id IN ?) OR (blocked_id = ? AND blocker_id IN ?)", currentUser.ID, memberIDs, currentUser.ID, memberIDs).
+		Count(&blocks).Error
+	if err != nil || blocks > 0 {
+		ctx.Error(apperror.Forbidden("You cannot message one of these users"))
+		return
+	}
+
+	now := time.Now()
+	conversation := models.Conversation{
+		IsGroup:     len(memberIDs) > 2,
+		CreatedByID: currentUser.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if conversation.IsGroup {
+		conversation.Title = payload.Title
+	} else {
+		key := directKey(memberIDs[0], memberIDs[1])
+		conversation.DirectKey = &key
+	}
+
+	status := http.StatusCreated
+	err = cc.DB.WithContext(ctx.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&conversation)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			// The pair already talks, hand back their conversation
+			status = http.StatusOK
+			// Into a fresh struct, gorm would add the id the insert was given to the lookup
+			key := *conversation.DirectKey
+			conversation = models.Conversation{}
+			return tx.First(&conversation, "direct_key = ?", key).Error
+		}
+
+		members := make([]models.ConversationMember, len(memberIDs))
+		for i, id := range memberIDs {
+			members[i] = models.ConversationMember{ConversationID: conversation.ID, UserID: id, JoinedAt: now}
+		}
+		return tx.Create(&members).Error
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	responses, err := cc.conversationResponses(ctx, currentUser.ID, []models.Conversation{conversation})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(status, gin.H{"status": "success", "data": responses[0]})
+}
+
+// FindConversations lists the current user's conversations, latest activity first
+func (cc *ConversationController) FindConversations(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	query := cc.DB.WithContext(ctx.Request.Context()).Joins("JOIN conversation_members ON conversation_members.conversation_id = conversations.id").
+		Where("conversation_members.user_id = ?", currentUser.ID)
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		updatedAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			ctx.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+		query = query.Where("(conversations.updated_at, conversations.id) < (?, ?)", updatedAt, id)
+	}
+
+	var conversations []models.Conversation
+	if err := query.Order("conversations.updated_at DESC, conversations.id DESC").Limit(limit + 1).Find(&conversations).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	var nextCursor string
+	if len(conversations) > limit {
+		conversations = conversations[:limit]
+		last := conversations[len(conversations)-1]
+		nextCursor = utils.EncodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	responses, err := cc.conversationResponses(ctx, currentUser.ID, conversations)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(responses), "data": responses, "next_cursor": nextCursor})
+}
+
+func (cc *ConversationController) FindConversation(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	conversation, ok := cc.findConversation(ctx)
+	if !ok {
+		return
+	}
+
+	responses, err := cc.conversationResponses(ctx, currentUser.ID, []models.Conversation{conversation})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": responses[0]})
+}
+
+// FindMessages returns the message history, newest first. Messages from users
+// the current user blocked are left out.
+func (cc *ConversationController) FindMessages(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	conversation, ok := cc.findConversation(ctx)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	blocked := cc.DB.WithContext(ctx.Request.Context()).Model(&models.UserBlock{}).Select("blocked_id").Where("blocker_id = ?", currentUser.ID)
+	query := cc.DB.WithContext(ctx.Request.Context()).Where("conversation_id = ? AND sender_id NOT IN (?)", conversation.ID, blocked)
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		createdAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			ctx.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	var messages []models.Message
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	var nextCursor string
+	if len(messages) > limit {
+		messages = messages[:limit]
+		last := messages[len(messages)-1]
+		nextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(messages), "data": messages, "next_cursor": nextCursor})
+}
+
+// SendMessage stores the message and pushes it to the members over the
+// real-time channel. In a 1:1 conversation a block on either side stops it.
+func (cc *ConversationController) SendMessage(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	conversation, ok := cc.findConversation(ctx)
+	if !ok {
+		return
+	}
+
+	var payload *models.SendMessageInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+
+	var memberIDs []uuid.UUID
+	if err := cc.DB.WithContext(ctx.Request.Context()).Model(&models.ConversationMember{}).Where("conversation_id = ?", conversation.ID).Pluck("user_id", &memberIDs).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	// Members who blocked the sender, or were blocked by them, don't get the message pushed
+	var blockedIDs []uuid.UUID
+	err := cc.DB.WithContext(ctx.Request.Context()).Model(&models.UserBlock{}).Select("CASE WHEN blocker_id = ? THEN blocked_id ELSE blocker_id END", currentUser.ID).
+		Where("(blocker_id = ? AND blocked_id IN ?) OR (blocked_id = ? AND blocker_id IN ?)", currentUser.ID, memberIDs, currentUser.ID, memberIDs).
+		Scan(&blockedIDs).Error
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	if !conversation.IsGroup && len(blockedIDs) > 0 {
+		ctx.Error(apperror.Forbidden("You cannot message this user"))
+		return
+	}
+
+	now := time.Now()
+	message := models.Message{
+		ConversationID: conversation.ID,
+		SenderID:       currentUser.ID,
+		Content:        payload.Content,
+		CreatedAt:      now,
+	}
+	err = cc.DB.WithContext(ctx.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&conversation).Update("updated_at", now).Error; err != nil {
+			return err
+		}
+		// Sending a message means the sender has read everything before it
+		return tx.Model(&models.ConversationMember{}).Where("conversation_id = ? AND user_id = ?", conversation.ID, currentUser.ID).
+			Update("last_read_at", now).Error
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	skip := make(map[uuid.UUID]bool, len(blockedIDs))
+	for _, id := range blockedIDs {
+		skip[id] = true
+	}
+	for _, id := range memberIDs {
+		if !skip[id] {
+			cc.Hub.PublishUser(ctx.Request.Context(), id, realtime.EventMessage, message)
+		}
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": message})
+}
+
+// MarkRead moves the current user's read marker up to message_id, or to now
+// when no message is given, and sends a read receipt to the other members
+func (cc *ConversationController) MarkRead(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	conversation, ok := cc.findConversation(ctx)
+	if !ok {
+		return
+	}
+
+	var payload models.MarkReadInput
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			ctx.Error(apperror.Invalid(err))
+			return
+		}
+	}
+
+	readAt := time.Now()
+	if payload.MessageID != "" {
+		var message models.Message
+		if _, err := uuid.Parse(payload.MessageID); err != nil {
+			ctx.Error(apperror.NotFound("Message not found"))
+			return
+		}
+		if err := cc.DB.WithContext(ctx.Request.Context()).First(&message, "id = ? AND conversation_id = ?", payload.MessageID, conversation.ID).Error; err != nil {
+			ctx.Error(apperror.NotFound("Message not found"))
+			return
+		}
+		readAt = message.CreatedAt
+	}
+
+	// The marker only ever moves forward
+	result := cc.DB.WithContext(ctx.Request.Context()).Model(&models.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ? AND (last_read_at IS NULL OR last_read_at < ?)", conversation.ID, currentUser.ID, readAt).
+		Update("last_read_at", readAt)
+	if result.Error != nil {
+		ctx.Error(apperror.Internal(result.Error))
+		return
+	}
+
+	receipt := models.ReadReceipt{ConversationID: conversation.ID, UserID: currentUser.ID, LastReadAt: readAt}
+	if result.RowsAffected > 0 {
+		var memberIDs []uuid.UUID
+		cc.DB.WithContext(ctx.Request.Context()).Model(&models.ConversationMember{}).Where("conversation_id = ? AND user_id <> ?", conversation.ID, currentUser.ID).Pluck("user_id", &memberIDs)
+		for _, id := range memberIDs {
+			cc.Hub.PublishUser(ctx.Request.Context(), id, realtime.EventMessageRead, receipt)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": receipt})
+}
+
+// findConversation loads :conversationId if the current user is a member, or writes a 404
+func (cc *ConversationController) findConversation(ctx *gin.Context) (models.Conversation, bool) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var conversation models.Conversation
+	if _, err := uuid.Parse(ctx.Param("conversationId")); err != nil {
+		ctx.Error(apperror.NotFound("Conversation not found"))
+		return conversation, false
+	}
+
+	err := cc.DB.WithContext(ctx.Request.Context()).Joins("JOIN conversation_members ON conversation_members.conversation_id = conversations.id").
+		Where("conversations.id = ? AND conversation_members.user_id = ?", ctx.Param("conversationId"), currentUser.ID).
+		First(&conversation).Error
+	if err != nil {
+		ctx.Error(apperror.NotFound("Conversation not found"))
+		return conversation, false
+	}
+	return conversation, true
+}
+
+// conversationResponses adds the members, the last message and the current
+// user's unread count to each conversation
+func (cc *ConversationController) conversationResponses(ctx *gin.Context, userID uuid.UUID, conversations []models.Conversation) ([]models.ConversationResponse, error) {
+	responses := make([]models.ConversationResponse, len(conversations))
+	if len(conversations) == 0 {
+		return responses, nil
+	}
+
+	ids := make([]string, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+
+	var members []struct {
+		models.UserSummary
+		ConversationID string
+		LastReadAt     *time.Time
+	}
+	err := cc.DB.WithContext(ctx.Request.Context()).Model(&models.ConversationMember{}).
+		Select("conversation_members.conversation_id, conversation_members.last_read_at, users.id, users.name, users.username, users.profile_image").
+		Joins("JOIN users ON users.id = conversation_members.user_id").
+		Where("conversation_members.conversation_id IN ?", ids).
+		Order("conversation_members.joined_at").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Like FindMessages, previews and unread counts leave out the users userID blocked
+	blocked := cc.DB.WithContext(ctx.Request.Context()).Model(&models.UserBlock{}).Select("blocked_id").Where("blocker_id = ?", userID)
+
+	// The newest message of each conversation, picked by a correlated subquery
+	// so it runs on SQLite as well as Postgres
+	latest := cc.DB.Table("messages AS latest").Select("latest.id").
+		Where("latest.conversation_id = messages.conversation_id AND latest.sender_id NOT IN (?)", blocked).
+		Order("latest.created_at DESC, latest.id DESC").Limit(1)
+	var lastMessages []models.Message
+	err = cc.DB.WithContext(ctx.Request.Context()).
+		Where("messages.conversation_id IN ? AND messages.id = (?)", ids, latest).
+		Find(&lastMessages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var unread []struct {
+		ConversationID string
+		Count          int64
+	}
+	err = cc.DB.WithContext(ctx.Request.Context()).Model(&models.Message{}).
+		Select("messages.conversation_id, count(*) AS count").
+		Joins("JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id AND conversation_members.user_id = ?", userID).
+		Where("messages.conversation_id IN ? AND messages.sender_id <> ? AND messages.sender_id NOT IN (?)", ids, userID, blocked).
+		Where("conversation_members.last_read_at IS NULL OR messages.created_at > conversation_members.last_read_at").
+		Group("messages.conversation_id").
+		Scan(&unread).Error
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(conversations))
+	for i, conversation := range conversations {
+		index[conversation.ID] = i
+		responses[i] = models.ConversationResponse{Conversation: conversation, Members: []models.ConversationMemberResponse{}}
+	}
+	for _, member := range members {
+		i := index[member.ConversationID]
+		responses[i].Members = append(responses[i].Members, models.ConversationMemberResponse{UserSummary: member.UserSummary, LastReadAt: member.LastReadAt})
+	}
+	for j := range lastMessages {
+		responses[index[lastMessages[j].ConversationID]].LastMessage = &lastMessages[j]
+	}
+	for _, row := range unread {
+		responses[index[row.ConversationID]].UnreadCount = row.Count
+	}
+	return responses, nil
+}
+
+func directKey(a, b uuid.UUID) string {
+	ids := []string{a.String(), b.String()}
+	sort.Strings(ids)
+	return ids[0] + ":" + ids[1]
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/openapi"
+)
+
+type DocsController struct {
+	Spec *openapi.Document
+}
+
+func NewDocsController(Spec *openapi.Document) DocsController {
+	return DocsController{Spec}
+}
+
+func (dc *DocsController) OpenAPI(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, dc.Spec)
+}
+
+// SwaggerUI renders the OpenAPI document with Swagger UI loaded from a CDN
+func (dc *DocsController) SwaggerUI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerPage))
+}
+
+const swaggerPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Engineer Pro API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
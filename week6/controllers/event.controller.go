@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/realtime"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	maxWatchedPosts   = 50
+	// How long before the server's write timeout a stream is ended
+	writeTimeoutMargin = 5 * time.Second
+)
+
+type EventController struct {
+	Hub *realtime.Hub
+	// WriteTimeout is the server's, a stream is ended cleanly before it
+	// would be cut off mid write. Zero lets streams run until the client
+	// leaves.
+	WriteTimeout time.Duration
+}
+
+func NewEventController(Hub *realtime.Hub, WriteTimeout time.Duration) EventController {
+	return EventController{Hub, WriteTimeout}
+}
+
+// StreamEvents is a Server-Sent Events stream of the current user's feed
+// items and notifications, plus counter changes of the posts listed in
+// ?posts=<id>,<id>. A reconnecting client sends Last-Event-ID and gets the
+// user events it missed first.
+func (ec *EventController) StreamEvents(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	topics := []string{realtime.UserTopic(currentUser.ID)}
+	if posts := ctx.Query("posts"); posts != "" {
+		for i, id := range strings.Split(posts, ",") {
+			postID, err := uuid.Parse(strings.TrimSpace(id))
+			if err != nil || i >= maxWatchedPosts {
+				ctx.Error(apperror.BadRequest("posts must be at most 50 post ids separated by commas"))
+				return
+			}
+			topics = append(topics, realtime.PostTopic(postID))
+		}
+	}
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("last_event_id")
+	}
+	if lastEventID != "" && !realtime.ValidID(lastEventID) {
+		ctx.Error(apperror.BadRequest("Invalid Last-Event-ID"))
+		return
+	}
+
+	// Subscribe before replaying so nothing published in between is lost,
+	// duplicates are skipped by comparing ids
+	sub := ec.Hub.Subscribe(topics...)
+	defer ec.Hub.Unsubscribe(sub)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	w := ctx.Writer
+	io.WriteString(w, "retry: 3000\n\n")
+
+	if lastEventID != "" {
+		missed, err := ec.Hub.Replay(ctx.Request.Context(), currentUser.ID, lastEventID)
+		if err != nil {
+			io.WriteString(w, "event: error\ndata: {\"message\":\"could not replay missed events\"}\n\n")
+		}
+		for _, event := range missed {
+			if _, err := io.WriteString(w, event.Format()); err != nil {
+				return
+			}
+			lastEventID = event.ID
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+	if ec.WriteTimeout > writeTimeoutMargin {
+		timer := time.NewTimer(ec.WriteTimeout - writeTimeoutMargin)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-sub.Done():
+			// Too far behind or shutting down, the client reconnects and
+			// replays from its last id
+			return
+		case <-expired:
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case event := <-sub.C:
+			if event.ID != "" && lastEventID != "" && !realtime.After(event.ID, lastEventID) {
+				continue
+			}
+			if _, err := io.WriteString(w, event.Format()); err != nil {
+				return
+			}
+			if event.ID != "" {
+				lastEventID = event.ID
+			}
+		}
+		w.Flush()
+	}
+}
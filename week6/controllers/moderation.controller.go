@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/moderation"
+	"gorm.io/gorm"
+)
+
+type ModerationController struct {
+	DB         *gorm.DB
+	Moderation *moderation.Service
+}
+
+func NewModerationController(DB *gorm.DB, Moderation *moderation.Service) ModerationController {
+	return ModerationController{DB, Moderation}
+}
+
+func (mc *ModerationController) ReportPost(ctx *gin.Context) {
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	var post models.Post
+	if err := mc.DB.WithContext(ctx.Request.Context()).Select("id, user_id").First(&post, "id = ? AND status = ?", postId, models.PostPublished).Error; err != nil {
+		ctx.Error(apperror.NotFound("No post with that title exists"))
+		return
+	}
+	mc.fileReport(ctx, models.ReportTargetPost, post.ID.String(), post.UserID)
+}
+
+func (mc *ModerationController) ReportComment(ctx *gin.Context) {
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+	if _, err := uuid.Parse(ctx.Param("commentId")); err != nil {
+		ctx.Error(apperror.BadRequest("Invalid comment ID format"))
+		return
+	}
+
+	var comment models.Comment
+	err = mc.DB.WithContext(ctx.Request.Context()).Select("id, user_id").First(&comment, "id = ? AND post_id = ? AND hidden_at IS NULL", ctx.Param("commentId"), postId).Error
+	if err != nil {
+		ctx.Error(apperror.NotFound("Comment not found"))
+		return
+	}
+	mc.fileReport(ctx, models.ReportTargetComment, comment.ID, comment.UserID)
+}
+
+func (mc *ModerationController) ReportUser(ctx *gin.Context) {
+	var user models.User
+	if err := mc.DB.WithContext(ctx.Request.Context()).Select("id").First(&user, "id = ?", ctx.Param("userID")).Error; err != nil {
+		ctx.Error(apperror.NotFound("User not found"))
+		return
+	}
+	mc.fileReport(ctx, models.ReportTargetUser, user.ID.String(), user.ID)
+}
+
+func (mc *ModerationController) fileReport(ctx *gin.Context, targetType, targetID string, authorID uuid.UUID) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var payload models.ReportInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+	if authorID == currentUser.ID {
+		ctx.Error(apperror.BadRequest("You cannot report yourself"))
+		return
+	}
+
+	report := models.Report{
+		ReporterID: &currentUser.ID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		AuthorID:   authorID,
+		Reason:     payload.Reason,
+		Details:    payload.Details,
+	}
+	if _, err := mc.Moderation.Report(ctx.Request.Context(), &report); err != nil {
+		if errors.Is(err, moderation.ErrAlreadyReported) {
+			ctx.Error(apperror.Conflict("You already reported this"))
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": report})
+}
+
+// FindQueue lists the targets with open reports, the most reported first
+func (mc *ModerationController) FindQueue(ctx *gin.Context) {
+	intPage, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	intLimit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if intPage < 1 {
+		intPage = 1
+	}
+	if intLimit <= 0 || intLimit > 100 {
+		intLimit = 20
+	}
+
+	query := mc.DB.WithContext(ctx.Request.Context()).Model(&models.Report{}).
+		Select("target_type, target_id, author_id, count(*) AS reports_count, count(reporter_id) AS reporters_count, min(created_at) AS first_reported_at, max(created_at) AS last_reported_at").
+		Where("status = ?", models.ReportOpen)
+	if targetType := ctx.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	var items []models.ModerationItem
+	err := query.Group("target_type, target_id, author_id").
+		Order("reporters_count DESC, last_reported_at DESC").
+		Limit(intLimit).Offset((intPage - 1) * intLimit).Scan(&items).Error
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	if err := mc.describeItems(ctx, items); err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(items), "data": items})
+}
+
+// describeItems fills in the reasons given for each target and whether it is hidden
+func (mc *ModerationController) describeItems(ctx *gin.Context, items []models.ModerationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var targetIDs, postIDs, commentIDs []string
+	for _, item := range items {
+		targetIDs = append(targetIDs, item.TargetID)
+		switch item.TargetType {
+		case models.ReportTargetPost:
+			postIDs = append(postIDs, item.TargetID)
+		case models.ReportTargetComment:
+			commentIDs = append(commentIDs, item.TargetID)
+		}
+	}
+
+	var reasons []struct {
+		TargetID string
+		Reason   string
+		Count    int64
+	}
+	err := mc.DB.WithContext(ctx.Request.Context()).Model(&models.Report{}).Select("target_id, reason, count(*) AS count").
+		Where("status = ? AND target_id IN ?", models.ReportOpen, targetIDs).
+		Group("target_id, reason").Scan(&reasons).Error
+	if err != nil {
+		return err
+	}
+
+	hidden := map[string]bool{}
+	var hiddenIDs []string
+	if len(postIDs) > 0 {
+		if err := mc.DB.WithContext(ctx.Request.Context()).Model(&models.Post{}).Where("id IN ? AND status = ?", postIDs, models.PostHidden).Pluck("id", &hiddenIDs).Error; err != nil {
+			return err
+		}
+	}
+	if len(commentIDs) > 0 {
+		var hiddenComments []string
+		if err := mc.DB.WithContext(ctx.Request.Context()).Model(&models.Comment{}).Where("id IN ? AND hidden_at IS NOT NULL", commentIDs).Pluck("id", &hiddenComments).Error; err != nil {
+			return err
+		}
+		hiddenIDs = append(hiddenIDs, hiddenComments...)
+	}
+	for _, id := range hiddenIDs {
+		hidden[id] = true
+	}
+
+	for i := range items {
+		items[i].Reasons = map[string]int64{}
+		items[i].Hidden = hidden[items[i].TargetID]
+		for _, reason := range reasons {
+			if reason.TargetID == items[i].TargetID {
+				items[i].Reasons[reason.Reason] = reason.Count
+			}
+		}
+	}
+	return nil
+}
+
+// FindTargetReports shows a reported target, hidden or not, with every report ever filed on it
+func (mc *ModerationController) FindTargetReports(ctx *gin.Context) {
+	targetType, targetID, ok := moderationTarget(ctx)
+	if !ok {
+		return
+	}
+
+	var target interface{}
+	var err error
+	switch targetType {
+	case models.ReportTargetPost:
+		var post models.Post
+		err = mc.DB.WithContext(ctx.Request.Context()).Unscoped().First(&post, "id = ?", targetID).Error
+		target = post
+	case models.ReportTargetComment:
+		var comment models.Comment
+		err = mc.DB.WithContext(ctx.Request.Context()).Unscoped().First(&comment, "id = ?", targetID).Error
+		target = comment
+	default:
+		var user models.UserSummary
+		err = mc.DB.WithContext(ctx.Request.Context()).Model(&models.User{}).Select("id, name, username, profile_image").Where("id = ?", targetID).Take(&user).Error
+		target = user
+	}
+	if err != nil {
+		ctx.Error(apperror.NotFound("Reported " + targetType + " not found"))
+		return
+	}
+
+	var reports []models.Report
+	if err := mc.DB.WithContext(ctx.Request.Context()).Where("target_type = ? AND target_id = ?", targetType, targetID).Order("created_at DESC").Find(&reports).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"target": target, "reports": reports}})
+}
+
+// ResolveReports applies a moderator action to a target and closes its open reports
+func (mc *ModerationController) ResolveReports(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	targetType, targetID, ok := moderationTarget(ctx)
+	if !ok {
+		return
+	}
+
+	var payload models.ModerationActionInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+
+	err := mc.Moderation.Resolve(ctx.Request.Context(), targetType, targetID, payload.Action, currentUser.ID)
+	switch {
+	case errors.Is(err, moderation.ErrNoOpenReports):
+		ctx.Error(apperror.NotFound(err.Error()))
+		return
+	case errors.Is(err, moderation.ErrCannotHideUser), errors.Is(err, moderation.ErrCannotBanAdmin):
+		ctx.Error(apperror.BadRequest(err.Error()))
+		return
+	case err != nil:
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "Reports resolved"})
+}
+
+func (mc *ModerationController) UnbanUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("userID"))
+	if err != nil {
+		ctx.Error(apperror.NotFound("User not found"))
+		return
+	}
+
+	unbanned, err := mc.Moderation.Unban(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	if !unbanned {
+		ctx.Error(apperror.BadRequest("This user is not banned"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "Successfully unbanned the user"})
+}
+
+func moderationTarget(ctx *gin.Context) (string, string, bool) {
+	targetType := ctx.Param("targetType")
+	switch targetType {
+	case models.ReportTargetPost, models.ReportTargetComment, models.ReportTargetUser:
+	default:
+		ctx.Error(apperror.BadRequest("target type must be post, comment or user"))
+		return "", "", false
+	}
+
+	targetID, err := uuid.Parse(ctx.Param("targetId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid target ID format"))
+		return "", "", false
+	}
+	return targetType, targetID.String(), true
+}
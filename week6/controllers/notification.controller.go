@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/notifications"
+	"github.com/trung/backend-engineerpro/utils"
+	"gorm.io/gorm"
+)
+
+type NotificationController struct {
+	DB            *gorm.DB
+	Notifications *notifications.Service
+}
+
+func NewNotificationController(DB *gorm.DB, Notifications *notifications.Service) NotificationController {
+	return NotificationController{DB, Notifications}
+}
+
+// FindNotifications lists the current user's notifications, most recently
+// updated first. ?unread=true leaves out the ones already read.
+func (nc *NotificationController) FindNotifications(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	query := nc.DB.WithContext(ctx.Request.Context()).Where("user_id = ?", currentUser.ID)
+	if ctx.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		updatedAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			ctx.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+		query = query.Where("(updated_at, id) < (?, ?)", updatedAt, id)
+	}
+
+	var list []models.Notification
+	if err := query.Order("updated_at DESC, id DESC").Limit(limit + 1).Find(&list).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	var nextCursor string
+	if len(list) > limit {
+		list = list[:limit]
+		last := list[len(list)-1]
+		nextCursor = utils.EncodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	actorIDs := make([]uuid.UUID, len(list))
+	for i, notification := range list {
+		actorIDs[i] = notification.ActorID
+	}
+	var actors []models.User
+	if len(actorIDs) > 0 {
+		if err := nc.DB.WithContext(ctx.Request.Context()).Select("id, name, username, profile_image").Where("id IN ?", actorIDs).Find(&actors).Error; err != nil {
+			ctx.Error(apperror.Internal(err))
+			return
+		}
+	}
+	actorsByID := make(map[uuid.UUID]models.User, len(actors))
+	for _, actor := range actors {
+		actorsByID[actor.ID] = actor
+	}
+
+	data := make([]models.NotificationResponse, len(list))
+	for i, notification := range list {
+		actor := actorsByID[notification.ActorID]
+		data[i] = models.NotificationResponse{
+			Notification: notification,
+			Actor: models.UserSummary{
+				ID:           actor.ID,
+				Name:         actor.Name,
+				Username:     actor.Username,
+				ProfileImage: actor.ProfileImage,
+			},
+			Message: notifications.Message(notification.Type, actor.Name, notification.ActorCount),
+		}
+	}
+
+	var unread int64
+	if err := nc.DB.WithContext(ctx.Request.Context()).Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", currentUser.ID).Count(&unread).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(data), "unread": unread, "data": data, "next_cursor": nextCursor})
+}
+
+func (nc *NotificationController) MarkRead(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	if _, err := uuid.Parse(ctx.Param("notificationId")); err != nil {
+		ctx.Error(apperror.NotFound("Notification not found"))
+		return
+	}
+
+	var notification models.Notification
+	if err := nc.DB.WithContext(ctx.Request.Context()).First(&notification, "id = ? AND user_id = ?", ctx.Param("notificationId"), currentUser.ID).Error; err != nil {
+		ctx.Error(apperror.NotFound("Notification not found"))
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := nc.DB.WithContext(ctx.Request.Context()).Model(&notification).Update("read_at", now).Error; err != nil {
+			ctx.Error(apperror.Internal(err))
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": notification})
+}
+
+// MarkAllRead marks every unread notification of the current user as read
+func (nc *NotificationController) MarkAllRead(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	result := nc.DB.WithContext(ctx.Request.Context()).Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", currentUser.ID).Update("read_at", time.Now())
+	if result.Error != nil {
+		ctx.Error(apperror.Internal(result.Error))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"updated": result.RowsAffected}})
+}
+
+func (nc *NotificationController) FindPreferences(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	preferences, err := nc.Notifications.Preferences(ctx.Request.Context(), currentUser.ID)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": preferences})
+}
+
+// UpdatePreferences turns notification types on or off, types left out keep their setting
+func (nc *NotificationController) UpdatePreferences(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var payload *models.NotificationPreferencesInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+	for notificationType := range payload.Preferences {
+		if !validNotificationType(notificationType) {
+			ctx.Error(apperror.BadRequest("Unknown notification type " + notificationType))
+			return
+		}
+	}
+
+	if err := nc.Notifications.SetPreferences(ctx.Request.Context(), currentUser.ID, payload.Preferences); err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	nc.FindPreferences(ctx)
+}
+
+func validNotificationType(notificationType string) bool {
+	for _, known := range models.NotificationTypes {
+		if known == notificationType {
+			return true
+		}
+	}
+	return false
+}
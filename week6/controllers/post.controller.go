@@ -2,28 +2,39 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/logging"
+	"github.com/trung/backend-engineerpro/metrics"
 	"github.com/trung/backend-engineerpro/models"
-	"gorm.io/gorm"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/services"
+	"github.com/trung/backend-engineerpro/utils"
+	"go.uber.org/zap"
 )
 
 type PostController struct {
-	DB    *gorm.DB
-	Redis *redis.Client
+	Posts     *services.PostService
+	Comments  *services.CommentService
+	Reactions *services.ReactionService
+	Redis     *redis.Client
 }
 
-func NewPostController(DB *gorm.DB, Redis *redis.Client) PostController {
+func NewPostController(Posts *services.PostService, Comments *services.CommentService, Reactions *services.ReactionService, Redis *redis.Client) PostController {
 	return PostController{
-		DB:    DB,
-		Redis: Redis,
+		Posts:     Posts,
+		Comments:  Comments,
+		Reactions: Reactions,
+		Redis:     Redis,
 	}
 }
 
@@ -32,27 +43,20 @@ func (pc *PostController) CreatePost(ctx *gin.Context) {
 	var payload *models.CreatePostRequest
 
 	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		ctx.JSON(http.StatusBadRequest, err.Error())
+		ctx.Error(apperror.Invalid(err))
 		return
 	}
 
-	now := time.Now()
-	newPost := models.Post{
-		Title:     payload.Title,
-		Content:   payload.Content,
-		Image:     payload.Image,
-		UserID:    currentUser.ID,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-
-	result := pc.DB.Create(&newPost)
-	if result.Error != nil {
-		if strings.Contains(result.Error.Error(), "duplicate key") {
-			ctx.JSON(http.StatusConflict, gin.H{"status": "fail", "message": "Post with that title already exists"})
-			return
-		}
-		ctx.JSON(http.StatusBadGateway, gin.H{"status": "error", "message": result.Error.Error()})
+	newPost, err := pc.Posts.Create(ctx.Request.Context(), currentUser.ID, *payload)
+	switch {
+	case errors.Is(err, services.ErrScheduleNeedsFuture):
+		ctx.Error(apperror.BadRequest(err.Error()))
+		return
+	case errors.Is(err, services.ErrTitleTaken):
+		ctx.Error(apperror.Conflict(err.Error()))
+		return
+	case err != nil:
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
@@ -60,42 +64,41 @@ func (pc *PostController) CreatePost(ctx *gin.Context) {
 }
 
 func (pc *PostController) UpdatePost(ctx *gin.Context) {
-	postId := ctx.Param("postId")
 	currentUser := ctx.MustGet("currentUser").(models.User)
 
 	var payload *models.UpdatePost
 	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		ctx.JSON(http.StatusBadGateway, gin.H{"status": "fail", "message": err.Error()})
+		ctx.Error(apperror.Invalid(err))
 		return
 	}
-	var updatedPost models.Post
-	result := pc.DB.Where("id = ? AND user_id = ?", postId, currentUser.ID).First(&updatedPost)
-	if result.Error != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "No post with that title exists"})
+	postId, ok := postIDParam(ctx)
+	if !ok {
 		return
 	}
-	now := time.Now()
-	postToUpdate := models.Post{
-		Title:     payload.Title,
-		Content:   payload.Content,
-		Image:     payload.Image,
-		UserID:    currentUser.ID,
-		CreatedAt: updatedPost.CreatedAt,
-		UpdatedAt: now,
-	}
 
-	pc.DB.Model(&updatedPost).Updates(postToUpdate)
+	updatedPost, err := pc.Posts.Update(ctx.Request.Context(), currentUser.ID, postId, *payload)
+	if !pc.postError(ctx, err) {
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": updatedPost})
 }
 
 func (pc *PostController) FindPostById(ctx *gin.Context) {
-	postId := ctx.Param("postId")
+	postId, ok := postIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// The author also gets to see how often the post was saved
+	var viewerID *uuid.UUID
+	if currentUser, ok := ctx.Get("currentUser"); ok {
+		id := currentUser.(models.User).ID
+		viewerID = &id
+	}
 
-	var post models.Post
-	result := pc.DB.First(&post, "id = ?", postId)
-	if result.Error != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "No post with that title exists"})
+	post, err := pc.Posts.Get(ctx.Request.Context(), postId, viewerID)
+	if !pc.postError(ctx, err) {
 		return
 	}
 
@@ -108,194 +111,370 @@ func (pc *PostController) FindPosts(ctx *gin.Context) {
 
 	intPage, _ := strconv.Atoi(page)
 	intLimit, _ := strconv.Atoi(limit)
-	offset := (intPage - 1) * intLimit
 
 	// Generate Redis key using page and limit
 	cacheKey := fmt.Sprintf("posts:page:%d:limit:%d", intPage, intLimit)
 
-	// Try to get cached data from Redis
-	cachedPosts, err := pc.Redis.Get(ctx, cacheKey).Result()
-	if err == nil && cachedPosts != "" {
-		// If data is found in cache, return the cached data
-		var posts []models.Post
-		// Unmarshal the cached JSON data into the posts struct
-		if err := json.Unmarshal([]byte(cachedPosts), &posts); err == nil {
-			ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(posts), "data": posts})
-			return
-		}
+	// Try to get cached data from Redis, unless the breaker says it is down
+	if posts, ok := pc.cachedPosts(ctx, cacheKey); ok {
+		ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(posts), "data": posts})
+		return
 	}
 
 	// If cache miss or unmarshaling fails, query the database
+	posts, err := pc.Posts.List(ctx.Request.Context(), intPage, intLimit)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	pc.cachePosts(ctx, cacheKey, posts)
+
+	// Return the result from the database
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(posts), "data": posts})
+}
+
+func (pc *PostController) cachedPosts(ctx *gin.Context, cacheKey string) ([]models.Post, bool) {
+	if !initializers.RedisAvailable() {
+		metrics.CacheRequests.WithLabelValues("posts", metrics.Error).Inc()
+		return nil, false
+	}
+
+	redisCtx, cancel := initializers.RedisContext(ctx.Request.Context())
+	defer cancel()
+
+	cached, err := pc.Redis.Get(redisCtx, cacheKey).Result()
+	if err == redis.Nil {
+		metrics.CacheRequests.WithLabelValues("posts", metrics.Miss).Inc()
+		return nil, false
+	}
+	if err != nil {
+		metrics.CacheRequests.WithLabelValues("posts", metrics.Error).Inc()
+		logging.FromContext(ctx).Warn("posts cache: get failed", zap.String("key", cacheKey), zap.Error(err))
+		return nil, false
+	}
+
+	// Unmarshal the cached JSON data into the posts struct
 	var posts []models.Post
-	results := pc.DB.Preload("Likes").Preload("Comments").Limit(intLimit).Offset(offset).Find(&posts)
-	if results.Error != nil {
-		ctx.JSON(http.StatusBadGateway, gin.H{"status": "error", "message": results.Error})
+	if err := json.Unmarshal([]byte(cached), &posts); err != nil {
+		metrics.CacheRequests.WithLabelValues("posts", metrics.Miss).Inc()
+		logging.FromContext(ctx).Warn("posts cache: decode failed", zap.String("key", cacheKey), zap.Error(err))
+		return nil, false
+	}
+	metrics.CacheRequests.WithLabelValues("posts", metrics.Hit).Inc()
+	return posts, true
+}
+
+func (pc *PostController) cachePosts(ctx *gin.Context, cacheKey string, posts []models.Post) {
+	if !initializers.RedisAvailable() {
 		return
 	}
 
 	// Marshal the result to JSON for caching
 	postsJSON, err := json.Marshal(posts)
-	if err == nil {
-		// Cache the result in Redis with a TTL (Time to Live) of 10 minutes
-		pc.Redis.Set(ctx, cacheKey, postsJSON, 10*time.Minute)
+	if err != nil {
+		return
 	}
 
-	// Return the result from the database
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(posts), "data": posts})
+	redisCtx, cancel := initializers.RedisContext(ctx.Request.Context())
+	defer cancel()
+
+	// Cache the result in Redis with a TTL (Time to Live) of 10 minutes
+	if err := pc.Redis.Set(redisCtx, cacheKey, postsJSON, 10*time.Minute).Err(); err != nil {
+		logging.FromContext(ctx).Warn("posts cache: set failed", zap.String("key", cacheKey), zap.Error(err))
+	}
 }
 
 func (pc *PostController) DeletePost(ctx *gin.Context) {
-	postId := ctx.Param("postId")
 	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, ok := postIDParam(ctx)
+	if !ok {
+		return
+	}
 
-	result := pc.DB.Where("id = ? AND user_id = ?", postId, currentUser.ID).Delete(&models.Post{})
-
-	if result.Error != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "No post with that title exists"})
+	// Posts go to the trash first, the purge job removes them for good later
+	if !pc.postError(ctx, pc.Posts.Delete(ctx.Request.Context(), currentUser.ID, postId)) {
 		return
 	}
 
 	ctx.JSON(http.StatusNoContent, nil)
 }
 
-func (pc *PostController) ToggleLike(ctx *gin.Context) {
-	var like models.Like
-	postIdStr := ctx.Param("postId")
+// FindDrafts lists the current user's drafts and scheduled posts
+func (pc *PostController) FindDrafts(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
-	postId, err := uuid.Parse(postIdStr)
 
+	posts, err := pc.Posts.Drafts(ctx.Request.Context(), currentUser.ID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "Invalid post ID format"})
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
-	// check if user already like this page
-	result := pc.DB.Where("post_id = ? AND user_id = ?", postId, currentUser.ID).First(&like)
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(posts), "data": posts})
+}
 
-	if result.RowsAffected == 0 {
-		// Case 1: The user hasn't liked the post yet, so create new Like
-		now := time.Now()
-		newLike := models.Like{
-			PostID:    postId,
-			UserID:    currentUser.ID,
-			CreateAt:  now,
-			UpdatedAt: now,
-		}
+func (pc *PostController) SchedulePost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
 
-		if err := pc.DB.Create(&newLike).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"status": "fail", "message": "Failed to like the post"})
-			return
-		}
-		ctx.JSON(http.StatusOK, gin.H{"message": "Post like update successfully"})
-	} else {
-		// Case 2: User already liked the post
-		if err := pc.DB.Delete(&like).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"status": "fail", "message": "Failed to dislike the post"})
-			return
-		}
-		ctx.JSON(http.StatusOK, gin.H{"message": "Post like update successfully"})
+	var payload *models.SchedulePostInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
 	}
+	postId, ok := postIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	post, err := pc.Posts.Schedule(ctx.Request.Context(), currentUser.ID, postId, payload.PublishAt)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": post})
 }
 
-func (pc *PostController) AddComment(ctx *gin.Context) {
-	postIdStr := ctx.Param("postId")
+// UnschedulePost moves a scheduled post back to the drafts
+func (pc *PostController) UnschedulePost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, ok := postIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	post, err := pc.Posts.Unschedule(ctx.Request.Context(), currentUser.ID, postId)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": post})
+}
+
+// PublishPost publishes a draft or scheduled post right away
+func (pc *PostController) PublishPost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, ok := postIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	post, err := pc.Posts.Publish(ctx.Request.Context(), currentUser.ID, postId)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": post})
+}
+
+// FindArchived lists the current user's archived posts
+func (pc *PostController) FindArchived(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
-	postId, err := uuid.Parse(postIdStr)
 
+	posts, err := pc.Posts.Archived(ctx.Request.Context(), currentUser.ID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "Invalid post ID format"})
+		ctx.Error(apperror.Internal(err))
 		return
 	}
-	now := time.Now()
-	var payload *models.CreateComment
 
-	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		ctx.JSON(http.StatusBadRequest, err.Error())
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(posts), "data": posts})
+}
+
+// ArchivePost hides a published post from everyone but its author
+func (pc *PostController) ArchivePost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, ok := postIDParam(ctx)
+	if !ok {
 		return
 	}
 
-	newComment := models.Comment{
-		PostID:    postId,
-		UserID:    currentUser.ID,
-		Content:   payload.Content,
-		CreateAt:  now,
-		UpdatedAt: now,
+	post, err := pc.Posts.Archive(ctx.Request.Context(), currentUser.ID, postId)
+	if !pc.postError(ctx, err) {
+		return
 	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": post})
+}
 
-	if err := pc.DB.Create(&newComment).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "Could not add new comment"})
+// UnarchivePost publishes an archived post again
+func (pc *PostController) UnarchivePost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, ok := postIDParam(ctx)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": newComment})
+	post, err := pc.Posts.Unarchive(ctx.Request.Context(), currentUser.ID, postId)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": post})
 }
 
-func (pc *PostController) UpdateComment(ctx *gin.Context) {
-	postIdStr := ctx.Param("postId")
-	commentId := ctx.Param("commentId")
+// postIDParam parses :postId, a malformed one is a post that doesn't exist
+func postIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.NotFound(services.ErrPostNotFound.Error()))
+		return postId, false
+	}
+	return postId, true
+}
+
+// postError hands an error of the post service to the error handler, it
+// returns whether there was none
+func (pc *PostController) postError(ctx *gin.Context, err error) bool {
+	var statusConflict *services.StatusConflictError
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrPostNotFound), errors.Is(err, services.ErrOriginalUnavailable), errors.Is(err, services.ErrRevisionNotFound),
+		errors.Is(err, services.ErrNotInTrash), errors.Is(err, services.ErrNotReposted):
+		ctx.Error(apperror.NotFound(err.Error()))
+	case errors.Is(err, services.ErrShareBlocked):
+		ctx.Error(apperror.Forbidden(err.Error()))
+	case errors.Is(err, services.ErrRepostNotEditable), errors.Is(err, services.ErrPublishAtInPast):
+		ctx.Error(apperror.BadRequest(err.Error()))
+	case errors.Is(err, services.ErrTitleTaken), errors.Is(err, services.ErrAlreadyReposted), errors.Is(err, services.ErrRestoreConflict),
+		errors.As(err, &statusConflict):
+		ctx.Error(apperror.Conflict(err.Error()))
+	default:
+		ctx.Error(apperror.Internal(err))
+	}
+	return false
+}
+
+// ToggleLike is kept for older clients, it removes any reaction or adds a "like"
+func (pc *PostController) ToggleLike(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
-	now := time.Now()
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
 
-	var payload *models.UpdateComment
-	var updatedComment models.Comment
-	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		ctx.JSON(http.StatusBadRequest, err.Error())
+	if err := pc.Reactions.ToggleLike(ctx.Request.Context(), currentUser.ID, postId); !pc.postError(ctx, err) {
 		return
 	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Post like update successfully"})
+}
 
-	// Parse the postId from string to UUID
-	postId, err := uuid.Parse(postIdStr)
+func (pc *PostController) AddComment(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "Invalid post ID format"})
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
 		return
 	}
 
-	result := pc.DB.Where("id = ? AND post_id = ? AND user_id = ?", commentId, postId, currentUser.ID).First(&updatedComment)
+	var payload *models.CreateComment
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
 
-	if result.Error != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "Comment not exists"})
+	comment, err := pc.Comments.Add(ctx.Request.Context(), currentUser.ID, postId, *payload)
+	if !pc.commentError(ctx, err) {
 		return
 	}
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": comment})
+}
+
+func (pc *PostController) UpdateComment(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
 
-	commentToUpdate := models.Comment{
-		PostID:    postId,
-		UserID:    currentUser.ID,
-		UpdatedAt: now,
-		Content:   payload.Content,
+	var payload *models.UpdateComment
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
 	}
 
-	pc.DB.Where("id = ? AND post_id = ? AND user_id = ?", commentId, postId, currentUser.ID).Model(&updatedComment).Updates(commentToUpdate)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
 
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": updatedComment})
+	comment, err := pc.Comments.Update(ctx.Request.Context(), currentUser.ID, postId, ctx.Param("commentId"), payload.Content)
+	if !pc.commentError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": comment})
 }
 
+// DeleteComment moves the comment to the trash together with every reply below it
 func (pc *PostController) DeleteComment(ctx *gin.Context) {
-	postIdStr := ctx.Param("postId")
-	commentId := ctx.Param("commentId")
 	currentUser := ctx.MustGet("currentUser").(models.User)
-
-	// Parse the postId from string to UUID
-	postId, err := uuid.Parse(postIdStr)
+	postId, err := uuid.Parse(ctx.Param("postId"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "Invalid post ID format"})
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
 		return
 	}
 
-	// Attempt to delete the comment with the matching commentId, postId, and userId
-	result := pc.DB.Where("id = ? AND post_id = ? AND user_id = ?", commentId, postId, currentUser.ID).Delete(&models.Comment{})
+	if err := pc.Comments.Delete(ctx.Request.Context(), currentUser.ID, postId, ctx.Param("commentId")); !pc.commentError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// FindComments lists the top-level comments of a post, oldest first
+func (pc *PostController) FindComments(ctx *gin.Context) {
+	pc.listComments(ctx, "")
+}
+
+// FindReplies lists the direct replies of a comment, oldest first
+func (pc *PostController) FindReplies(ctx *gin.Context) {
+	pc.listComments(ctx, ctx.Param("commentId"))
+}
 
-	// Check if any row was affected (meaning comment was found and deleted)
-	if result.RowsAffected == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "Comment not found or not authorized to delete"})
+func (pc *PostController) listComments(ctx *gin.Context, parentID string) {
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
 		return
 	}
 
-	// Handle any potential database errors
-	if result.Error != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "fail", "message": "Something went wrong, cannot delete comment"})
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	var after *repository.Cursor
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		createdAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			ctx.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+		after = &repository.Cursor{CreatedAt: createdAt, ID: id}
+	}
+
+	// Fetch one extra row to know whether there is another page
+	data, err := pc.Comments.List(ctx.Request.Context(), postId, parentID, after, limit+1)
+	if !pc.commentError(ctx, err) {
 		return
 	}
 
-	// Successful deletion, returning no content
-	ctx.JSON(http.StatusNoContent, nil)
+	var nextCursor string
+	if len(data) > limit {
+		data = data[:limit]
+		last := data[len(data)-1]
+		nextCursor = utils.EncodeCursor(last.CreateAt, last.ID)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(data), "data": data, "next_cursor": nextCursor})
+}
+
+// commentError hands an error of the comment service to the error handler,
+// it returns whether there was none
+func (pc *PostController) commentError(ctx *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrPostNotFound), errors.Is(err, services.ErrCommentNotFound), errors.Is(err, services.ErrParentNotFound),
+		errors.Is(err, services.ErrRevisionNotFound), errors.Is(err, services.ErrCommentNotInTrash):
+		ctx.Error(apperror.NotFound(err.Error()))
+	case errors.Is(err, services.ErrRestoreParentFirst):
+		ctx.Error(apperror.Conflict(err.Error()))
+	case errors.Is(err, services.ErrReplyTooDeep):
+		ctx.Error(apperror.BadRequest(err.Error()))
+	default:
+		ctx.Error(apperror.Internal(err))
+	}
+	return false
 }
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/utils"
+)
+
+// SetPostReaction adds or replaces the current user's reaction, calling it twice is a no-op
+func (pc *PostController) SetPostReaction(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	var payload *models.ReactionInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+
+	reaction, err := pc.Reactions.SetPostReaction(ctx.Request.Context(), currentUser.ID, postId, payload.Type)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": reaction})
+}
+
+// RemovePostReaction succeeds whether or not the user had reacted
+func (pc *PostController) RemovePostReaction(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	if err := pc.Reactions.RemovePostReaction(ctx.Request.Context(), currentUser.ID, postId); err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// FindPostReactions lists who reacted to a post, newest first, optionally for one type
+func (pc *PostController) FindPostReactions(ctx *gin.Context) {
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	pc.listReactors(ctx, func(after *repository.Cursor, limit int) ([]models.ReactorResponse, error) {
+		return pc.Reactions.PostReactors(ctx.Request.Context(), postId, ctx.Query("type"), after, limit)
+	})
+}
+
+func (pc *PostController) SetCommentReaction(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	comment, ok := pc.findComment(ctx)
+	if !ok {
+		return
+	}
+
+	var payload *models.ReactionInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+
+	reaction, err := pc.Reactions.SetCommentReaction(ctx.Request.Context(), currentUser.ID, comment, payload.Type)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": reaction})
+}
+
+func (pc *PostController) RemoveCommentReaction(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	comment, ok := pc.findComment(ctx)
+	if !ok {
+		return
+	}
+
+	if err := pc.Reactions.RemoveCommentReaction(ctx.Request.Context(), currentUser.ID, comment); err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (pc *PostController) FindCommentReactions(ctx *gin.Context) {
+	comment, ok := pc.findComment(ctx)
+	if !ok {
+		return
+	}
+
+	pc.listReactors(ctx, func(after *repository.Cursor, limit int) ([]models.ReactorResponse, error) {
+		return pc.Reactions.CommentReactors(ctx.Request.Context(), comment, ctx.Query("type"), after, limit)
+	})
+}
+
+// findComment loads the :commentId comment of the :postId post or writes the error response
+func (pc *PostController) findComment(ctx *gin.Context) (models.Comment, bool) {
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return models.Comment{}, false
+	}
+	if _, err := uuid.Parse(ctx.Param("commentId")); err != nil {
+		ctx.Error(apperror.BadRequest("Invalid comment ID format"))
+		return models.Comment{}, false
+	}
+
+	comment, err := pc.Comments.Find(ctx.Request.Context(), postId, ctx.Param("commentId"))
+	return comment, pc.commentError(ctx, err)
+}
+
+// listReactors writes a page of reactors, list fetches them after the cursor
+func (pc *PostController) listReactors(ctx *gin.Context, list func(after *repository.Cursor, limit int) ([]models.ReactorResponse, error)) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	var after *repository.Cursor
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		createdAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			ctx.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+		after = &repository.Cursor{CreatedAt: createdAt, ID: id}
+	}
+
+	// Fetch one extra row to know whether there is another page
+	reactors, err := list(after, limit+1)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	var nextCursor string
+	if len(reactors) > limit {
+		reactors = reactors[:limit]
+		last := reactors[len(reactors)-1]
+		nextCursor = utils.EncodeCursor(last.ReactedAt, last.ID)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(reactors), "data": reactors, "next_cursor": nextCursor})
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/services"
+	"gorm.io/gorm"
+)
+
+// Repost shares a post with the current user's followers
+func (pc *PostController) Repost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	repost, err := pc.Posts.Repost(ctx.Request.Context(), currentUser.ID, postId)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": repost})
+}
+
+// Unrepost takes the current user's repost of the post back
+func (pc *PostController) Unrepost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	err = pc.Posts.Unrepost(ctx.Request.Context(), currentUser.ID, postId)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// QuotePost publishes a new post with the current user's comment on top of the shared one
+func (pc *PostController) QuotePost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var payload *models.QuotePostInput
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(apperror.Invalid(err))
+		return
+	}
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	quote, err := pc.Posts.Quote(ctx.Request.Context(), currentUser.ID, postId, *payload)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": quote})
+}
+
+// attachOriginals embeds the shared post into every repost and quote, for
+// the controllers that still query posts themselves
+func attachOriginals(ctx context.Context, db *gorm.DB, posts []models.Post) error {
+	return services.AttachOriginals(ctx, repository.NewPostRepository(db), posts)
+}
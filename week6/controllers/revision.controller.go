@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+)
+
+// FindPostRevisions lists the earlier versions of a post, newest first, each
+// with the diff to the version that replaced it
+func (pc *PostController) FindPostRevisions(ctx *gin.Context) {
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	revisions, err := pc.Posts.Revisions(ctx.Request.Context(), postId)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(revisions), "data": revisions})
+}
+
+// RestorePostRevision brings back an earlier version, the replaced text becomes a revision itself
+func (pc *PostController) RestorePostRevision(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, ok := postIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	post, err := pc.Posts.RestoreRevision(ctx.Request.Context(), currentUser.ID, postId, ctx.Param("revisionId"))
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": post})
+}
+
+func (pc *PostController) FindCommentRevisions(ctx *gin.Context) {
+	comment, ok := pc.findComment(ctx)
+	if !ok {
+		return
+	}
+
+	revisions, err := pc.Comments.Revisions(ctx.Request.Context(), comment)
+	if !pc.commentError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(revisions), "data": revisions})
+}
+
+func (pc *PostController) RestoreCommentRevision(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	comment, ok := pc.findComment(ctx)
+	if !ok {
+		return
+	}
+
+	comment, err := pc.Comments.RestoreRevision(ctx.Request.Context(), currentUser.ID, comment, ctx.Param("revisionId"))
+	if !pc.commentError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": comment})
+}
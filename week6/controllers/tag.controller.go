@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/tags"
+	"github.com/trung/backend-engineerpro/utils"
+	"gorm.io/gorm"
+)
+
+type TagController struct {
+	DB   *gorm.DB
+	Tags *tags.Service
+}
+
+func NewTagController(DB *gorm.DB, Tags *tags.Service) TagController {
+	return TagController{DB, Tags}
+}
+
+// FindTrending returns the most used tags, ?window=24h accepts 1h up to 168h
+func (tc *TagController) FindTrending(ctx *gin.Context) {
+	window, err := time.ParseDuration(ctx.DefaultQuery("window", "24h"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid window, use a duration like 24h"))
+		return
+	}
+	if window < time.Hour {
+		window = time.Hour
+	}
+	if window > tags.MaxWindow {
+		window = tags.MaxWindow
+	}
+	window = window.Truncate(time.Hour)
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	trending, err := tc.Tags.Trending(ctx.Request.Context(), window, limit)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(trending), "data": trending})
+}
+
+// FindTagPosts lists the published posts carrying a tag, newest first
+func (tc *TagController) FindTagPosts(ctx *gin.Context) {
+	name := strings.ToLower(strings.TrimPrefix(ctx.Param("tag"), "#"))
+
+	var tag models.Tag
+	if err := tc.DB.WithContext(ctx.Request.Context()).First(&tag, "name = ?", name).Error; err != nil {
+		ctx.Error(apperror.NotFound("No post with that tag exists"))
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	query := tc.DB.WithContext(ctx.Request.Context()).Joins("JOIN post_tags ON post_tags.post_id = posts.id").
+		Where("post_tags.tag_id = ? AND posts.status = ?", tag.ID, models.PostPublished)
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		publishedAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			ctx.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+		query = query.Where("(posts.published_at, posts.id) < (?, ?)", publishedAt, id)
+	}
+
+	var posts []models.Post
+	if err := query.Order("posts.published_at DESC, posts.id DESC").Limit(limit + 1).Find(&posts).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	if err := attachOriginals(ctx.Request.Context(), tc.DB, posts); err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	var nextCursor string
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		if last.PublishedAt != nil {
+			nextCursor = utils.EncodeCursor(*last.PublishedAt, last.ID.String())
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(posts), "data": posts, "next_cursor": nextCursor})
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+)
+
+// FindTrash lists the current user's deleted posts and comments that can still be restored
+func (pc *PostController) FindTrash(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	var trash models.TrashResponse
+	var err error
+	trash.Posts, err = pc.Posts.Trashed(ctx.Request.Context(), currentUser.ID)
+	if err == nil {
+		trash.Comments, err = pc.Comments.Trashed(ctx.Request.Context(), currentUser.ID)
+	}
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": trash})
+}
+
+func (pc *PostController) RestorePost(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+
+	post, err := pc.Posts.Restore(ctx.Request.Context(), currentUser.ID, postId)
+	if !pc.postError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": post})
+}
+
+// RestoreComment brings back a deleted comment along with the replies deleted with it
+func (pc *PostController) RestoreComment(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+	postId, err := uuid.Parse(ctx.Param("postId"))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid post ID format"))
+		return
+	}
+	if _, err := uuid.Parse(ctx.Param("commentId")); err != nil {
+		ctx.Error(apperror.BadRequest("Invalid comment ID format"))
+		return
+	}
+
+	comment, err := pc.Comments.Restore(ctx.Request.Context(), currentUser.ID, postId, ctx.Param("commentId"))
+	if !pc.commentError(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": comment})
+}
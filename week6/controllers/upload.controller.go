@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/storage"
+	"github.com/trung/backend-engineerpro/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	maxImageSize   = 2048
+	thumbnailSize  = 320
+	avatarSize     = 512
+	avatarThumb    = 128
+	multipartSlack = 1 << 20
+)
+
+type UploadController struct {
+	DB      *gorm.DB
+	Store   storage.BlobStore
+	MaxSize int64
+}
+
+func NewUploadController(DB *gorm.DB, Store storage.BlobStore, MaxSize int64) UploadController {
+	return UploadController{DB: DB, Store: Store, MaxSize: MaxSize}
+}
+
+// UploadImage stores an image for a post, the returned url goes into CreatePostRequest.Image
+func (uc *UploadController) UploadImage(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	upload, ok := uc.storeImage(ctx, "images/"+currentUser.ID.String(), maxImageSize, thumbnailSize)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": upload})
+}
+
+// UploadAvatar stores a new profile picture and points the user's ProfileImage at it
+func (uc *UploadController) UploadAvatar(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	upload, ok := uc.storeImage(ctx, "avatars/"+currentUser.ID.String(), avatarSize, avatarThumb)
+	if !ok {
+		return
+	}
+
+	currentUser.ProfileImage = upload.URL
+	currentUser.UpdatedAt = time.Now()
+	if err := uc.DB.WithContext(ctx.Request.Context()).Model(&currentUser).Updates(map[string]interface{}{"profile_image": currentUser.ProfileImage, "updated_at": currentUser.UpdatedAt}).Error; err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	userResponse := &models.UserResponse{
+		ID:           currentUser.ID,
+		Name:         currentUser.Name,
+		Username:     currentUser.Username,
+		Age:          currentUser.Age,
+		Email:        currentUser.Email,
+		ProfileImage: currentUser.ProfileImage,
+		Role:         currentUser.Role,
+		Provider:     currentUser.Provider,
+		CreatedAt:    currentUser.CreatedAt,
+		UpdatedAt:    currentUser.UpdatedAt,
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": userResponse, "upload": upload}})
+}
+
+// storeImage reads the "file" form field, validates and processes it and saves
+// the image and its thumbnail under prefix. It writes the error response itself.
+func (uc *UploadController) storeImage(ctx *gin.Context, prefix string, size, thumbSize int) (*models.UploadResponse, bool) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, uc.MaxSize+multipartSlack)
+
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			ctx.Error(apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge, fmt.Sprintf("File is larger than %d bytes", uc.MaxSize)))
+			return nil, false
+		}
+		ctx.Error(apperror.BadRequest("Missing file field"))
+		return nil, false
+	}
+	defer file.Close()
+
+	if header.Size > uc.MaxSize {
+		ctx.Error(apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge, fmt.Sprintf("File is larger than %d bytes", uc.MaxSize)))
+		return nil, false
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, uc.MaxSize+1))
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Could not read the uploaded file"))
+		return nil, false
+	}
+	if int64(len(data)) > uc.MaxSize {
+		ctx.Error(apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge, fmt.Sprintf("File is larger than %d bytes", uc.MaxSize)))
+		return nil, false
+	}
+
+	if _, err := utils.SniffImageType(data); err != nil {
+		ctx.Error(apperror.New(http.StatusUnsupportedMediaType, apperror.CodeUnsupportedMediaType, err.Error()))
+		return nil, false
+	}
+
+	full, thumb, err := utils.ProcessImage(data, size, thumbSize)
+	if err != nil {
+		ctx.Error(apperror.New(http.StatusUnprocessableEntity, apperror.CodeUnprocessable, err.Error()))
+		return nil, false
+	}
+
+	name := prefix + "/" + uuid.New().String()
+	key := name + full.Extension
+	thumbKey := name + "_thumb" + thumb.Extension
+
+	if err := uc.Store.Put(ctx.Request.Context(), key, bytes.NewReader(full.Data), int64(len(full.Data)), full.ContentType); err != nil {
+		ctx.Error(apperror.Internal(err))
+		return nil, false
+	}
+	if err := uc.Store.Put(ctx.Request.Context(), thumbKey, bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+		uc.Store.Delete(ctx.Request.Context(), key)
+		ctx.Error(apperror.Internal(err))
+		return nil, false
+	}
+
+	return &models.UploadResponse{
+		URL:          uc.Store.URL(key),
+		ThumbnailURL: uc.Store.URL(thumbKey),
+		ContentType:  full.ContentType,
+		Width:        full.Width,
+		Height:       full.Height,
+		Size:         int64(len(full.Data)),
+	}, true
+}
@@ -1,185 +1,250 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/feed"
 	"github.com/trung/backend-engineerpro/models"
-	"gorm.io/gorm"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/services"
+	"github.com/trung/backend-engineerpro/utils"
 )
 
 type UserController struct {
-	DB *gorm.DB
+	Users *services.UserService
+	Feed  *feed.Feed
+	Posts *services.PostService
 }
 
-func NewUserController(DB *gorm.DB) UserController {
-	return UserController{DB}
+func NewUserController(Users *services.UserService, Feed *feed.Feed, Posts *services.PostService) UserController {
+	return UserController{Users, Feed, Posts}
 }
 
 func (uc *UserController) UserProfile(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
 
-	userResponse := &models.UserResponse{
-		ID:           currentUser.ID,
-		Name:         currentUser.Name,
-		Age:          currentUser.Age,
-		Email:        currentUser.Email,
-		ProfileImage: currentUser.ProfileImage,
-		Role:         currentUser.Role,
-		Provider:     currentUser.Provider,
-		CreatedAt:    currentUser.CreatedAt,
-		UpdatedAt:    currentUser.UpdatedAt,
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": userResponse}})
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": userResponse(currentUser)}})
 }
 
 func (uc *UserController) UpdateUserProfile(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User) // Get the current logged-in user
 
-	// Bind the incoming JSON body to a User struct
-	var updateData struct {
-		Name         string `json:"name"`
-		Age          int64  `json:"age"`
-		Email        string `json:"email"`
-		ProfileImage string `json:"profile_image"`
-	}
-
+	var updateData models.UpdateProfileInput
 	if err := ctx.ShouldBindJSON(&updateData); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid input"})
+		ctx.Error(apperror.Invalid(err))
 		return
 	}
 
-	// Update the fields if they are provided
-	if updateData.Name != "" {
-		currentUser.Name = updateData.Name
+	updatedUser, err := uc.Users.UpdateProfile(ctx.Request.Context(), currentUser, updateData)
+	switch {
+	case errors.Is(err, services.ErrInvalidUsername):
+		ctx.Error(apperror.BadRequest(err.Error()))
+		return
+	case errors.Is(err, services.ErrUsernameTaken):
+		ctx.Error(apperror.Conflict("Username already in use"))
+		return
+	case errors.Is(err, services.ErrEmailTaken):
+		ctx.Error(apperror.Conflict("Email already in use"))
+		return
+	case err != nil:
+		ctx.Error(apperror.Internal(err))
+		return
 	}
 
-	if updateData.Age != 0 {
-		currentUser.Age = updateData.Age
-	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": userResponse(updatedUser)}})
+}
 
-	if updateData.Email != "" {
-		// Check if the new email is already in use by another user
-		var existingUser models.User
-		if err := uc.DB.Where("email = ?", updateData.Email).First(&existingUser).Error; err == nil && existingUser.ID != currentUser.ID {
-			ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Email already in use"})
-			return
-		}
-		currentUser.Email = updateData.Email
+func (uc *UserController) FollowUser(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	// get user id that will set follow for current user
+	userID, err := uuid.Parse(ctx.Param("userID"))
+	if err != nil {
+		ctx.Error(apperror.NotFound("User that user wants to follow not found"))
+		return
 	}
 
-	if updateData.ProfileImage != "" {
-		currentUser.ProfileImage = updateData.ProfileImage
+	err = uc.Users.Follow(ctx.Request.Context(), currentUser.ID, userID)
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.Error(apperror.NotFound("User that user wants to follow not found"))
+		return
+	case errors.Is(err, services.ErrFollowBlocked):
+		ctx.Error(apperror.Forbidden(err.Error()))
+		return
+	case errors.Is(err, services.ErrFollowSelf), errors.Is(err, services.ErrAlreadyFollowing):
+		ctx.Error(apperror.BadRequest(err.Error()))
+		return
+	case err != nil:
+		ctx.Error(apperror.Internal(err))
+		return
 	}
 
-	// Save the updated user to the database
-	if err := uc.DB.Save(&currentUser).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Unable to update user profile"})
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "Successfully followed the user"})
+}
+
+func (uc *UserController) UnfollowerUser(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	userID, err := uuid.Parse(ctx.Param("userID"))
+	if err != nil {
+		ctx.Error(apperror.NotFound("User not found"))
 		return
 	}
 
-	// Prepare response
-	userResponse := &models.UserResponse{
-		ID:           currentUser.ID,
-		Name:         currentUser.Name,
-		Age:          currentUser.Age,
-		Email:        currentUser.Email,
-		ProfileImage: currentUser.ProfileImage,
-		Role:         currentUser.Role,
-		Provider:     currentUser.Provider,
-		CreatedAt:    currentUser.CreatedAt,
-		UpdatedAt:    currentUser.UpdatedAt,
+	err = uc.Users.Unfollow(ctx.Request.Context(), currentUser.ID, userID)
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.Error(apperror.NotFound(err.Error()))
+		return
+	case errors.Is(err, services.ErrNotFollowing):
+		ctx.Error(apperror.BadRequest(err.Error()))
+		return
+	case err != nil:
+		ctx.Error(apperror.Internal(err))
+		return
 	}
 
-	// Return success response
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": userResponse}})
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "Successfully unfollowed the user"})
 }
 
-func (uc *UserController) FollowUser(ctx *gin.Context) {
+func (uc *UserController) GetNewsFeed(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
 
-	// get user id that will set follow for current user
-	userID := ctx.Param("userID")
-
-	// check if user exists
-	var userToFollow models.User
-
-	if err := uc.DB.First(&userToFollow, "id = ?", userID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "User that user wants to follow not found"})
-		return
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
 	}
 
-	// check if current user is already following that user
-	var follow models.UserFollower
-	err := uc.DB.Where("follower_id = ? AND following_id = ?", currentUser.ID, userToFollow.ID).First(&follow).Error
+	// posts of the users current user is following, served from the cached feed when possible
+	posts, err := uc.Feed.Posts(ctx.Request.Context(), currentUser.ID, page, limit)
+	if err == nil {
+		err = uc.Posts.AttachOriginals(ctx.Request.Context(), posts)
+	}
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "You are already following this user"})
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
-	// Add new follow entry in the user_followers table
-	follow = models.UserFollower{
-		FollowerID:  currentUser.ID,
-		FollowingID: userToFollow.ID,
-		CreatedAt:   time.Now(),
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"newfeeds": posts}})
+}
+
+// FindMentions lists where the current user was @mentioned, newest first
+func (uc *UserController) FindMentions(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	var after *repository.Cursor
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		createdAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			ctx.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+		after = &repository.Cursor{CreatedAt: createdAt, ID: id}
 	}
 
-	if err := uc.DB.Create(&follow).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Unable to follow user"})
+	mentions, err := uc.Users.FindMentions(ctx.Request.Context(), currentUser.ID, after, limit+1)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "Successfully followed the user"})
+	var nextCursor string
+	if len(mentions) > limit {
+		mentions = mentions[:limit]
+		last := mentions[len(mentions)-1]
+		nextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(mentions), "data": mentions, "next_cursor": nextCursor})
 }
 
-func (uc *UserController) UnfollowerUser(ctx *gin.Context) {
+// BlockUser stops two users from following or messaging each other, any
+// follow between them is removed
+func (uc *UserController) BlockUser(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
 
-	userID := ctx.Param("userID")
+	userID, err := uuid.Parse(ctx.Param("userID"))
+	if err != nil {
+		ctx.Error(apperror.NotFound("User not found"))
+		return
+	}
 
-	var userToUnfollow models.User
-	// check if user exists
-	if err := uc.DB.First(&userToUnfollow, "id = ?", userID).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "User not found"})
+	err = uc.Users.Block(ctx.Request.Context(), currentUser.ID, userID)
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.Error(apperror.NotFound(err.Error()))
+		return
+	case errors.Is(err, services.ErrBlockSelf):
+		ctx.Error(apperror.BadRequest(err.Error()))
+		return
+	case err != nil:
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
-	// check if current user is actually following the user
-	var follow models.UserFollower
-	err := uc.DB.Where("follower_id = ? AND following = ?", currentUser.ID, userToUnfollow.ID).First(&follow).Error
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "Successfully blocked the user"})
+}
+
+func (uc *UserController) UnblockUser(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(models.User)
 
+	userID, err := uuid.Parse(ctx.Param("userID"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "You are not following this user"})
+		ctx.Error(apperror.NotFound("User not found"))
 		return
 	}
 
-	// delete the follow entry from user_followers table
-	if err := uc.DB.Delete(&follow).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Unable to unfollow user"})
+	err = uc.Users.Unblock(ctx.Request.Context(), currentUser.ID, userID)
+	if errors.Is(err, services.ErrNotBlocked) {
+		ctx.Error(apperror.BadRequest(err.Error()))
+		return
+	} else if err != nil {
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "Successfully unfollowed the user"})
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "Successfully unblocked the user"})
 }
 
-func (uc *UserController) GetNewsFeed(ctx *gin.Context) {
+// FindBlockedUsers lists the users the current user blocked
+func (uc *UserController) FindBlockedUsers(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(models.User)
 
-	// find the users that current user is following
-	var following []models.User
-	if err := uc.DB.Model(&currentUser).Association("Following").Find(&following); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Unable to get user following"})
+	users, err := uc.Users.FindBlocked(ctx.Request.Context(), currentUser.ID)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
-	// fetch the posts from fllowing list
-	var posts []models.Post
-	if err := uc.DB.Where("user_id IN ?", following).Order("created_at desc").Find(&posts).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Unable to get the posts"})
-		return
-	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "results": len(users), "data": users})
+}
 
-	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"newfeeds": posts}})
+func userResponse(user models.User) *models.UserResponse {
+	return &models.UserResponse{
+		ID:           user.ID,
+		Name:         user.Name,
+		Username:     user.Username,
+		Age:          user.Age,
+		Email:        user.Email,
+		ProfileImage: user.ProfileImage,
+		Role:         user.Role,
+		Provider:     user.Provider,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
 }
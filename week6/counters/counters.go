@@ -0,0 +1,301 @@
+package counters
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/logging"
+	"github.com/trung/backend-engineerpro/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Columns on posts that are maintained by the Store
+const (
+	Comments  = "comments_count"
+	Reactions = "reactions_count"
+	Reposts   = "reposts_count"
+)
+
+var fields = []string{Comments, Reactions, Reposts}
+
+const (
+	dirtyKey  = "counters:posts:dirty"
+	lockKey   = "counters:lock"
+	flushSize = 500
+
+	// lockTTL bounds how long a crashed instance can hold up the other
+	// instances' flushes and reconciles
+	lockTTL = 5 * time.Minute
+)
+
+// unlock releases the lock only if it is still ours, it may have expired and
+// been taken by another instance in the meantime
+var unlock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func postKey(id string) string {
+	return "counters:post:" + id
+}
+
+// Store keeps the denormalized post counters. Deltas are buffered in Redis and
+// written behind to Postgres by Flush; when Redis is unavailable they go to
+// Postgres straight away. Reconcile repairs whatever drift is left.
+type Store struct {
+	DB    *gorm.DB
+	Redis *redis.Client
+
+	// OnChange is called for every counter change, before it is persisted
+	OnChange func(ctx context.Context, postID uuid.UUID, field string, delta int64)
+}
+
+func NewStore(DB *gorm.DB, Redis *redis.Client) *Store {
+	return &Store{DB: DB, Redis: Redis}
+}
+
+func (s *Store) Incr(ctx context.Context, postID uuid.UUID, field string, delta int64) {
+	if delta == 0 {
+		return
+	}
+	if s.OnChange != nil {
+		s.OnChange(ctx, postID, field, delta)
+	}
+
+	if initializers.RedisAvailable() {
+		redisCtx, cancel := initializers.RedisContext(ctx)
+		defer cancel()
+
+		_, err := s.Redis.TxPipelined(redisCtx, func(pipe redis.Pipeliner) error {
+			pipe.HIncrBy(redisCtx, postKey(postID.String()), field, delta)
+			pipe.SAdd(redisCtx, dirtyKey, postID.String())
+			return nil
+		})
+		if err == nil {
+			return
+		}
+		logging.FromContext(ctx).Warn("counters: buffer failed", zap.String("field", field), zap.Stringer("post_id", postID), zap.Error(err))
+	}
+
+	if err := s.apply(postID.String(), map[string]int64{field: delta}); err != nil {
+		logging.FromContext(ctx).Error("counters: update failed", zap.String("field", field), zap.Stringer("post_id", postID), zap.Error(err))
+	}
+}
+
+// Flush moves the buffered deltas from Redis into the posts table.
+func (s *Store) Flush(ctx context.Context) error {
+	if !initializers.RedisAvailable() {
+		return nil
+	}
+
+	unlock, locked, err := s.lock(ctx)
+	if err != nil || !locked {
+		return err
+	}
+	defer unlock()
+
+	for {
+		ids, err := s.Redis.SPopN(ctx, dirtyKey, flushSize).Result()
+		if err != nil {
+			return fmt.Errorf("pop dirty posts: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for i, id := range ids {
+			if err := s.flushPost(ctx, id); err != nil {
+				// The posts not written yet stay dirty, their deltas are still in Redis
+				if err := s.Redis.SAdd(ctx, dirtyKey, toMembers(ids[i:])...).Err(); err != nil {
+					zap.L().Error("counters: lost dirty posts", zap.Strings("post_ids", ids[i:]), zap.Error(err))
+				}
+				return err
+			}
+		}
+	}
+}
+
+// lock makes Flush and Reconcile take turns across all instances: a post
+// popped by a flush but not written yet is invisible to Reconcile, which
+// would then set it to count(*) before the flush adds the delta on top.
+// It reports false when another instance holds the lock.
+func (s *Store) lock(ctx context.Context) (func(), bool, error) {
+	token := uuid.NewString()
+	locked, err := s.Redis.SetNX(ctx, lockKey, token, lockTTL).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("lock counters: %w", err)
+	}
+	if !locked {
+		return nil, false, nil
+	}
+	return func() {
+		// The job's context may be cancelled on shutdown, release the lock anyway
+		unlock.Run(context.Background(), s.Redis, []string{lockKey}, token)
+	}, true, nil
+}
+
+func toMembers(ids []string) []interface{} {
+	members := make([]interface{}, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+	return members
+}
+
+func (s *Store) flushPost(ctx context.Context, id string) error {
+	// Read and clear the hash in one go so concurrent increments land in the next flush
+	var values *redis.StringStringMapCmd
+	_, err := s.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		values = pipe.HGetAll(ctx, postKey(id))
+		pipe.Del(ctx, postKey(id))
+		return nil
+	})
+	if err != nil {
+		s.Redis.SAdd(ctx, dirtyKey, id)
+		return fmt.Errorf("take deltas for %s: %w", id, err)
+	}
+
+	deltas := make(map[string]int64)
+	for field, value := range values.Val() {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n != 0 {
+			deltas[field] = n
+		}
+	}
+
+	if err := s.apply(id, deltas); err != nil {
+		// Put the deltas back so they are retried on the next flush
+		_, _ = s.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for field, n := range deltas {
+				pipe.HIncrBy(ctx, postKey(id), field, n)
+			}
+			pipe.SAdd(ctx, dirtyKey, id)
+			return nil
+		})
+		return fmt.Errorf("write counters for %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *Store) apply(id string, deltas map[string]int64) error {
+	updates := make(map[string]interface{}, len(deltas))
+	for _, field := range fields {
+		if n, ok := deltas[field]; ok {
+			updates[field] = gorm.Expr("CASE WHEN "+field+" + ? < 0 THEN 0 ELSE "+field+" + ? END", n, n)
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.DB.Model(&models.Post{}).Where("id = ?", id).UpdateColumns(updates).Error
+}
+
+// Subqueries counting each field from its source table, for an UPDATE on posts
+var sourceCounts = map[string]string{
+	Comments:  "(SELECT count(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL)",
+	Reactions: "(SELECT count(*) FROM reactions WHERE reactions.post_id = posts.id)",
+	Reposts:   "(SELECT count(*) FROM posts shares WHERE (shares.repost_of_id = posts.id OR shares.quote_of_id = posts.id) AND shares.deleted_at IS NULL)",
+}
+
+// Reconcile recomputes the counters from the source tables and fixes the
+// posts that drifted, e.g. because a flush or a Redis write was lost.
+//
+// The source tables already include the changes whose deltas are still
+// buffered in Redis, so those posts are set to count(*) minus the pending
+// delta and the next Flush brings them to count(*) without counting twice.
+func (s *Store) Reconcile(ctx context.Context) error {
+	if initializers.RedisAvailable() {
+		unlock, locked, err := s.lock(ctx)
+		if err != nil || !locked {
+			return err
+		}
+		defer unlock()
+	}
+
+	pending, err := s.pending(ctx)
+	if err != nil {
+		return fmt.Errorf("read pending counters: %w", err)
+	}
+
+	sets := make([]string, 0, len(fields))
+	drifted := make([]string, 0, len(fields))
+	for _, field := range fields {
+		sets = append(sets, field+" = "+sourceCounts[field])
+		drifted = append(drifted, field+" <> "+sourceCounts[field])
+	}
+
+	query := "UPDATE posts SET " + strings.Join(sets, ", ") + " WHERE (" + strings.Join(drifted, " OR ") + ")"
+	var args []interface{}
+	if len(pending) > 0 {
+		ids := make([]string, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		query += " AND id NOT IN ?"
+		args = append(args, ids)
+	}
+
+	result := s.DB.WithContext(ctx).Exec(query, args...)
+	if result.Error != nil {
+		return fmt.Errorf("reconcile counters: %w", result.Error)
+	}
+	reconciled := result.RowsAffected
+
+	for id, deltas := range pending {
+		sets := make([]string, 0, len(fields))
+		drifted := make([]string, 0, len(fields))
+		var setArgs, driftArgs []interface{}
+		for _, field := range fields {
+			sets = append(sets, field+" = "+sourceCounts[field]+" - ?")
+			drifted = append(drifted, field+" <> "+sourceCounts[field]+" - ?")
+			setArgs = append(setArgs, deltas[field])
+			driftArgs = append(driftArgs, deltas[field])
+		}
+		args := append(append(setArgs, id), driftArgs...)
+		result := s.DB.WithContext(ctx).Exec("UPDATE posts SET "+strings.Join(sets, ", ")+" WHERE id = ? AND ("+strings.Join(drifted, " OR ")+")", args...)
+		if result.Error != nil {
+			return fmt.Errorf("reconcile counters for %s: %w", id, result.Error)
+		}
+		reconciled += result.RowsAffected
+	}
+
+	if reconciled > 0 {
+		zap.L().Info("counters: reconciled", zap.Int64("posts", reconciled))
+	}
+	return nil
+}
+
+// pending returns the deltas still buffered in Redis, keyed by post id.
+func (s *Store) pending(ctx context.Context) (map[string]map[string]int64, error) {
+	if !initializers.RedisAvailable() {
+		return nil, nil
+	}
+
+	ids, err := s.Redis.SMembers(ctx, dirtyKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[string]map[string]int64, len(ids))
+	for _, id := range ids {
+		values, err := s.Redis.HGetAll(ctx, postKey(id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		deltas := make(map[string]int64, len(values))
+		for field, value := range values {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && n != 0 {
+				deltas[field] = n
+			}
+		}
+		pending[id] = deltas
+	}
+	return pending, nil
+}
@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegister(t *testing.T) {
+	s := newServer(t)
+	s.signUp("Alice")
+
+	register := func(username, email, confirm string) response {
+		return s.post("/api/auth/register", "", gin.H{
+			"name": "Someone", "username": username, "email": email, "age": 30,
+			"password": password, "password_confirm": confirm,
+		})
+	}
+
+	expect(t, register("alice2", "alice@example.com", password), http.StatusConflict)
+	expect(t, register("alice", "other@example.com", password), http.StatusConflict)
+	expect(t, register("bob", "bob@example.com", "something else"), http.StatusBadRequest)
+	expect(t, s.post("/api/auth/register", "", gin.H{"email": "carol@example.com"}), http.StatusBadRequest)
+}
+
+func TestLogin(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+
+	res := s.post("/api/auth/login", "", gin.H{"email": alice.Email, "password": "wrong password"})
+	expect(t, res, http.StatusBadRequest)
+	res = s.post("/api/auth/login", "", gin.H{"email": "nobody@example.com", "password": password})
+	expect(t, res, http.StatusBadRequest)
+
+	res = s.post("/api/auth/login", "", gin.H{"email": alice.Email, "password": password})
+	expect(t, res, http.StatusOK)
+	for _, name := range []string{"access_token", "refresh_token", "logged_in"} {
+		if res.cookie(name) == nil {
+			t.Errorf("login did not set the %s cookie", name)
+		}
+	}
+
+	res = s.get("/api/users/profile", alice.Token)
+	expect(t, res, http.StatusOK)
+	if user := res.data()["user"].(map[string]interface{}); user["id"] != alice.ID {
+		t.Errorf("profile is of user %v, want %v", user["id"], alice.ID)
+	}
+}
+
+func TestProtectedRoutesNeedToken(t *testing.T) {
+	s := newServer(t)
+
+	expect(t, s.get("/api/users/profile", ""), http.StatusUnauthorized)
+	expect(t, s.get("/api/users/profile", "not-a-token"), http.StatusUnauthorized)
+	expect(t, s.get("/api/auth/logout", ""), http.StatusUnauthorized)
+	expect(t, s.get("/api/users/newsfeeds", ""), http.StatusUnauthorized)
+}
+
+func TestRefresh(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+
+	login := s.post("/api/auth/login", "", gin.H{"email": alice.Email, "password": password})
+	expect(t, login, http.StatusOK)
+
+	res := s.do(request{method: http.MethodGet, path: "/api/auth/refresh", cookies: []*http.Cookie{login.cookie("refresh_token")}})
+	expect(t, res, http.StatusOK)
+	token, _ := res.Body["access_token"].(string)
+	if token == "" || res.cookie("access_token") == nil {
+		t.Fatalf("refresh returned no access token: %v", res.Body)
+	}
+	expect(t, s.get("/api/users/profile", token), http.StatusOK)
+
+	expect(t, s.get("/api/auth/refresh", ""), http.StatusForbidden)
+	res = s.do(request{method: http.MethodGet, path: "/api/auth/refresh", cookies: []*http.Cookie{{Name: "refresh_token", Value: "garbage"}}})
+	expect(t, res, http.StatusForbidden)
+}
+
+func TestLogout(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+
+	res := s.get("/api/auth/logout", alice.Token)
+	expect(t, res, http.StatusOK)
+	for _, name := range []string{"access_token", "refresh_token", "logged_in"} {
+		if cookie := res.cookie(name); cookie == nil || cookie.MaxAge >= 0 {
+			t.Errorf("logout did not clear the %s cookie", name)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// bookmarks returns the ids of the posts the user saved, query narrows the list
+func (s *server) bookmarks(token, query string) []string {
+	s.t.Helper()
+	res := s.get("/api/bookmarks"+query, token)
+	expect(s.t, res, http.StatusOK)
+	var ids []string
+	for _, item := range res.list() {
+		ids = append(ids, item.(map[string]interface{})["post"].(map[string]interface{})["id"].(string))
+	}
+	return ids
+}
+
+func TestBookmarkCollections(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	first := s.createPost(bob.Token, "First")
+	second := s.createPost(bob.Token, "Second")
+
+	expect(t, s.post("/api/collections", alice.Token, gin.H{"name": ""}), http.StatusBadRequest)
+	res := s.post("/api/collections", alice.Token, gin.H{"name": " Reading "})
+	expect(t, res, http.StatusCreated)
+	reading := res.data()["id"].(string)
+	if res.data()["name"] != "Reading" {
+		t.Errorf("created %v", res.data())
+	}
+	expect(t, s.post("/api/collections", alice.Token, gin.H{"name": "Reading"}), http.StatusConflict)
+	expect(t, s.post("/api/collections", bob.Token, gin.H{"name": "Reading"}), http.StatusCreated)
+	res = s.post("/api/collections", alice.Token, gin.H{"name": "Later"})
+	expect(t, res, http.StatusCreated)
+	later := res.data()["id"].(string)
+
+	expect(t, s.put("/api/collections/"+later, alice.Token, gin.H{"name": "Reading"}), http.StatusConflict)
+	expect(t, s.put("/api/collections/"+later, bob.Token, gin.H{"name": "Mine"}), http.StatusNotFound)
+	expect(t, s.put("/api/collections/not-a-uuid", alice.Token, gin.H{"name": "Mine"}), http.StatusNotFound)
+	res = s.put("/api/collections/"+later, alice.Token, gin.H{"name": "Someday"})
+	expect(t, res, http.StatusOK)
+	if res.data()["name"] != "Someday" {
+		t.Errorf("renamed %v", res.data())
+	}
+
+	// Saving into someone else's collection is refused, saving again only moves the bookmark
+	expect(t, s.put("/api/posts/"+uuid.NewString()+"/bookmark", alice.Token, nil), http.StatusNotFound)
+	res = s.get("/api/collections", bob.Token)
+	expect(t, res, http.StatusOK)
+	bobs := res.list()[0].(map[string]interface{})["id"].(string)
+	expect(t, s.put("/api/posts/"+first+"/bookmark", alice.Token, gin.H{"collection_id": bobs}), http.StatusNotFound)
+	expect(t, s.put("/api/posts/"+first+"/bookmark", alice.Token, nil), http.StatusOK)
+	res = s.put("/api/posts/"+first+"/bookmark", alice.Token, gin.H{"collection_id": reading})
+	expect(t, res, http.StatusOK)
+	if res.data()["collection_id"] != reading {
+		t.Errorf("saved %v", res.data())
+	}
+	expect(t, s.put("/api/posts/"+second+"/bookmark", alice.Token, nil), http.StatusOK)
+
+	if ids := s.bookmarks(alice.Token, ""); len(ids) != 2 || ids[0] != second {
+		t.Fatalf("bookmarks %v", ids)
+	}
+	if ids := s.bookmarks(alice.Token, "?collection_id="+reading); len(ids) != 1 || ids[0] != first {
+		t.Fatalf("bookmarks in reading %v", ids)
+	}
+	if ids := s.bookmarks(alice.Token, "?collection_id=none"); len(ids) != 1 || ids[0] != second {
+		t.Fatalf("unsorted bookmarks %v", ids)
+	}
+	expect(t, s.get("/api/bookmarks?collection_id=not-a-uuid", alice.Token), http.StatusNotFound)
+
+	res = s.post("/api/bookmarks/move", alice.Token, gin.H{"post_ids": []string{first, second}, "collection_id": later})
+	expect(t, res, http.StatusOK)
+	if res.data()["moved"] != 2.0 {
+		t.Errorf("move %v", res.data())
+	}
+	res = s.get("/api/collections", alice.Token)
+	expect(t, res, http.StatusOK)
+	counts := map[string]interface{}{}
+	for _, item := range res.list() {
+		collection := item.(map[string]interface{})
+		counts[collection["id"].(string)] = collection["bookmarks_count"]
+	}
+	if counts[reading] != 0.0 || counts[later] != 2.0 || res.Body["unsorted"] != 0.0 {
+		t.Errorf("collections %v, unsorted %v", res.list(), res.Body["unsorted"])
+	}
+
+	// Deleting a collection keeps its bookmarks as unsorted
+	expect(t, s.delete("/api/collections/"+later, bob.Token), http.StatusNotFound)
+	expect(t, s.delete("/api/collections/"+later, alice.Token), http.StatusNoContent)
+	if ids := s.bookmarks(alice.Token, "?collection_id=none"); len(ids) != 2 {
+		t.Fatalf("unsorted after deleting the collection %v", ids)
+	}
+	expect(t, s.delete("/api/posts/"+first+"/bookmark", alice.Token), http.StatusNoContent)
+	if ids := s.bookmarks(alice.Token, ""); len(ids) != 1 || ids[0] != second {
+		t.Fatalf("bookmarks after unsaving %v", ids)
+	}
+}
@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// inbox returns the user's conversations keyed by id
+func (s *server) inbox(token string) map[string]map[string]interface{} {
+	s.t.Helper()
+	res := s.get("/api/conversations", token)
+	expect(s.t, res, http.StatusOK)
+	conversations := map[string]map[string]interface{}{}
+	for _, item := range res.list() {
+		conversation := item.(map[string]interface{})
+		conversations[conversation["id"].(string)] = conversation
+	}
+	return conversations
+}
+
+func lastMessage(conversation map[string]interface{}) interface{} {
+	message, _ := conversation["last_message"].(map[string]interface{})
+	return message["content"]
+}
+
+func TestConversations(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	carol := s.signUp("Carol")
+
+	expect(t, s.post("/api/conversations", alice.Token, gin.H{"user_ids": []string{alice.ID}}), http.StatusBadRequest)
+	expect(t, s.post("/api/conversations", alice.Token, gin.H{"user_ids": []string{uuid.NewString()}}), http.StatusNotFound)
+
+	res := s.post("/api/conversations", alice.Token, gin.H{"user_ids": []string{bob.ID}})
+	expect(t, res, http.StatusCreated)
+	id := res.data()["id"].(string)
+	// The pair already has a conversation, starting one again returns it
+	res = s.post("/api/conversations", bob.Token, gin.H{"user_ids": []string{alice.ID}})
+	expect(t, res, http.StatusOK)
+	if res.data()["id"] != id {
+		t.Fatalf("second 1:1 conversation %v, want %s", res.data()["id"], id)
+	}
+
+	path := "/api/conversations/" + id
+	expect(t, s.get(path, carol.Token), http.StatusNotFound)
+	expect(t, s.post(path+"/messages", carol.Token, gin.H{"content": "let me in"}), http.StatusNotFound)
+
+	var sent []string
+	for _, content := range []string{"hi", "how are you", "still there?"} {
+		res := s.post(path+"/messages", alice.Token, gin.H{"content": content})
+		expect(t, res, http.StatusCreated)
+		sent = append(sent, res.data()["id"].(string))
+	}
+
+	if got := s.inbox(alice.Token)[id]; got["unread_count"] != 0.0 || lastMessage(got) != "still there?" {
+		t.Fatalf("sender's inbox %v", got)
+	}
+	if got := s.inbox(bob.Token)[id]; got["unread_count"] != 3.0 || lastMessage(got) != "still there?" {
+		t.Fatalf("recipient's inbox %v", got)
+	}
+
+	res = s.get(path+"/messages?limit=2", bob.Token)
+	expect(t, res, http.StatusOK)
+	if messages := res.list(); len(messages) != 2 || messages[0].(map[string]interface{})["content"] != "still there?" {
+		t.Fatalf("first page %v", messages)
+	}
+	res = s.get(path+"/messages?limit=2&cursor="+res.Body["next_cursor"].(string), bob.Token)
+	expect(t, res, http.StatusOK)
+	if messages := res.list(); len(messages) != 1 || messages[0].(map[string]interface{})["content"] != "hi" || res.Body["next_cursor"] != "" {
+		t.Fatalf("second page %v", res.Body)
+	}
+
+	// Reading up to a message leaves the later ones unread
+	expect(t, s.post(path+"/read", bob.Token, gin.H{"message_id": uuid.NewString()}), http.StatusNotFound)
+	expect(t, s.post(path+"/read", bob.Token, gin.H{"message_id": sent[1]}), http.StatusOK)
+	if got := s.inbox(bob.Token)[id]["unread_count"]; got != 1.0 {
+		t.Fatalf("unread after reading up to the second message: %v", got)
+	}
+	// The marker never moves back
+	expect(t, s.post(path+"/read", bob.Token, gin.H{"message_id": sent[0]}), http.StatusOK)
+	if got := s.inbox(bob.Token)[id]["unread_count"]; got != 1.0 {
+		t.Fatalf("unread after reading an older message: %v", got)
+	}
+
+	expect(t, s.post(path+"/read", bob.Token, nil), http.StatusOK)
+	res = s.get(path, alice.Token)
+	expect(t, res, http.StatusOK)
+	for _, item := range res.data()["members"].([]interface{}) {
+		member := item.(map[string]interface{})
+		if member["id"] == bob.ID && member["last_read_at"] == nil {
+			t.Fatalf("no read receipt for bob: %v", member)
+		}
+	}
+	if got := s.inbox(bob.Token)[id]["unread_count"]; got != 0.0 {
+		t.Fatalf("unread after reading everything: %v", got)
+	}
+}
+
+func TestConversationBlocks(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	carol := s.signUp("Carol")
+
+	res := s.post("/api/conversations", alice.Token, gin.H{"user_ids": []string{bob.ID, carol.ID}, "title": "Group"})
+	expect(t, res, http.StatusCreated)
+	group := "/api/conversations/" + res.data()["id"].(string)
+	res = s.post("/api/conversations", carol.Token, gin.H{"user_ids": []string{bob.ID}})
+	expect(t, res, http.StatusCreated)
+	direct := "/api/conversations/" + res.data()["id"].(string)
+
+	expect(t, s.post("/api/users/block/"+carol.ID, alice.Token, nil), http.StatusOK)
+	expect(t, s.post("/api/conversations", alice.Token, gin.H{"user_ids": []string{carol.ID}}), http.StatusForbidden)
+
+	// In a group the message still goes out, only alice no longer sees it
+	expect(t, s.post(group+"/messages", bob.Token, gin.H{"content": "from bob"}), http.StatusCreated)
+	expect(t, s.post(group+"/messages", carol.Token, gin.H{"content": "from carol"}), http.StatusCreated)
+
+	id := group[len("/api/conversations/"):]
+	if got := s.inbox(alice.Token)[id]; got["unread_count"] != 1.0 || lastMessage(got) != "from bob" {
+		t.Fatalf("inbox of the user who blocked carol %v", got)
+	}
+	if got := s.inbox(bob.Token)[id]; got["unread_count"] != 1.0 || lastMessage(got) != "from carol" {
+		t.Fatalf("bob's inbox %v", got)
+	}
+	res = s.get(group+"/messages", alice.Token)
+	expect(t, res, http.StatusOK)
+	if messages := res.list(); len(messages) != 1 || messages[0].(map[string]interface{})["content"] != "from bob" {
+		t.Fatalf("messages seen by alice %v", messages)
+	}
+
+	// A block on either side stops a 1:1 conversation
+	expect(t, s.post(direct+"/messages", carol.Token, gin.H{"content": "before"}), http.StatusCreated)
+	expect(t, s.post("/api/users/block/"+carol.ID, bob.Token, nil), http.StatusOK)
+	expect(t, s.post(direct+"/messages", carol.Token, gin.H{"content": "after"}), http.StatusForbidden)
+	expect(t, s.post(direct+"/messages", bob.Token, gin.H{"content": "reply"}), http.StatusForbidden)
+	if got := s.inbox(bob.Token)[direct[len("/api/conversations/"):]]; got["unread_count"] != 0.0 || got["last_message"] != nil {
+		t.Fatalf("bob's inbox after blocking carol %v", got)
+	}
+}
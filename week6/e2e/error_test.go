@@ -0,0 +1,63 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorsAreProblems(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+
+	res := s.get("/api/users/profile", "")
+	expect(t, res, http.StatusUnauthorized)
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type is %q", contentType)
+	}
+	requestID := res.Header.Get("X-Request-ID")
+	if requestID == "" || res.Body["request_id"] != requestID {
+		t.Errorf("request_id is %v, header %q", res.Body["request_id"], requestID)
+	}
+	for key, want := range map[string]interface{}{
+		"type": "about:blank", "title": "Unauthorized", "status": float64(401),
+		"code": "unauthorized", "instance": "/api/users/profile",
+	} {
+		if res.Body[key] != want {
+			t.Errorf("%s is %v, want %v", key, res.Body[key], want)
+		}
+	}
+
+	res = s.do(request{method: http.MethodGet, path: "/api/nothing-here", header: map[string]string{"X-Request-ID": "abc-123"}})
+	expect(t, res, http.StatusNotFound)
+	if res.Body["code"] != "not_found" || res.Body["request_id"] != "abc-123" {
+		t.Errorf("unknown route: %v", res.Body)
+	}
+
+	res = s.post("/api/posts", alice.Token, gin.H{"title": "No content", "image": "img.png"})
+	expect(t, res, http.StatusBadRequest)
+	if res.Body["code"] != "validation_failed" {
+		t.Errorf("code is %v", res.Body["code"])
+	}
+	fields, _ := res.Body["errors"].([]interface{})
+	if len(fields) != 1 {
+		t.Fatalf("errors: %v", res.Body["errors"])
+	}
+	if field := fields[0].(map[string]interface{}); field["field"] != "content" || field["rule"] != "required" {
+		t.Errorf("field error: %v", field)
+	}
+}
+
+func TestUpdatePostRejectsBadBody(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	id := s.createPost(alice.Token, "Hello")
+
+	res := s.put("/api/posts/"+id, alice.Token, gin.H{"title": 42})
+	expect(t, res, http.StatusBadRequest)
+	fields, _ := res.Body["errors"].([]interface{})
+	if len(fields) != 1 || fields[0].(map[string]interface{})["field"] != "title" {
+		t.Errorf("errors: %v", res.Body["errors"])
+	}
+}
@@ -0,0 +1,143 @@
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sseEvent struct {
+	ID   string
+	Type string
+	Data map[string]interface{}
+}
+
+// stream opens an event stream on the started app and returns its events,
+// the channel closes when the stream ends
+func stream(t *testing.T, addr, token, query, lastEventID string) <-chan sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/api/events"+query, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		res.Body.Close()
+	})
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("stream status %d", res.StatusCode)
+	}
+
+	reader := bufio.NewReader(res.Body)
+	// Once the retry line is out the stream is subscribed
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("stream starts with %q, %v", line, err)
+	}
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				event.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data)
+			case line == "" && event.Type != "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func next(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event within 5s")
+	}
+	return sseEvent{}
+}
+
+func TestEventStream(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	carol := s.signUp("Carol")
+	watched := s.createPost(carol.Token, "Watched")
+	expect(t, s.post("/api/users/follow/"+alice.ID, bob.Token, nil), http.StatusOK)
+
+	s.app.Config.ServerPort = "0"
+	if err := s.app.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.app.Stop(ctx)
+	})
+	// Events travel through Redis Pub/Sub, wait for the hub to listen
+	for deadline := time.Now().Add(5 * time.Second); s.redis.PubSubNumPat() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("hub never subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	addr := s.app.Addr().String()
+
+	expect(t, s.get("/api/events?posts=not-a-uuid", bob.Token), http.StatusBadRequest)
+	events := stream(t, addr, bob.Token, "?posts="+watched, "")
+
+	expect(t, s.post("/api/users/follow/"+bob.ID, alice.Token, nil), http.StatusOK)
+	followed := next(t, events)
+	if followed.Type != "notification" || followed.ID == "" || followed.Data["type"] != "follow" {
+		t.Fatalf("follow event %+v", followed)
+	}
+
+	id := s.createPost(alice.Token, "Fresh")
+	posted := next(t, events)
+	if posted.Type != "feed.post" || posted.Data["id"] != id {
+		t.Fatalf("feed event %+v", posted)
+	}
+
+	// Counter changes of a watched post are ephemeral and carry no id
+	expect(t, s.post("/api/posts/"+watched+"/comments", alice.Token, gin.H{"content": "Hi"}), http.StatusCreated)
+	counted := next(t, events)
+	if counted.Type != "post.counters" || counted.ID != "" || counted.Data["post_id"] != watched || counted.Data["field"] != "comments_count" {
+		t.Fatalf("counter event %+v", counted)
+	}
+
+	// Reconnecting after the follow replays the feed event that came later
+	replayed := next(t, stream(t, addr, bob.Token, "", followed.ID))
+	if replayed.Type != "feed.post" || replayed.ID != posted.ID {
+		t.Fatalf("replayed %+v, want %+v", replayed, posted)
+	}
+}
@@ -0,0 +1,54 @@
+package e2e
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/trung/backend-engineerpro/fixtures"
+)
+
+func TestFixtures(t *testing.T) {
+	var sets []*fixtures.Set
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			s := newServer(t)
+			set := s.seed(fixtures.Options{Seed: 7, Users: 8, FollowsPerUser: 2, PostsPerUser: 3, CommentsPerPost: 2, LikesPerPost: 2})
+			sets = append(sets, set)
+
+			// The first user is an admin and every user can log in
+			admin := s.login(set.Users[0].Email)
+			expect(t, s.get("/api/admin/reports", admin), http.StatusOK)
+			user := s.login(set.Users[1].Email)
+			expect(t, s.get("/api/admin/reports", user), http.StatusForbidden)
+
+			res := s.get("/api/posts?limit=100", "")
+			expect(t, res, http.StatusOK)
+			if len(res.list()) != len(set.Posts) {
+				t.Errorf("listed %d posts, seeded %d", len(res.list()), len(set.Posts))
+			}
+		})
+	}
+
+	// Same seed, same data, whatever day the tests run
+	if len(sets) == 2 && !reflect.DeepEqual(sets[0].Posts, sets[1].Posts) {
+		t.Error("the same seed generated different posts")
+	}
+}
+
+func TestFixturesZeroCounts(t *testing.T) {
+	s := newServer(t)
+
+	// Zero means none, only a negative count falls back to the default
+	set := s.seed(fixtures.Options{Seed: 7, Users: 4, FollowsPerUser: -1, PostsPerUser: 2})
+	if len(set.Users) != 4 || len(set.Follows) == 0 || len(set.Posts) == 0 {
+		t.Fatalf("seeded %d users, %d follows and %d posts", len(set.Users), len(set.Follows), len(set.Posts))
+	}
+	if len(set.Comments) != 0 || len(set.Reactions) != 0 {
+		t.Errorf("seeded %d comments and %d reactions, want none", len(set.Comments), len(set.Reactions))
+	}
+
+	if _, err := fixtures.Generate(s.db, fixtures.Options{Seed: 8}); err == nil {
+		t.Error("generated a data set without users")
+	}
+}
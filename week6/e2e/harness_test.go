@@ -0,0 +1,328 @@
+// Package e2e drives the whole API over HTTP: the real gin engine, services
+// and gorm repositories, with miniredis standing in for Redis. Tests run on
+// an in-memory SQLite database unless TEST_DATABASE_URL points at a Postgres
+// one, which is migrated and wiped before every test.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/app"
+	"github.com/trung/backend-engineerpro/fixtures"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/migrations"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/storage"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+const password = fixtures.Password
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+type server struct {
+	t     *testing.T
+	app   *app.App
+	db    *gorm.DB
+	redis *miniredis.Miniredis
+}
+
+// newServer starts an app on an empty database. The app sets
+// initializers.RedisClient, so tests using it must not run in parallel.
+func newServer(t *testing.T) *server {
+	t.Helper()
+
+	config, err := initializers.LoadConfig("..")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	config.UploadDir = t.TempDir()
+
+	mr := miniredis.RunT(t)
+	initializers.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		initializers.RedisClient.Close()
+		initializers.RedisClient = nil
+	})
+
+	blobs, err := storage.NewLocalStore(config.UploadDir, config.UploadBaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := openDB(t)
+	return &server{
+		t:     t,
+		app:   app.New(config, db, initializers.RedisClient, blobs),
+		db:    db,
+		redis: mr,
+	}
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+		return openPostgres(t, url)
+	}
+	return openSQLite(t)
+}
+
+func openPostgres(t *testing.T, url string) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(url), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect to TEST_DATABASE_URL: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := fixtures.Wipe(db); err != nil {
+		t.Fatalf("wipe: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// openSQLite creates the schema from the models, SQLite has no
+// uuid_generate_v4() so those defaults are dropped and the ids are filled in
+// before every insert instead
+func openSQLite(t *testing.T) *gorm.DB {
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The shared in-memory database lives as long as a connection to it
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	generated := map[*schema.Field]bool{}
+	for _, model := range models.All {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		var kept []*schema.Field
+		for _, field := range stmt.Schema.FieldsWithDefaultDBValue {
+			if field.DefaultValue == "uuid_generate_v4()" {
+				field.HasDefaultValue, field.DefaultValue = false, ""
+				generated[field] = true
+				continue
+			}
+			kept = append(kept, field)
+		}
+		stmt.Schema.FieldsWithDefaultDBValue = kept
+	}
+
+	err = db.Callback().Create().Before("gorm:create").Register("e2e:uuid", func(tx *gorm.DB) {
+		if tx.Statement.Schema == nil {
+			return
+		}
+		fill := func(value reflect.Value) {
+			for _, field := range tx.Statement.Schema.PrimaryFields {
+				if _, zero := field.ValueOf(tx.Statement.Context, value); !generated[field] || !zero {
+					continue
+				}
+				if field.FieldType.Kind() == reflect.String {
+					field.Set(tx.Statement.Context, value, uuid.NewString())
+				} else {
+					field.Set(tx.Statement.Context, value, uuid.New())
+				}
+			}
+		}
+		switch value := reflect.Indirect(tx.Statement.ReflectValue); value.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				fill(reflect.Indirect(value.Index(i)))
+			}
+		case reflect.Struct:
+			fill(value)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(models.All...); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	return db
+}
+
+type response struct {
+	Code    int
+	Header  http.Header
+	Body    map[string]interface{}
+	Cookies []*http.Cookie
+}
+
+// data returns the "data" object of the body
+func (r response) data() map[string]interface{} {
+	data, _ := r.Body["data"].(map[string]interface{})
+	return data
+}
+
+// list returns the "data" array of the body
+func (r response) list() []interface{} {
+	list, _ := r.Body["data"].([]interface{})
+	return list
+}
+
+func (r response) cookie(name string) *http.Cookie {
+	for _, cookie := range r.Cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+type request struct {
+	method  string
+	path    string
+	body    interface{}
+	token   string
+	header  map[string]string
+	cookies []*http.Cookie
+}
+
+func (s *server) do(req request) response {
+	s.t.Helper()
+
+	var body io.Reader
+	if req.body != nil {
+		raw, err := json.Marshal(req.body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		body = bytes.NewReader(raw)
+	}
+	httpReq := httptest.NewRequest(req.method, req.path, body)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if req.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+req.token)
+	}
+	for name, value := range req.header {
+		httpReq.Header.Set(name, value)
+	}
+	for _, cookie := range req.cookies {
+		httpReq.AddCookie(cookie)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.app.Router.ServeHTTP(recorder, httpReq)
+
+	res := response{Code: recorder.Code, Header: recorder.Header(), Cookies: recorder.Result().Cookies()}
+	if recorder.Body.Len() > 0 {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &res.Body); err != nil {
+			s.t.Fatalf("%s %s: decode %q: %v", req.method, req.path, recorder.Body.String(), err)
+		}
+	}
+	return res
+}
+
+func (s *server) get(path, token string) response {
+	s.t.Helper()
+	return s.do(request{method: http.MethodGet, path: path, token: token})
+}
+
+func (s *server) post(path, token string, body interface{}) response {
+	s.t.Helper()
+	return s.do(request{method: http.MethodPost, path: path, token: token, body: body})
+}
+
+func (s *server) put(path, token string, body interface{}) response {
+	s.t.Helper()
+	return s.do(request{method: http.MethodPut, path: path, token: token, body: body})
+}
+
+func (s *server) delete(path, token string) response {
+	s.t.Helper()
+	return s.do(request{method: http.MethodDelete, path: path, token: token})
+}
+
+type account struct {
+	ID    string
+	Email string
+	Token string
+}
+
+// signUp registers a user and logs them in
+func (s *server) signUp(name string) account {
+	s.t.Helper()
+
+	email := strings.ToLower(name) + "@example.com"
+	res := s.post("/api/auth/register", "", gin.H{
+		"name": name, "username": strings.ToLower(name), "email": email, "age": 30,
+		"password": password, "password_confirm": password,
+	})
+	expect(s.t, res, http.StatusCreated)
+	user := res.data()["user"].(map[string]interface{})
+
+	return account{ID: user["id"].(string), Email: email, Token: s.login(email)}
+}
+
+func (s *server) login(email string) string {
+	s.t.Helper()
+	res := s.post("/api/auth/login", "", gin.H{"email": email, "password": password})
+	expect(s.t, res, http.StatusOK)
+	return res.Body["access_token"].(string)
+}
+
+// seed fills the database with a generated data set, its users log in with
+// the same password as the ones from signUp
+func (s *server) seed(options fixtures.Options) *fixtures.Set {
+	s.t.Helper()
+	set, err := fixtures.Generate(s.db, options)
+	if err != nil {
+		s.t.Fatalf("seed: %v", err)
+	}
+	return set
+}
+
+// createPost publishes a post and returns its id
+func (s *server) createPost(token, title string) string {
+	s.t.Helper()
+	res := s.post("/api/posts", token, gin.H{"title": title, "content": "Content of " + title, "image": "img.png"})
+	expect(s.t, res, http.StatusCreated)
+	return res.data()["id"].(string)
+}
+
+func expect(t *testing.T, res response, code int) {
+	t.Helper()
+	if res.Code != code {
+		t.Fatalf("got status %d, want %d: %v", res.Code, code, res.Body)
+	}
+}
@@ -0,0 +1,46 @@
+package e2e
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	postID := s.createPost(alice.Token, "Metrics")
+	expect(t, s.get("/api/posts/"+postID, ""), http.StatusOK)
+	// The second listing is served from the posts cache
+	expect(t, s.get("/api/posts", ""), http.StatusOK)
+	expect(t, s.get("/api/posts", ""), http.StatusOK)
+
+	recorder := httptest.NewRecorder()
+	s.app.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d", recorder.Code)
+	}
+	body := recorder.Body.String()
+
+	for _, want := range []string{
+		`engineerpro_http_requests_total{method="POST",route="/api/auth/register",status="201"}`,
+		`engineerpro_http_requests_total{method="GET",route="/api/posts/:postId",status="200"}`,
+		`engineerpro_http_request_duration_seconds_bucket{method="GET",route="/api/posts"`,
+		`engineerpro_cache_requests_total{cache="posts",result="hit"}`,
+		`engineerpro_cache_requests_total{cache="posts",result="miss"}`,
+		`engineerpro_signups_total`,
+		`engineerpro_logins_total{result="success"}`,
+		`engineerpro_posts_created_total{status="published"}`,
+		`go_sql_open_connections{db_name="sqlite"}`,
+		`engineerpro_redis_pool_connections`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	// Routes are labelled by template, never by the path with the id in it
+	if strings.Contains(body, postID) {
+		t.Errorf("metrics contain the post id %s", postID)
+	}
+}
@@ -0,0 +1,27 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/models"
+)
+
+func TestReportAgainAfterDismissal(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	admin := s.signUp("Admin")
+	if err := s.db.Model(&models.User{}).Where("id = ?", admin.ID).Update("role", models.RoleAdmin).Error; err != nil {
+		t.Fatal(err)
+	}
+	id := s.createPost(alice.Token, "Questionable")
+
+	report := gin.H{"reason": "spam"}
+	expect(t, s.post("/api/posts/"+id+"/report", bob.Token, report), http.StatusCreated)
+	expect(t, s.post("/api/admin/reports/post/"+id, admin.Token, gin.H{"action": "dismiss"}), http.StatusOK)
+
+	// The dismissed report doesn't keep Bob from reporting the post again
+	expect(t, s.post("/api/posts/"+id+"/report", bob.Token, report), http.StatusCreated)
+}
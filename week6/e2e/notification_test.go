@@ -0,0 +1,95 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// notifications returns the user's inbox and its unread count
+func (s *server) notifications(token, query string) ([]map[string]interface{}, float64) {
+	s.t.Helper()
+	res := s.get("/api/notifications"+query, token)
+	expect(s.t, res, http.StatusOK)
+	var list []map[string]interface{}
+	for _, item := range res.list() {
+		list = append(list, item.(map[string]interface{}))
+	}
+	return list, res.Body["unread"].(float64)
+}
+
+func TestNotifications(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	carol := s.signUp("Carol")
+	dave := s.signUp("Dave")
+	id := s.createPost(alice.Token, "Likeable")
+	like := func(user account) {
+		t.Helper()
+		expect(t, s.put("/api/posts/"+id+"/reactions", user.Token, gin.H{"type": "like"}), http.StatusOK)
+	}
+
+	// Likes of the same post fold into one notification, the author's own doesn't count
+	like(alice)
+	like(bob)
+	like(carol)
+	like(dave)
+	list, unread := s.notifications(alice.Token, "")
+	if len(list) != 1 || unread != 1 {
+		t.Fatalf("inbox %v, unread %v", list, unread)
+	}
+	if list[0]["actor_count"] != 3.0 || list[0]["message"] != "Dave and 2 others liked your post" {
+		t.Errorf("folded like %v", list[0])
+	}
+	likeID := list[0]["id"].(string)
+
+	expect(t, s.post("/api/users/follow/"+alice.ID, bob.Token, nil), http.StatusOK)
+	list, unread = s.notifications(alice.Token, "")
+	if len(list) != 2 || unread != 2 || list[0]["type"] != "follow" {
+		t.Fatalf("inbox after the follow %v, unread %v", list, unread)
+	}
+
+	expect(t, s.post("/api/notifications/"+likeID+"/read", bob.Token, nil), http.StatusNotFound)
+	expect(t, s.post("/api/notifications/not-a-uuid/read", alice.Token, nil), http.StatusNotFound)
+	res := s.post("/api/notifications/"+likeID+"/read", alice.Token, nil)
+	expect(t, res, http.StatusOK)
+	if res.data()["read_at"] == nil {
+		t.Errorf("read notification %v", res.data())
+	}
+	list, unread = s.notifications(alice.Token, "?unread=true")
+	if len(list) != 1 || unread != 1 || list[0]["type"] != "follow" {
+		t.Fatalf("unread after reading the likes %v, unread %v", list, unread)
+	}
+
+	// A like after the group was read starts a new notification
+	like(s.signUp("Eve"))
+	list, unread = s.notifications(alice.Token, "")
+	if len(list) != 3 || unread != 2 || list[0]["id"] == likeID || list[0]["actor_count"] != 1.0 {
+		t.Fatalf("inbox after another like %v, unread %v", list, unread)
+	}
+
+	res = s.post("/api/notifications/read", alice.Token, nil)
+	expect(t, res, http.StatusOK)
+	if res.data()["updated"] != 2.0 {
+		t.Errorf("mark all read %v", res.data())
+	}
+	if _, unread := s.notifications(alice.Token, ""); unread != 0 {
+		t.Errorf("unread after marking all read %v", unread)
+	}
+
+	// A type turned off is not recorded at all
+	expect(t, s.put("/api/notifications/preferences", alice.Token, gin.H{"preferences": gin.H{"poke": false}}), http.StatusBadRequest)
+	res = s.put("/api/notifications/preferences", alice.Token, gin.H{"preferences": gin.H{"like": false}})
+	expect(t, res, http.StatusOK)
+	if res.data()["like"] != false || res.data()["follow"] != true {
+		t.Errorf("preferences %v", res.data())
+	}
+	like(s.signUp("Frank"))
+	if list, unread := s.notifications(alice.Token, ""); len(list) != 3 || unread != 0 {
+		t.Errorf("inbox with likes turned off %v, unread %v", list, unread)
+	}
+	expect(t, s.post("/api/notifications/"+uuid.NewString()+"/read", alice.Token, nil), http.StatusNotFound)
+}
@@ -0,0 +1,350 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"gorm.io/gorm"
+)
+
+func TestPostCRUD(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+
+	body := gin.H{"title": "Hello", "content": "First post", "image": "img.png"}
+	expect(t, s.post("/api/posts", "", body), http.StatusUnauthorized)
+
+	res := s.post("/api/posts", alice.Token, body)
+	expect(t, res, http.StatusCreated)
+	id := res.data()["id"].(string)
+	if author := res.data()["user_id"]; author != alice.ID {
+		t.Errorf("post author is %v, want %v", author, alice.ID)
+	}
+	expect(t, s.post("/api/posts", bob.Token, body), http.StatusConflict)
+
+	res = s.get("/api/posts/"+id, "")
+	expect(t, res, http.StatusOK)
+	if title := res.data()["title"]; title != "Hello" {
+		t.Errorf("title is %v, want Hello", title)
+	}
+	expect(t, s.get("/api/posts/"+uuid.NewString(), ""), http.StatusNotFound)
+
+	res = s.get("/api/posts", "")
+	expect(t, res, http.StatusOK)
+	if len(res.list()) != 1 {
+		t.Errorf("listed %d posts, want 1", len(res.list()))
+	}
+
+	update := gin.H{"title": "Hello again", "content": "Edited post"}
+	expect(t, s.put("/api/posts/"+id, "", update), http.StatusUnauthorized)
+	expect(t, s.put("/api/posts/"+id, bob.Token, update), http.StatusNotFound)
+	res = s.put("/api/posts/"+id, alice.Token, update)
+	expect(t, res, http.StatusOK)
+	if data := res.data(); data["title"] != "Hello again" || data["edited"] != true {
+		t.Errorf("update returned %v", data)
+	}
+
+	expect(t, s.delete("/api/posts/"+id, bob.Token), http.StatusNotFound)
+	expect(t, s.delete("/api/posts/"+id, alice.Token), http.StatusNoContent)
+	expect(t, s.get("/api/posts/"+id, ""), http.StatusNotFound)
+	expect(t, s.delete("/api/posts/"+id, alice.Token), http.StatusNotFound)
+}
+
+func TestLikes(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	id := s.createPost(alice.Token, "Likeable")
+
+	expect(t, s.post("/api/posts/"+id+"/like", "", nil), http.StatusUnauthorized)
+	expect(t, s.post("/api/posts/not-a-uuid/like", bob.Token, nil), http.StatusBadRequest)
+
+	expect(t, s.post("/api/posts/"+id+"/like", bob.Token, nil), http.StatusOK)
+	res := s.get("/api/posts/"+id+"/reactions", "")
+	expect(t, res, http.StatusOK)
+	if reactors := res.list(); len(reactors) != 1 || reactors[0].(map[string]interface{})["user_id"] != bob.ID {
+		t.Fatalf("reactions after like: %v", reactors)
+	}
+	res = s.get("/api/posts", "")
+	expect(t, res, http.StatusOK)
+	if counts := res.list()[0].(map[string]interface{})["reaction_counts"]; !reflect.DeepEqual(counts, map[string]interface{}{"like": 1.0}) {
+		t.Errorf("listed reaction_counts: %v", counts)
+	}
+
+	// Liking again takes the like back
+	expect(t, s.post("/api/posts/"+id+"/like", bob.Token, nil), http.StatusOK)
+	res = s.get("/api/posts/"+id+"/reactions", "")
+	expect(t, res, http.StatusOK)
+	if len(res.list()) != 0 {
+		t.Fatalf("reactions after unlike: %v", res.list())
+	}
+}
+
+func TestComments(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	id := s.createPost(alice.Token, "Discuss")
+	path := "/api/posts/" + id + "/comments"
+
+	expect(t, s.post(path, "", gin.H{"content": "Nice"}), http.StatusUnauthorized)
+	expect(t, s.post("/api/posts/"+uuid.NewString()+"/comments", bob.Token, gin.H{"content": "Nice"}), http.StatusNotFound)
+
+	res := s.post(path, bob.Token, gin.H{"content": "Nice"})
+	expect(t, res, http.StatusCreated)
+	commentID := res.data()["id"].(string)
+
+	res = s.get(path, "")
+	expect(t, res, http.StatusOK)
+	if comments := res.list(); len(comments) != 1 || comments[0].(map[string]interface{})["content"] != "Nice" {
+		t.Fatalf("comments: %v", comments)
+	}
+
+	// Only the author can change or remove a comment
+	expect(t, s.put(path+"/"+commentID, alice.Token, gin.H{"content": "Changed"}), http.StatusNotFound)
+	expect(t, s.put(path+"/"+commentID, bob.Token, gin.H{"content": "Very nice"}), http.StatusOK)
+	expect(t, s.delete(path+"/"+commentID, alice.Token), http.StatusNotFound)
+	expect(t, s.delete(path+"/"+commentID, bob.Token), http.StatusNoContent)
+
+	res = s.get(path, "")
+	expect(t, res, http.StatusOK)
+	if len(res.list()) != 0 {
+		t.Fatalf("comments after delete: %v", res.list())
+	}
+
+	// Replies nest under their parent and stay out of the top-level list
+	var top []string
+	for _, content := range []string{"One", "Two", "Three"} {
+		res = s.post(path, bob.Token, gin.H{"content": content})
+		expect(t, res, http.StatusCreated)
+		top = append(top, res.data()["id"].(string))
+	}
+	res = s.post(path, alice.Token, gin.H{"content": "Reply", "parent_id": top[0]})
+	expect(t, res, http.StatusCreated)
+	reply := res.data()["id"].(string)
+	expect(t, s.post(path, bob.Token, gin.H{"content": "Nested", "parent_id": reply}), http.StatusCreated)
+	expect(t, s.post(path, bob.Token, gin.H{"content": "Orphan", "parent_id": uuid.NewString()}), http.StatusNotFound)
+	other := s.createPost(bob.Token, "Elsewhere")
+	expect(t, s.post("/api/posts/"+other+"/comments", bob.Token, gin.H{"content": "Stray", "parent_id": top[0]}), http.StatusNotFound)
+
+	res = s.get(path+"/"+top[0]+"/replies", "")
+	expect(t, res, http.StatusOK)
+	replies := res.list()
+	if len(replies) != 1 || replies[0].(map[string]interface{})["id"] != reply || replies[0].(map[string]interface{})["reply_count"] != 1.0 {
+		t.Fatalf("replies: %v", replies)
+	}
+	res = s.get(path+"/"+reply+"/replies", "")
+	expect(t, res, http.StatusOK)
+	if replies := res.list(); len(replies) != 1 || replies[0].(map[string]interface{})["content"] != "Nested" {
+		t.Fatalf("nested replies: %v", replies)
+	}
+
+	// The cursor walks the top-level comments oldest first, past the first page
+	res = s.get(path+"?limit=2", "")
+	expect(t, res, http.StatusOK)
+	page := res.list()
+	cursor, _ := res.Body["next_cursor"].(string)
+	if len(page) != 2 || page[0].(map[string]interface{})["id"] != top[0] || page[1].(map[string]interface{})["id"] != top[1] || cursor == "" {
+		t.Fatalf("first page %v, cursor %q", page, cursor)
+	}
+	if page[0].(map[string]interface{})["reply_count"] != 1.0 {
+		t.Errorf("reply_count of the first comment is %v, want 1", page[0].(map[string]interface{})["reply_count"])
+	}
+	res = s.get(path+"?limit=2&cursor="+cursor, "")
+	expect(t, res, http.StatusOK)
+	if page := res.list(); len(page) != 1 || page[0].(map[string]interface{})["id"] != top[2] || res.Body["next_cursor"] != "" {
+		t.Fatalf("second page %v, cursor %v", page, res.Body["next_cursor"])
+	}
+	expect(t, s.get(path+"?cursor=not-a-cursor", ""), http.StatusBadRequest)
+}
+
+func TestCounterReconcile(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	id := s.createPost(alice.Token, "Counted")
+
+	expect(t, s.post("/api/posts/"+id+"/comments", bob.Token, gin.H{"content": "First"}), http.StatusCreated)
+	if err := s.app.Counters.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second comment is already in the comments table while its delta is
+	// still buffered in Redis, the next flush must not count it again
+	expect(t, s.post("/api/posts/"+id+"/comments", bob.Token, gin.H{"content": "Second"}), http.StatusCreated)
+	if err := s.app.Counters.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.app.Counters.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var post models.Post
+	if err := s.db.First(&post, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if post.CommentsCount != 2 {
+		t.Errorf("comments_count is %d, want 2", post.CommentsCount)
+	}
+}
+
+func TestCounterReconcileWaitsForFlush(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	id := s.createPost(alice.Token, "Counted")
+	if err := s.db.Model(&models.Post{}).Where("id = ?", id).UpdateColumn("comments_count", 5).Error; err != nil {
+		t.Fatal(err)
+	}
+	commentsCount := func() int64 {
+		var post models.Post
+		if err := s.db.First(&post, "id = ?", id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return post.CommentsCount
+	}
+
+	// Another instance is in the middle of a flush, its popped posts are not
+	// in the dirty set for Reconcile to see
+	if err := s.redis.Set("counters:lock", "other-instance"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.app.Counters.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := commentsCount(); n != 5 {
+		t.Errorf("reconciled to %d while a flush held the lock", n)
+	}
+	if err := s.app.Counters.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := s.redis.Get("counters:lock"); owner != "other-instance" {
+		t.Errorf("lock is held by %q, a flush must not take or release another instance's lock", owner)
+	}
+
+	s.redis.Del("counters:lock")
+	if err := s.app.Counters.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := commentsCount(); n != 0 {
+		t.Errorf("comments_count is %d, want 0", n)
+	}
+	if s.redis.Exists("counters:lock") {
+		t.Error("reconcile did not release the lock")
+	}
+}
+
+func TestCounterFlushFailure(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	ids := []string{s.createPost(alice.Token, "First"), s.createPost(alice.Token, "Second")}
+	for _, id := range ids {
+		expect(t, s.post("/api/posts/"+id+"/comments", bob.Token, gin.H{"content": "Hi"}), http.StatusCreated)
+	}
+
+	// The first counter write fails, whichever post it is for
+	failed := false
+	err := s.db.Callback().Update().Before("gorm:update").Register("test:fail_once", func(db *gorm.DB) {
+		if !failed && db.Statement.Table == "posts" {
+			failed = true
+			db.AddError(errors.New("connection reset"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.app.Counters.Flush(context.Background()); err == nil {
+		t.Fatal("flush succeeded, want the write error")
+	}
+	if dirty, _ := s.redis.Members("counters:posts:dirty"); len(dirty) != 2 {
+		t.Errorf("dirty posts after the failed flush are %v, want both", dirty)
+	}
+
+	if err := s.app.Counters.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		var post models.Post
+		if err := s.db.First(&post, "id = ?", id).Error; err != nil {
+			t.Fatal(err)
+		}
+		if post.CommentsCount != 1 {
+			t.Errorf("comments_count of %s is %d, want 1", id, post.CommentsCount)
+		}
+	}
+}
+
+func TestArchive(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	id := s.createPost(alice.Token, "Old news")
+
+	expect(t, s.post("/api/posts/"+id+"/archive", bob.Token, nil), http.StatusNotFound)
+	res := s.post("/api/posts/"+id+"/archive", alice.Token, nil)
+	expect(t, res, http.StatusOK)
+	if status := res.data()["status"]; status != "archived" {
+		t.Errorf("status is %v, want archived", status)
+	}
+	expect(t, s.post("/api/posts/"+id+"/archive", alice.Token, nil), http.StatusConflict)
+
+	// Gone for everyone, but listed for the author
+	expect(t, s.get("/api/posts/"+id, bob.Token), http.StatusNotFound)
+	res = s.get("/api/posts", "")
+	expect(t, res, http.StatusOK)
+	if len(res.list()) != 0 {
+		t.Errorf("listed %d posts, want 0", len(res.list()))
+	}
+	res = s.get("/api/posts/archive", alice.Token)
+	expect(t, res, http.StatusOK)
+	if archived := res.list(); len(archived) != 1 || archived[0].(map[string]interface{})["id"] != id {
+		t.Errorf("archived posts: %v", archived)
+	}
+
+	expect(t, s.delete("/api/posts/"+id+"/archive", bob.Token), http.StatusNotFound)
+	res = s.delete("/api/posts/"+id+"/archive", alice.Token)
+	expect(t, res, http.StatusOK)
+	if status := res.data()["status"]; status != "published" {
+		t.Errorf("status is %v, want published", status)
+	}
+	expect(t, s.get("/api/posts/"+id, bob.Token), http.StatusOK)
+	expect(t, s.delete("/api/posts/"+id+"/archive", alice.Token), http.StatusConflict)
+}
+
+func TestUnrepost(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	carol := s.signUp("Carol")
+	id := s.createPost(alice.Token, "Worth sharing")
+
+	res := s.post("/api/posts/"+id+"/repost", bob.Token, nil)
+	expect(t, res, http.StatusCreated)
+	repostID := res.data()["id"].(string)
+	expect(t, s.put("/api/posts/"+repostID+"/reactions", carol.Token, gin.H{"type": "like"}), http.StatusOK)
+	expect(t, s.do(request{method: http.MethodPut, path: "/api/posts/" + repostID + "/bookmark", token: carol.Token}), http.StatusOK)
+
+	expect(t, s.delete("/api/posts/"+id+"/repost", carol.Token), http.StatusNotFound)
+	expect(t, s.delete("/api/posts/"+id+"/repost", bob.Token), http.StatusNoContent)
+	expect(t, s.delete("/api/posts/"+id+"/repost", bob.Token), http.StatusNotFound)
+
+	// Nothing is left pointing at the repost
+	for _, model := range []interface{}{&models.Reaction{}, &models.Bookmark{}} {
+		var count int64
+		if err := s.db.Model(model).Where("post_id = ?", repostID).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%T rows left for the repost: %d", model, count)
+		}
+	}
+
+	expect(t, s.post("/api/posts/"+id+"/repost", bob.Token, nil), http.StatusCreated)
+}
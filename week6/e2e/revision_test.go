@@ -0,0 +1,125 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/moderation"
+)
+
+// diffOps flattens a diff to "op text" lines
+func diffOps(revision map[string]interface{}) []string {
+	var ops []string
+	for _, item := range revision["diff"].([]interface{}) {
+		line := item.(map[string]interface{})
+		ops = append(ops, line["op"].(string)+" "+line["text"].(string))
+	}
+	return ops
+}
+
+func TestPostRevisions(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	s.app.Moderation.Filter = moderation.NewWordFilter([]string{"scam"})
+
+	res := s.post("/api/posts", alice.Token, gin.H{"title": "Draft one", "content": "first line\nscam line", "image": "img.png"})
+	expect(t, res, http.StatusCreated)
+	id := res.data()["id"].(string)
+	path := "/api/posts/" + id
+	expect(t, s.put(path, alice.Token, gin.H{"content": "first line\nsecond line"}), http.StatusOK)
+	expect(t, s.put(path, alice.Token, gin.H{"title": "Final", "content": "first line\nsecond line\nthird line"}), http.StatusOK)
+
+	expect(t, s.get("/api/posts/not-a-uuid/revisions", ""), http.StatusBadRequest)
+	res = s.get(path+"/revisions", "")
+	expect(t, res, http.StatusOK)
+	revisions := res.list()
+	if len(revisions) != 2 {
+		t.Fatalf("revisions %v", revisions)
+	}
+	newest, oldest := revisions[0].(map[string]interface{}), revisions[1].(map[string]interface{})
+	if newest["version"] != 2.0 || newest["title"] != "Draft one" || oldest["version"] != 1.0 {
+		t.Fatalf("revisions %v", revisions)
+	}
+	// Each diff leads to the version that replaced the revision
+	if ops := diffOps(newest); len(ops) != 3 || ops[0] != "equal first line" || ops[2] != "insert third line" {
+		t.Errorf("diff to the current post %v", ops)
+	}
+	if ops := diffOps(oldest); len(ops) != 3 || ops[1] != "delete scam line" || ops[2] != "insert second line" {
+		t.Errorf("diff to the second version %v", ops)
+	}
+
+	oldestPath := path + "/revisions/" + oldest["id"].(string) + "/restore"
+	expect(t, s.post(oldestPath, bob.Token, nil), http.StatusNotFound)
+	expect(t, s.post(path+"/revisions/"+uuid.NewString()+"/restore", alice.Token, nil), http.StatusNotFound)
+	expect(t, s.post(path+"/revisions/not-a-uuid/restore", alice.Token, nil), http.StatusNotFound)
+
+	// The text a restore brings back goes through the filter like any edit
+	if err := s.db.Where("target_id = ?", id).Delete(&models.Report{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	res = s.post(oldestPath, alice.Token, nil)
+	expect(t, res, http.StatusOK)
+	if res.data()["title"] != "Draft one" || res.data()["content"] != "first line\nscam line" {
+		t.Fatalf("restored %v", res.data())
+	}
+	var reports int64
+	if err := s.db.Model(&models.Report{}).Where("target_id = ? AND reason = ?", id, models.ReportFiltered).Count(&reports).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reports != 1 {
+		t.Errorf("filter reports after restoring %d, want 1", reports)
+	}
+
+	// The replaced text is a revision of its own now
+	res = s.get(path+"/revisions", "")
+	expect(t, res, http.StatusOK)
+	if revisions := res.list(); len(revisions) != 3 || revisions[0].(map[string]interface{})["title"] != "Final" {
+		t.Fatalf("revisions after restoring %v", revisions)
+	}
+
+	// Restoring a title another post took since is a conflict
+	s.createPost(bob.Token, "Final")
+	expect(t, s.post(path+"/revisions/"+res.list()[0].(map[string]interface{})["id"].(string)+"/restore", alice.Token, nil), http.StatusConflict)
+}
+
+func TestCommentRevisions(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	id := s.createPost(alice.Token, "Discuss")
+	comments := "/api/posts/" + id + "/comments"
+
+	res := s.post(comments, bob.Token, gin.H{"content": "first take"})
+	expect(t, res, http.StatusCreated)
+	path := comments + "/" + res.data()["id"].(string)
+	expect(t, s.put(path, bob.Token, gin.H{"content": "second take"}), http.StatusOK)
+
+	res = s.get(path+"/revisions", "")
+	expect(t, res, http.StatusOK)
+	revisions := res.list()
+	if len(revisions) != 1 {
+		t.Fatalf("revisions %v", revisions)
+	}
+	revision := revisions[0].(map[string]interface{})
+	if ops := diffOps(revision); len(ops) != 2 || ops[0] != "delete first take" || ops[1] != "insert second take" {
+		t.Errorf("diff %v", ops)
+	}
+
+	restore := path + "/revisions/" + revision["id"].(string) + "/restore"
+	expect(t, s.post(restore, alice.Token, nil), http.StatusNotFound)
+	expect(t, s.post(path+"/revisions/"+uuid.NewString()+"/restore", bob.Token, nil), http.StatusNotFound)
+	res = s.post(restore, bob.Token, nil)
+	expect(t, res, http.StatusOK)
+	if res.data()["content"] != "first take" {
+		t.Fatalf("restored %v", res.data())
+	}
+	res = s.get(path+"/revisions", "")
+	expect(t, res, http.StatusOK)
+	if revisions := res.list(); len(revisions) != 2 || revisions[0].(map[string]interface{})["content"] != "second take" {
+		t.Fatalf("revisions after restoring %v", revisions)
+	}
+}
@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGracefulShutdown stops the app while a login is still sending its body
+// and an event stream is open: the login gets its answer, the stream ends
+// and Stop returns without waiting for the timeout.
+func TestGracefulShutdown(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+
+	s.app.Config.ServerPort = "0"
+	if err := s.app.Start(); err != nil {
+		t.Fatal(err)
+	}
+	addr := s.app.Addr().String()
+
+	streamReq, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/api/events", nil)
+	streamReq.Header.Set("Authorization", "Bearer "+alice.Token)
+	stream, err := http.DefaultClient.Do(streamReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	if line, err := bufio.NewReader(stream.Body).ReadString('\n'); err != nil || !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("stream starts with %q, %v", line, err)
+	}
+
+	// The server answers 100 Continue once the handler reads the body, from
+	// then on the login is in flight
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	body := `{"email": "` + alice.Email + `", "password": "` + password + `"}`
+	io.WriteString(conn, "POST /api/auth/login HTTP/1.1\r\nHost: "+addr+"\r\nContent-Type: application/json\r\n"+
+		"Content-Length: "+strconv.Itoa(len(body))+"\r\nExpect: 100-continue\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, "100 Continue") {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	reader.ReadString('\n')
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.app.Stop(ctx) }()
+
+	// Once new connections are refused the shutdown is under way
+	for {
+		probe, err := net.Dial("tcp", addr)
+		if err != nil {
+			break
+		}
+		probe.Close()
+		time.Sleep(10 * time.Millisecond)
+	}
+	io.WriteString(conn, body)
+
+	res, err := http.ReadResponse(reader, nil)
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Errorf("in-flight login got %v, %v", res, err)
+	}
+	if _, err := io.ReadAll(stream.Body); err != nil {
+		t.Errorf("stream did not end cleanly: %v", err)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("stop: %v", err)
+	}
+}
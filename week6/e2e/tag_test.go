@@ -0,0 +1,30 @@
+package e2e
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrending(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+
+	id := s.createPost(alice.Token, "Learning #golang")
+	expect(t, s.post("/api/posts", alice.Token, gin.H{"title": "Later", "content": "About #drafts", "image": "img.png", "status": "draft"}), http.StatusCreated)
+	expect(t, s.post("/api/posts/"+id+"/comments", alice.Token, gin.H{"content": "More #golang and #testing"}), http.StatusCreated)
+	expect(t, s.put("/api/posts/"+id, alice.Token, gin.H{"content": "Still #golang"}), http.StatusOK)
+
+	res := s.get("/api/tags/trending", "")
+	expect(t, res, http.StatusOK)
+	scores := map[string]interface{}{}
+	for _, tag := range res.list() {
+		tag := tag.(map[string]interface{})
+		scores[tag["name"].(string)] = tag["score"]
+	}
+	if want := map[string]interface{}{"golang": 2.0, "testing": 1.0}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("trending is %v, want %v", scores, want)
+	}
+}
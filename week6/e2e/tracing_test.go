@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	postID := s.createPost(alice.Token, "Traced")
+
+	if _, err := tracing.Setup(context.Background(), tracing.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+	if err := tracing.RegisterGorm(s.db); err != nil {
+		t.Fatal(err)
+	}
+	initializers.RedisClient.AddHook(tracing.RedisHook{})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	res := s.do(request{method: http.MethodGet, path: "/api/posts", header: map[string]string{
+		"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	}})
+	expect(t, res, http.StatusOK)
+
+	server := serverSpan(t, recorder, traceID)
+	if server == nil || server.Name() != "GET /api/posts" {
+		t.Fatalf("no server span for the route: %v", server)
+	}
+	if server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("server span parent is %s, want the caller's span", server.Parent().SpanID())
+	}
+	children := childSpans(recorder, server)
+	for _, name := range []string{"gorm.select", "redis.get", "redis.set"} {
+		if !children[name] {
+			t.Errorf("no %s span under the request, got %v", name, children)
+		}
+	}
+
+	// Handlers that still query gorm themselves must hand it the request context too
+	traces := map[string]bool{traceID: true}
+	for i, tc := range []struct {
+		req   request
+		route string
+		query string
+	}{
+		{request{method: http.MethodPost, path: "/api/posts/" + postID + "/comments", token: bob.Token, body: gin.H{"content": "Nice"}}, "POST /api/posts/:postId/comments", "gorm.create"},
+		{request{method: http.MethodPut, path: "/api/posts/" + postID + "/bookmark", token: bob.Token}, "PUT /api/posts/:postId/bookmark", "gorm.create"},
+		{request{method: http.MethodGet, path: "/api/bookmarks", token: bob.Token}, "GET /api/bookmarks", "gorm.select"},
+		{request{method: http.MethodGet, path: "/api/notifications", token: alice.Token}, "GET /api/notifications", "gorm.select"},
+		{request{method: http.MethodGet, path: "/api/posts/" + postID + "/revisions"}, "GET /api/posts/:postId/revisions", "gorm.select"},
+		{request{method: http.MethodGet, path: "/api/posts/trash", token: alice.Token}, "GET /api/posts/trash", "gorm.select"},
+		{request{method: http.MethodGet, path: "/api/conversations", token: alice.Token}, "GET /api/conversations", "gorm.select"},
+	} {
+		traceID := fmt.Sprintf("%032x", i+1)
+		traces[traceID] = true
+		if tc.req.header == nil {
+			tc.req.header = map[string]string{}
+		}
+		tc.req.header["traceparent"] = "00-" + traceID + "-00f067aa0ba902b7-01"
+		res := s.do(tc.req)
+		if res.Code >= 400 {
+			t.Errorf("%s: got status %d: %v", tc.route, res.Code, res.Body)
+			continue
+		}
+
+		server := serverSpan(t, recorder, traceID)
+		if server == nil || server.Name() != tc.route {
+			t.Errorf("no server span for %s: %v", tc.route, server)
+			continue
+		}
+		if children := childSpans(recorder, server); !children[tc.query] {
+			t.Errorf("no %s span under %s, got %v", tc.query, tc.route, children)
+		}
+	}
+	for _, span := range recorder.Ended() {
+		if !traces[span.SpanContext().TraceID().String()] {
+			t.Errorf("span %s is in trace %s, want one of the requests'", span.Name(), span.SpanContext().TraceID())
+		}
+	}
+}
+
+// serverSpan returns the span of the request made in traceID
+func serverSpan(t *testing.T, recorder *tracetest.SpanRecorder, traceID string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindServer && span.SpanContext().TraceID().String() == traceID {
+			return span
+		}
+	}
+	return nil
+}
+
+// childSpans names the spans started right under parent
+func childSpans(recorder *tracetest.SpanRecorder, parent sdktrace.ReadOnlySpan) map[string]bool {
+	children := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() == parent.SpanContext().SpanID() {
+			children[span.Name()] = true
+		}
+	}
+	return children
+}
@@ -0,0 +1,125 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/models"
+)
+
+// trash returns the ids of the posts and comments in the user's trash
+func (s *server) trash(token string) (posts, comments []string) {
+	s.t.Helper()
+	res := s.get("/api/posts/trash", token)
+	expect(s.t, res, http.StatusOK)
+	for _, item := range res.data()["posts"].([]interface{}) {
+		posts = append(posts, item.(map[string]interface{})["id"].(string))
+	}
+	for _, item := range res.data()["comments"].([]interface{}) {
+		comments = append(comments, item.(map[string]interface{})["id"].(string))
+	}
+	return posts, comments
+}
+
+func TestTrashRestore(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	id := s.createPost(alice.Token, "Second thoughts")
+	path := "/api/posts/" + id
+
+	expect(t, s.delete(path, alice.Token), http.StatusNoContent)
+	expect(t, s.get(path, ""), http.StatusNotFound)
+	if posts, _ := s.trash(alice.Token); len(posts) != 1 || posts[0] != id {
+		t.Fatalf("alice's trash %v", posts)
+	}
+	if posts, _ := s.trash(bob.Token); len(posts) != 0 {
+		t.Fatalf("bob's trash %v", posts)
+	}
+
+	expect(t, s.post("/api/posts/not-a-uuid/restore", alice.Token, nil), http.StatusBadRequest)
+	expect(t, s.post(path+"/restore", bob.Token, nil), http.StatusNotFound)
+	expect(t, s.post(path+"/restore", alice.Token, nil), http.StatusOK)
+	expect(t, s.post(path+"/restore", alice.Token, nil), http.StatusNotFound)
+	expect(t, s.get(path, ""), http.StatusOK)
+
+	// A comment comes back with the replies deleted along with it
+	res := s.post(path+"/comments", bob.Token, gin.H{"content": "Top"})
+	expect(t, res, http.StatusCreated)
+	comment := res.data()["id"].(string)
+	res = s.post(path+"/comments", alice.Token, gin.H{"content": "Reply", "parent_id": comment})
+	expect(t, res, http.StatusCreated)
+	reply := res.data()["id"].(string)
+
+	expect(t, s.delete(path+"/comments/"+comment, bob.Token), http.StatusNoContent)
+	if _, comments := s.trash(bob.Token); len(comments) != 1 || comments[0] != comment {
+		t.Fatalf("bob's trashed comments %v", comments)
+	}
+	expect(t, s.post(path+"/comments/"+reply+"/restore", alice.Token, nil), http.StatusConflict)
+	expect(t, s.post(path+"/comments/"+comment+"/restore", alice.Token, nil), http.StatusNotFound)
+	expect(t, s.post(path+"/comments/"+comment+"/restore", bob.Token, nil), http.StatusOK)
+
+	res = s.get(path+"/comments/"+comment+"/replies", "")
+	expect(t, res, http.StatusOK)
+	if replies := res.list(); len(replies) != 1 || replies[0].(map[string]interface{})["id"] != reply {
+		t.Fatalf("replies after restore %v", replies)
+	}
+	if err := s.app.Counters.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if count := s.get(path, "").data()["comments_count"]; count != 2.0 {
+		t.Errorf("comments_count is %v, want 2", count)
+	}
+
+	// A repost that was taken over by a newer one can't come back
+	res = s.post(path+"/repost", bob.Token, nil)
+	expect(t, res, http.StatusCreated)
+	repost := res.data()["id"].(string)
+	expect(t, s.delete("/api/posts/"+repost, bob.Token), http.StatusNoContent)
+	expect(t, s.post(path+"/repost", bob.Token, nil), http.StatusCreated)
+	expect(t, s.post("/api/posts/"+repost+"/restore", bob.Token, nil), http.StatusConflict)
+}
+
+func TestTrashPurge(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	kept := s.createPost(alice.Token, "Recently deleted")
+	purged := s.createPost(alice.Token, "Deleted long ago")
+	expect(t, s.post("/api/posts/"+purged+"/comments", bob.Token, gin.H{"content": "Gone too"}), http.StatusCreated)
+	expect(t, s.put("/api/posts/"+purged+"/reactions", bob.Token, gin.H{"type": "like"}), http.StatusOK)
+
+	expect(t, s.delete("/api/posts/"+kept, alice.Token), http.StatusNoContent)
+	expect(t, s.delete("/api/posts/"+purged, alice.Token), http.StatusNoContent)
+	past := s.app.Trash.Cutoff().Add(-time.Hour)
+	if err := s.db.Unscoped().Model(&models.Post{}).Where("id = ?", purged).Update("deleted_at", past).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Past the retention a post is no longer offered for restoring
+	if posts, _ := s.trash(alice.Token); len(posts) != 1 || posts[0] != kept {
+		t.Fatalf("trash %v", posts)
+	}
+	expect(t, s.post("/api/posts/"+purged+"/restore", alice.Token, nil), http.StatusNotFound)
+
+	if err := s.app.Trash.Purge(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []interface{}{&models.Post{}, &models.Comment{}, &models.Reaction{}} {
+		column := "post_id"
+		if _, ok := model.(*models.Post); ok {
+			column = "id"
+		}
+		var count int64
+		if err := s.db.Unscoped().Model(model).Where(column+" = ?", purged).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%T rows left for the purged post: %d", model, count)
+		}
+	}
+	expect(t, s.post("/api/posts/"+kept+"/restore", alice.Token, nil), http.StatusOK)
+}
@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// upload posts data as the "file" field of a multipart form
+func (s *server) upload(path, token, field string, data []byte) response {
+	s.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(field, "upload.bin")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	s.app.Router.ServeHTTP(recorder, req)
+
+	res := response{Code: recorder.Code, Header: recorder.Header()}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &res.Body); err != nil {
+		s.t.Fatalf("POST %s: decode %q: %v", path, recorder.Body.String(), err)
+	}
+	return res
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+func TestUploadImage(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+
+	res := s.upload("/api/uploads/images", alice.Token, "file", pngImage(t, 300, 200))
+	expect(t, res, http.StatusCreated)
+	upload := res.data()
+	prefix := s.app.Config.UploadBaseURL + "/images/" + alice.ID + "/"
+	if url, _ := upload["url"].(string); !strings.HasPrefix(url, prefix) {
+		t.Fatalf("url %v, want it under %s", upload["url"], prefix)
+	}
+	if upload["content_type"] != "image/png" || upload["width"] != 300.0 || upload["height"] != 200.0 {
+		t.Errorf("upload %v", upload)
+	}
+
+	// The local store serves both files from the url it handed out
+	for _, field := range []string{"url", "thumbnail_url"} {
+		path := "/uploads" + strings.TrimPrefix(upload[field].(string), s.app.Config.UploadBaseURL)
+		recorder := httptest.NewRecorder()
+		s.app.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusOK || !strings.HasPrefix(recorder.Header().Get("Content-Type"), "image/") {
+			t.Errorf("GET %s: %d %s", path, recorder.Code, recorder.Header().Get("Content-Type"))
+		}
+	}
+
+	expect(t, s.upload("/api/uploads/images", alice.Token, "image", pngImage(t, 10, 10)), http.StatusBadRequest)
+	expect(t, s.upload("/api/uploads/images", alice.Token, "file", []byte("just some text, not an image")), http.StatusUnsupportedMediaType)
+	tooLarge := append(pngImage(t, 10, 10), make([]byte, s.app.Config.UploadMaxSize)...)
+	expect(t, s.upload("/api/uploads/images", alice.Token, "file", tooLarge), http.StatusRequestEntityTooLarge)
+}
+
+func TestUploadAvatar(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+
+	res := s.upload("/api/uploads/avatar", alice.Token, "file", pngImage(t, 600, 600))
+	expect(t, res, http.StatusOK)
+	user := res.data()["user"].(map[string]interface{})
+	upload := res.data()["upload"].(map[string]interface{})
+	if user["profile_image"] != upload["url"] || upload["width"] != 512.0 {
+		t.Fatalf("avatar %v", res.data())
+	}
+	if url := upload["url"].(string); !strings.HasPrefix(url, s.app.Config.UploadBaseURL+"/avatars/"+alice.ID+"/") {
+		t.Errorf("url %s", url)
+	}
+
+	expect(t, s.upload("/api/uploads/avatar", alice.Token, "file", []byte("not an image")), http.StatusUnsupportedMediaType)
+}
@@ -0,0 +1,53 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFollow(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+
+	expect(t, s.post("/api/users/follow/"+bob.ID, "", nil), http.StatusUnauthorized)
+	expect(t, s.post("/api/users/follow/"+alice.ID, alice.Token, nil), http.StatusBadRequest)
+	expect(t, s.post("/api/users/follow/"+uuid.NewString(), alice.Token, nil), http.StatusNotFound)
+
+	expect(t, s.post("/api/users/follow/"+bob.ID, alice.Token, nil), http.StatusOK)
+	expect(t, s.post("/api/users/follow/"+bob.ID, alice.Token, nil), http.StatusBadRequest)
+
+	expect(t, s.delete("/api/users/unfollow/"+bob.ID, alice.Token), http.StatusOK)
+	expect(t, s.delete("/api/users/unfollow/"+bob.ID, alice.Token), http.StatusBadRequest)
+}
+
+func TestNewsFeed(t *testing.T) {
+	s := newServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+	carol := s.signUp("Carol")
+
+	expect(t, s.post("/api/users/follow/"+bob.ID, alice.Token, nil), http.StatusOK)
+	bobPost := s.createPost(bob.Token, "From Bob")
+	s.createPost(carol.Token, "From Carol")
+
+	feed := func() []interface{} {
+		t.Helper()
+		res := s.get("/api/users/newsfeeds", alice.Token)
+		expect(t, res, http.StatusOK)
+		posts, _ := res.data()["newfeeds"].([]interface{})
+		return posts
+	}
+
+	posts := feed()
+	if len(posts) != 1 || posts[0].(map[string]interface{})["id"] != bobPost {
+		t.Fatalf("feed while following bob: %v", posts)
+	}
+
+	expect(t, s.delete("/api/users/unfollow/"+bob.ID, alice.Token), http.StatusOK)
+	if posts := feed(); len(posts) != 0 {
+		t.Fatalf("feed after unfollowing bob: %v", posts)
+	}
+}
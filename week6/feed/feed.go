@@ -0,0 +1,182 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/logging"
+	"github.com/trung/backend-engineerpro/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MaxLength is how many entries a cached feed keeps
+const MaxLength = 500
+
+func feedKey(userID uuid.UUID) string {
+	return "feed:" + userID.String()
+}
+
+// addIfCached only touches feeds that are already cached, a missing feed is
+// rebuilt from Postgres the next time it is read
+var addIfCached = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+	redis.call("ZREMRANGEBYRANK", KEYS[1], 0, -tonumber(ARGV[3]) - 1)
+end
+return 0`)
+
+// Feed keeps every user's news feed as a Redis sorted set of post ids scored
+// by publish time. New posts are pushed to the followers' feeds (fan-out on
+// write); Postgres stays the source of truth when Redis is unavailable.
+type Feed struct {
+	DB    *gorm.DB
+	Redis *redis.Client
+
+	// OnFanOut is called with the followers a newly published post went out to
+	OnFanOut func(ctx context.Context, post models.Post, followerIDs []uuid.UUID)
+}
+
+func New(DB *gorm.DB, Redis *redis.Client) *Feed {
+	return &Feed{DB: DB, Redis: Redis}
+}
+
+// FanOut pushes a freshly published post to the feeds of its author's followers.
+func (f *Feed) FanOut(ctx context.Context, post models.Post) error {
+	if !initializers.RedisAvailable() && f.OnFanOut == nil {
+		return nil
+	}
+
+	var followerIDs []uuid.UUID
+	if err := f.DB.WithContext(ctx).Model(&models.UserFollower{}).Where("following_id = ?", post.UserID).Pluck("follower_id", &followerIDs).Error; err != nil {
+		return fmt.Errorf("load followers of %s: %w", post.UserID, err)
+	}
+	if f.OnFanOut != nil {
+		f.OnFanOut(ctx, post, followerIDs)
+	}
+	if !initializers.RedisAvailable() {
+		return nil
+	}
+
+	score := publishedScore(post)
+	_, err := f.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, followerID := range followerIDs {
+			addIfCached.Eval(ctx, pipe, []string{feedKey(followerID)}, score, post.ID.String(), MaxLength)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("fan out post %s: %w", post.ID, err)
+	}
+	return nil
+}
+
+// Invalidate drops a cached feed, e.g. after the user followed or unfollowed someone.
+func (f *Feed) Invalidate(ctx context.Context, userID uuid.UUID) {
+	if !initializers.RedisAvailable() {
+		return
+	}
+	if err := f.Redis.Del(ctx, feedKey(userID)).Err(); err != nil {
+		logging.FromContext(ctx).Warn("feed: invalidate failed", zap.Stringer("user_id", userID), zap.Error(err))
+	}
+}
+
+// Posts returns one page of the user's news feed, newest first.
+func (f *Feed) Posts(ctx context.Context, userID uuid.UUID, page, limit int) ([]models.Post, error) {
+	offset := (page - 1) * limit
+
+	ids, ok := f.cachedIDs(ctx, userID, offset, limit)
+	if !ok {
+		var posts []models.Post
+		err := f.followedPosts(ctx, userID).Order("published_at DESC").Limit(limit).Offset(offset).Find(&posts).Error
+		return posts, err
+	}
+	if len(ids) == 0 {
+		return []models.Post{}, nil
+	}
+
+	var found []models.Post
+	if err := f.DB.WithContext(ctx).Where("id IN ? AND status = ?", ids, models.PostPublished).Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	// Keep the feed order, posts deleted in the meantime simply drop out
+	byID := make(map[string]models.Post, len(found))
+	for _, post := range found {
+		byID[post.ID.String()] = post
+	}
+	posts := make([]models.Post, 0, len(ids))
+	for _, id := range ids {
+		if post, ok := byID[id]; ok {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func (f *Feed) cachedIDs(ctx context.Context, userID uuid.UUID, offset, limit int) ([]string, bool) {
+	if !initializers.RedisAvailable() || offset+limit > MaxLength {
+		return nil, false
+	}
+
+	key := feedKey(userID)
+	exists, err := f.Redis.Exists(ctx, key).Result()
+	if err != nil {
+		return nil, false
+	}
+	if exists == 0 {
+		if err := f.rebuild(ctx, userID); err != nil {
+			logging.FromContext(ctx).Warn("feed: rebuild failed", zap.Stringer("user_id", userID), zap.Error(err))
+			return nil, false
+		}
+	}
+
+	members, err := f.Redis.ZRevRange(ctx, key, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, false
+	}
+
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		if member != "" {
+			ids = append(ids, member)
+		}
+	}
+	return ids, true
+}
+
+func (f *Feed) rebuild(ctx context.Context, userID uuid.UUID) error {
+	var posts []models.Post
+	if err := f.followedPosts(ctx, userID).Select("id", "published_at", "created_at").Order("published_at DESC").Limit(MaxLength).Find(&posts).Error; err != nil {
+		return err
+	}
+
+	// An empty feed is stored as a placeholder member so it counts as cached
+	members := []*redis.Z{{Score: 0, Member: ""}}
+	for _, post := range posts {
+		members = append(members, &redis.Z{Score: publishedScore(post), Member: post.ID.String()})
+	}
+
+	key := feedKey(userID)
+	_, err := f.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, members...)
+		return nil
+	})
+	return err
+}
+
+func (f *Feed) followedPosts(ctx context.Context, userID uuid.UUID) *gorm.DB {
+	following := f.DB.WithContext(ctx).Model(&models.UserFollower{}).Select("following_id").Where("follower_id = ?", userID)
+	return f.DB.WithContext(ctx).Where("user_id IN (?) AND status = ?", following, models.PostPublished)
+}
+
+func publishedScore(post models.Post) float64 {
+	if post.PublishedAt != nil {
+		return float64(post.PublishedAt.UnixMilli())
+	}
+	return float64(post.CreatedAt.UnixMilli())
+}
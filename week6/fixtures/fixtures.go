@@ -0,0 +1,352 @@
+// Package fixtures generates a realistic data set for development and tests.
+// The same seed and Options.Now always give the same users, ids, follow graph,
+// content and timestamps; the timestamps are spread over the days before Now.
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/utils"
+	"gorm.io/gorm"
+)
+
+// Password of every generated user
+const Password = "password123"
+
+const batchSize = 500
+
+// DefaultNow is where the generated time span ends unless Options.Now says
+// otherwise, fixed so runs on different days give the same data
+var DefaultNow = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Options of a data set. A negative count means its default, zero means none.
+type Options struct {
+	Seed  int64
+	Users int
+	// Averages, the actual numbers vary per user and post
+	FollowsPerUser  int
+	PostsPerUser    int
+	CommentsPerPost int
+	LikesPerPost    int
+	// Everything happens within Days before Now
+	Now  time.Time
+	Days int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Users < 0 {
+		o.Users = 50
+	}
+	if o.FollowsPerUser < 0 {
+		o.FollowsPerUser = 10
+	}
+	if o.PostsPerUser < 0 {
+		o.PostsPerUser = 5
+	}
+	if o.CommentsPerPost < 0 {
+		o.CommentsPerPost = 3
+	}
+	if o.LikesPerPost < 0 {
+		o.LikesPerPost = 5
+	}
+	if o.Now.IsZero() {
+		o.Now = DefaultNow
+	}
+	if o.Days <= 0 {
+		o.Days = 30
+	}
+	return o
+}
+
+// Set is what Generate created. The first user is an admin, all of them log in with Password.
+type Set struct {
+	Users     []models.User
+	Follows   []models.UserFollower
+	Posts     []models.Post
+	Comments  []models.Comment
+	Reactions []models.Reaction
+}
+
+type generator struct {
+	Options
+	rng *rand.Rand
+}
+
+// Generate inserts a data set in one transaction. It does not wipe first, two
+// runs with the same seed clash on the unique emails.
+func Generate(db *gorm.DB, options Options) (*Set, error) {
+	g := &generator{Options: options.withDefaults()}
+	if g.Users == 0 {
+		return nil, errors.New("a data set needs at least one user, the admin")
+	}
+	g.rng = rand.New(rand.NewSource(g.Seed))
+
+	// bcrypt is slow on purpose, everybody shares the one hash
+	hashedPassword, err := utils.HashPassword(Password)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &Set{}
+	set.Users = g.users(hashedPassword)
+	set.Follows = g.follows(set.Users)
+	set.Posts = g.posts(set.Users)
+	set.Comments = g.comments(set.Users, set.Posts)
+	set.Reactions = g.reactions(set.Users, set.Posts)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		tables := []struct {
+			rows  interface{}
+			count int
+		}{
+			{&set.Users, len(set.Users)},
+			{&set.Follows, len(set.Follows)},
+			{&set.Posts, len(set.Posts)},
+			{&set.Comments, len(set.Comments)},
+			{&set.Reactions, len(set.Reactions)},
+		}
+		for _, table := range tables {
+			if table.count == 0 {
+				continue
+			}
+			if err := tx.CreateInBatches(table.rows, batchSize).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Wipe deletes every row of every table, children first
+func Wipe(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		for i := len(models.All) - 1; i >= 0; i-- {
+			if err := tx.Unscoped().Delete(models.All[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *generator) users(hashedPassword string) []models.User {
+	users := make([]models.User, g.Users)
+	for i := range users {
+		first := firstNames[g.rng.Intn(len(firstNames))]
+		last := lastNames[g.rng.Intn(len(lastNames))]
+		// The index keeps usernames and emails unique whatever names come up
+		username := fmt.Sprintf("%s_%s%d", strings.ToLower(first), strings.ToLower(last), i)
+		if len(username) > 30 {
+			username = username[len(username)-30:]
+		}
+		createdAt := g.before(g.Now)
+
+		users[i] = models.User{
+			ID:           g.uuid(),
+			Name:         first + " " + last,
+			Username:     username,
+			Email:        username + "@example.com",
+			Age:          int64(18 + g.rng.Intn(50)),
+			Password:     hashedPassword,
+			Role:         models.RoleUser,
+			Provider:     "local",
+			ProfileImage: fmt.Sprintf("https://i.pravatar.cc/150?u=%s", username),
+			Verified:     true,
+			CreatedAt:    createdAt,
+			UpdatedAt:    createdAt,
+		}
+	}
+	users[0].Name, users[0].Username, users[0].Email, users[0].Role = "Admin", "admin", "admin@example.com", models.RoleAdmin
+	return users
+}
+
+// follows builds a power-law graph: everybody follows a few users, picked by a
+// Zipf distribution so a handful of accounts end up with most of the followers
+func (g *generator) follows(users []models.User) []models.UserFollower {
+	if len(users) < 2 || g.FollowsPerUser == 0 {
+		return nil
+	}
+	popularity := g.rng.Perm(len(users))
+	zipf := rand.NewZipf(g.rng, 1.2, 1, uint64(len(users)-1))
+
+	var follows []models.UserFollower
+	for i, follower := range users {
+		want := 1 + g.rng.Intn(2*g.FollowsPerUser)
+		seen := map[int]bool{i: true}
+		for attempts := 0; len(seen)-1 < want && attempts < 4*want; attempts++ {
+			j := popularity[zipf.Uint64()]
+			if seen[j] {
+				continue
+			}
+			seen[j] = true
+			follows = append(follows, models.UserFollower{
+				FollowerID:  follower.ID,
+				FollowingID: users[j].ID,
+				CreatedAt:   g.between(maxTime(follower.CreatedAt, users[j].CreatedAt), g.Now),
+			})
+		}
+	}
+	return follows
+}
+
+func (g *generator) posts(users []models.User) []models.Post {
+	var posts []models.Post
+	for _, user := range users {
+		for n := g.rng.Intn(2*g.PostsPerUser + 1); n > 0; n-- {
+			createdAt := g.between(user.CreatedAt, g.Now)
+			posts = append(posts, models.Post{
+				ID: g.uuid(),
+				// Non-empty titles are unique
+				Title:       fmt.Sprintf("%s #%d", g.title(), len(posts)+1),
+				Content:     g.paragraph(2 + g.rng.Intn(4)),
+				Image:       fmt.Sprintf("https://picsum.photos/seed/%d/800/600", g.rng.Intn(1000)),
+				UserID:      user.ID,
+				Status:      models.PostPublished,
+				PublishedAt: &createdAt,
+				CreatedAt:   createdAt,
+				UpdatedAt:   createdAt,
+			})
+		}
+	}
+	return posts
+}
+
+func (g *generator) comments(users []models.User, posts []models.Post) []models.Comment {
+	var comments []models.Comment
+	for i := range posts {
+		post := &posts[i]
+		first := len(comments)
+		for n := g.rng.Intn(2*g.CommentsPerPost + 1); n > 0; n-- {
+			createdAt := g.between(post.CreatedAt, g.Now)
+			comment := models.Comment{
+				ID:        g.uuid().String(),
+				Content:   g.paragraph(1),
+				PostID:    post.ID,
+				UserID:    users[g.rng.Intn(len(users))].ID,
+				CreateAt:  createdAt,
+				UpdatedAt: createdAt,
+			}
+			// About a third are replies to an earlier comment of the thread
+			if len(comments) > first && g.rng.Intn(3) == 0 {
+				parent := comments[first+g.rng.Intn(len(comments)-first)]
+				if parent.Depth < models.MaxCommentDepth {
+					comment.ParentID = &parent.ID
+					comment.Depth = parent.Depth + 1
+					comment.CreateAt = g.between(parent.CreateAt, g.Now)
+					comment.UpdatedAt = comment.CreateAt
+				}
+			}
+			comments = append(comments, comment)
+		}
+		post.CommentsCount = int64(len(comments) - first)
+	}
+	return comments
+}
+
+func (g *generator) reactions(users []models.User, posts []models.Post) []models.Reaction {
+	var reactions []models.Reaction
+	for i := range posts {
+		post := &posts[i]
+		n := g.rng.Intn(2*g.LikesPerPost + 1)
+		if n > len(users) {
+			n = len(users)
+		}
+		for _, j := range g.rng.Perm(len(users))[:n] {
+			reactionType := models.ReactionLike
+			if g.rng.Intn(4) == 0 {
+				reactionType = reactionTypes[g.rng.Intn(len(reactionTypes))]
+			}
+			createdAt := g.between(post.CreatedAt, g.Now)
+			reactions = append(reactions, models.Reaction{
+				ID:        g.uuid().String(),
+				PostID:    post.ID,
+				UserID:    users[j].ID,
+				Type:      reactionType,
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			})
+		}
+		post.ReactionsCount = int64(n)
+	}
+	return reactions
+}
+
+func (g *generator) uuid() uuid.UUID {
+	id, _ := uuid.NewRandomFromReader(g.rng)
+	return id
+}
+
+// before is a moment within Days before t
+func (g *generator) before(t time.Time) time.Time {
+	return t.Add(-time.Duration(g.rng.Int63n(int64(time.Duration(g.Days) * 24 * time.Hour))))
+}
+
+func (g *generator) between(from, to time.Time) time.Time {
+	if !to.After(from) {
+		return from
+	}
+	return from.Add(time.Duration(g.rng.Int63n(int64(to.Sub(from)))))
+}
+
+func (g *generator) title() string {
+	words := make([]string, 2+g.rng.Intn(4))
+	for i := range words {
+		words[i] = capitalize(vocabulary[g.rng.Intn(len(vocabulary))])
+	}
+	return strings.Join(words, " ")
+}
+
+func (g *generator) paragraph(sentences int) string {
+	parts := make([]string, sentences)
+	for i := range parts {
+		words := make([]string, 5+g.rng.Intn(10))
+		for j := range words {
+			words[j] = vocabulary[g.rng.Intn(len(vocabulary))]
+		}
+		parts[i] = capitalize(strings.Join(words, " ")) + "."
+	}
+	return strings.Join(parts, " ")
+}
+
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+var reactionTypes = []string{models.ReactionLove, models.ReactionHaha, models.ReactionSad, models.ReactionAngry}
+
+var firstNames = []string{
+	"Alice", "Bob", "Carol", "David", "Emma", "Frank", "Grace", "Henry", "Ivy", "Jack",
+	"Kate", "Liam", "Mia", "Noah", "Olivia", "Paul", "Quinn", "Ruby", "Sam", "Tina",
+	"Uma", "Victor", "Wendy", "Xavier", "Yara", "Zoe", "Minh", "Lan", "Hoa", "Trung",
+}
+
+var lastNames = []string{
+	"Nguyen", "Tran", "Le", "Pham", "Smith", "Johnson", "Brown", "Garcia", "Miller", "Davis",
+	"Wilson", "Moore", "Taylor", "Anderson", "Thomas", "Martin", "Lee", "Walker", "Hall", "Young",
+}
+
+var vocabulary = []string{
+	"go", "backend", "database", "cache", "redis", "postgres", "query", "index", "latency", "service",
+	"deploy", "weekend", "coffee", "morning", "project", "team", "review", "release", "bug", "feature",
+	"api", "design", "scaling", "queue", "event", "stream", "test", "today", "finally", "learned",
+	"shipped", "refactor", "performance", "memory", "goroutine", "channel", "interface", "struct", "error", "handler",
+	"the", "a", "with", "about", "after", "before", "new", "better", "simple", "fast",
+}
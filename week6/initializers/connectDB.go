@@ -2,8 +2,10 @@ package initializers
 
 import (
 	"fmt"
-	"log"
 
+	"github.com/trung/backend-engineerpro/logging"
+	"github.com/trung/backend-engineerpro/tracing"
+	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -14,9 +16,21 @@ func ConnectDB(config *Config) {
 	var err error
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Shanghai", config.DBHost, config.DBUserName, config.DBUserPassword, config.DBName, config.DBPort)
 
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger(config.SlowQueryThreshold)})
 	if err != nil {
-		log.Fatal("Failed to connect to the Database")
+		zap.L().Fatal("failed to connect to the database", zap.Error(err))
 	}
-	fmt.Println("🚀 Connected Successfully to the Database")
+	if err := tracing.RegisterGorm(DB); err != nil {
+		zap.L().Fatal("could not trace database queries", zap.Error(err))
+	}
+	zap.L().Info("connected to the database")
+}
+
+// CloseDB closes the database connection pool
+func CloseDB() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
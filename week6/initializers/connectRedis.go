@@ -2,27 +2,147 @@ package initializers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/trung/backend-engineerpro/tracing"
+	"github.com/trung/backend-engineerpro/utils"
+	"go.uber.org/zap"
 )
 
 var ctx = context.Background()
 
 var RedisClient *redis.Client
 
+// RedisBreaker guards every command sent through RedisClient. While it is open
+// callers should skip Redis and go straight to the database.
+var RedisBreaker = utils.NewCircuitBreaker(3, 10*time.Second)
+
+var redisTimeout = 300 * time.Millisecond
+
+const redisWatchInterval = 5 * time.Second
+
+var stopRedisWatch = make(chan struct{})
+
 func ConnectRedis(config *Config) {
+	if config.RedisTimeout > 0 {
+		redisTimeout = config.RedisTimeout
+	}
+
 	// Assign to the global RedisClient variable
 	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", config.RedisHost, config.RedisPort),
-		Password: config.RedisPass,
+		Addr:         fmt.Sprintf("%s:%s", config.RedisHost, config.RedisPort),
+		Password:     config.RedisPass,
+		DialTimeout:  redisTimeout,
+		ReadTimeout:  redisTimeout,
+		WriteTimeout: redisTimeout,
+		PoolTimeout:  redisTimeout,
+		MaxRetries:   1,
 	})
+	// Traced first so the commands the breaker turns away show up as well
+	RedisClient.AddHook(tracing.RedisHook{})
+	RedisClient.AddHook(breakerHook{RedisBreaker})
+
+	RedisBreaker.OnStateChange = func(from, to utils.BreakerState) {
+		zap.L().Warn("redis circuit breaker changed state", zap.String("from", string(from)), zap.String("to", string(to)))
+	}
 
-	// Check if the connection is successful
-	if err := RedisClient.Ping(ctx).Err(); err != nil {
-		log.Fatalf("Could not connect to Redis: %v", err)
+	// The server keeps running without Redis, the watcher below reconnects
+	if err := pingRedis(); err != nil {
+		zap.L().Warn("could not connect to redis, running in degraded mode", zap.Error(err))
+		RedisBreaker.Trip()
+	} else {
+		zap.L().Info("connected to redis")
 	}
 
-	fmt.Println("🚀 Connected Successfully to the Redis")
+	go watchRedis()
+}
+
+// RedisAvailable reports whether Redis calls are currently being let through.
+func RedisAvailable() bool {
+	return RedisClient != nil && RedisBreaker.State() == utils.BreakerClosed
+}
+
+func RedisStatus() string {
+	if RedisAvailable() {
+		return "up"
+	}
+	return "down"
+}
+
+// RedisContext bounds a single Redis call so a slow server can't stall a request.
+func RedisContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, redisTimeout)
+}
+
+func pingRedis() error {
+	pingCtx, cancel := RedisContext(ctx)
+	defer cancel()
+	return RedisClient.Ping(pingCtx).Err()
+}
+
+// watchRedis keeps probing Redis so the breaker closes again once it is back,
+// even when no request happens to touch the cache.
+func watchRedis() {
+	ticker := time.NewTicker(redisWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopRedisWatch:
+			return
+		case <-ticker.C:
+			pingRedis()
+		}
+	}
+}
+
+// CloseRedis stops probing Redis and closes the connection pool
+func CloseRedis() error {
+	close(stopRedisWatch)
+	return RedisClient.Close()
+}
+
+type breakerHook struct {
+	breaker *utils.CircuitBreaker
+}
+
+func (h breakerHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, h.breaker.Allow()
+}
+
+func (h breakerHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.record(cmd.Err())
+	return nil
+}
+
+func (h breakerHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, h.breaker.Allow()
+}
+
+func (h breakerHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && cmdErr != redis.Nil {
+			err = cmdErr
+			break
+		}
+	}
+	h.record(err)
+	return nil
+}
+
+func (h breakerHook) record(err error) {
+	var redisErr redis.Error
+	switch {
+	case errors.Is(err, utils.ErrCircuitOpen), errors.Is(err, context.Canceled):
+		// rejected by the breaker itself or abandoned by the caller
+	case err == nil, err == redis.Nil, errors.As(err, &redisErr):
+		// the server answered, even if it was an error reply
+		h.breaker.Success()
+	default:
+		h.breaker.Failure()
+	}
 }
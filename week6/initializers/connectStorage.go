@@ -0,0 +1,31 @@
+package initializers
+
+import (
+	"github.com/trung/backend-engineerpro/storage"
+	"go.uber.org/zap"
+)
+
+var Storage storage.BlobStore
+
+func ConnectStorage(config *Config) {
+	var err error
+
+	switch config.UploadDriver {
+	case "s3":
+		Storage, err = storage.NewS3Store(ctx, storage.S3Options{
+			Endpoint:  config.S3Endpoint,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			Bucket:    config.S3Bucket,
+			UseSSL:    config.S3UseSSL,
+			PublicURL: config.S3PublicURL,
+		})
+	default:
+		Storage, err = storage.NewLocalStore(config.UploadDir, config.UploadBaseURL)
+	}
+
+	if err != nil {
+		zap.L().Fatal("could not set up upload storage", zap.String("driver", config.UploadDriver), zap.Error(err))
+	}
+	zap.L().Info("upload storage ready", zap.String("driver", config.UploadDriver))
+}
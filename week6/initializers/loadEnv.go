@@ -14,6 +14,11 @@ type Config struct {
 	DBPort         string `mapstructure:"POSTGRES_PORT"`
 	ServerPort     string `mapstructure:"PORT"`
 
+	ReadTimeout     time.Duration `mapstructure:"READ_TIMEOUT"`
+	WriteTimeout    time.Duration `mapstructure:"WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `mapstructure:"IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+
 	ClientOrigin string `mapstructure:"CLIENT_ORIGIN"`
 
 	AccessTokenPrivateKey  string        `mapstructure:"ACCESS_TOKEN_PRIVATE_KEY"`
@@ -28,6 +33,40 @@ type Config struct {
 	RedisPass string `mapstructure:"REDIS_PASS"`
 	RedisHost string `mapstructure:"REDIS_HOST"`
 	RedisPort string `mapstructure:"REDIS_PORT"`
+
+	RedisTimeout time.Duration `mapstructure:"REDIS_TIMEOUT"`
+
+	CounterFlushInterval     time.Duration `mapstructure:"COUNTER_FLUSH_INTERVAL"`
+	CounterReconcileInterval time.Duration `mapstructure:"COUNTER_RECONCILE_INTERVAL"`
+	SchedulerInterval        time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	TrashPurgeInterval       time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
+	TrashRetentionDays       int           `mapstructure:"TRASH_RETENTION_DAYS"`
+
+	UploadDriver  string `mapstructure:"UPLOAD_DRIVER"`
+	UploadDir     string `mapstructure:"UPLOAD_DIR"`
+	UploadBaseURL string `mapstructure:"UPLOAD_BASE_URL"`
+	UploadMaxSize int64  `mapstructure:"UPLOAD_MAX_SIZE"`
+
+	S3Endpoint  string `mapstructure:"S3_ENDPOINT"`
+	S3AccessKey string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey string `mapstructure:"S3_SECRET_KEY"`
+	S3Bucket    string `mapstructure:"S3_BUCKET"`
+	S3UseSSL    bool   `mapstructure:"S3_USE_SSL"`
+	S3PublicURL string `mapstructure:"S3_PUBLIC_URL"`
+
+	ReportHideThreshold int      `mapstructure:"REPORT_HIDE_THRESHOLD"`
+	ModerationWords     []string `mapstructure:"MODERATION_WORDS"`
+
+	LogLevel           string        `mapstructure:"LOG_LEVEL"`
+	SlowQueryThreshold time.Duration `mapstructure:"SLOW_QUERY_THRESHOLD"`
+
+	MetricsAddr string `mapstructure:"METRICS_ADDR"`
+
+	TracingExporter    string  `mapstructure:"TRACING_EXPORTER"`
+	TracingSampleRatio float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+	TracingServiceName string  `mapstructure:"TRACING_SERVICE_NAME"`
+	OTLPEndpoint       string  `mapstructure:"OTLP_ENDPOINT"`
+	OTLPInsecure       bool    `mapstructure:"OTLP_INSECURE"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -35,6 +74,25 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetConfigType("env")
 	viper.SetConfigName("app")
 
+	viper.SetDefault("READ_TIMEOUT", "30s")
+	viper.SetDefault("WRITE_TIMEOUT", "60s")
+	viper.SetDefault("IDLE_TIMEOUT", "120s")
+	viper.SetDefault("SHUTDOWN_TIMEOUT", "30s")
+	viper.SetDefault("COUNTER_FLUSH_INTERVAL", "5s")
+	viper.SetDefault("COUNTER_RECONCILE_INTERVAL", "1h")
+	viper.SetDefault("SCHEDULER_INTERVAL", "15s")
+	viper.SetDefault("TRASH_PURGE_INTERVAL", "1h")
+	viper.SetDefault("TRASH_RETENTION_DAYS", 30)
+	viper.SetDefault("UPLOAD_DRIVER", "local")
+	viper.SetDefault("UPLOAD_DIR", "uploads")
+	viper.SetDefault("UPLOAD_BASE_URL", "/uploads")
+	viper.SetDefault("UPLOAD_MAX_SIZE", 5<<20)
+	viper.SetDefault("REPORT_HIDE_THRESHOLD", 5)
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("SLOW_QUERY_THRESHOLD", "200ms")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("TRACING_SERVICE_NAME", "engineerpro-api")
+
 	viper.AutomaticEnv()
 
 	err = viper.ReadInConfig()
@@ -0,0 +1,18 @@
+package initializers
+
+import (
+	"github.com/trung/backend-engineerpro/logging"
+	"go.uber.org/zap"
+)
+
+// SetupLogger replaces zap's global logger, which every package logs
+// through, with a JSON one at LOG_LEVEL
+func SetupLogger(config *Config) *zap.Logger {
+	logger, err := logging.New(config.LogLevel)
+	if err != nil {
+		logger, _ = logging.New("info")
+		logger.Warn("invalid LOG_LEVEL, logging at info", zap.String("level", config.LogLevel), zap.Error(err))
+	}
+	zap.ReplaceGlobals(logger)
+	return logger
+}
@@ -0,0 +1,27 @@
+package initializers
+
+import (
+	"context"
+
+	"github.com/trung/backend-engineerpro/tracing"
+	"go.uber.org/zap"
+)
+
+// SetupTracing installs the tracer provider picked by TRACING_EXPORTER. The
+// returned function flushes the spans not exported yet.
+func SetupTracing(config *Config) func(context.Context) error {
+	shutdown, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    config.TracingExporter,
+		Endpoint:    config.OTLPEndpoint,
+		Insecure:    config.OTLPInsecure,
+		SampleRatio: config.TracingSampleRatio,
+		ServiceName: config.TracingServiceName,
+	})
+	if err != nil {
+		zap.L().Fatal("could not set up tracing", zap.Error(err))
+	}
+	if config.TracingExporter != "" {
+		zap.L().Info("tracing enabled", zap.String("exporter", config.TracingExporter), zap.Float64("sample_ratio", config.TracingSampleRatio))
+	}
+	return shutdown
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Group runs background jobs until its context is cancelled. Wait blocks
+// until all of them have returned, so shutdown doesn't cut one off midway.
+type Group struct {
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+func NewGroup(ctx context.Context) *Group {
+	return &Group{ctx: ctx}
+}
+
+// Go runs fn in its own goroutine, fn must return once ctx is cancelled.
+func (g *Group) Go(fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+}
+
+// Every runs fn on a ticker in its own goroutine until the group's context is
+// cancelled.
+func (g *Group) Every(name string, interval time.Duration, fn func(context.Context) error) {
+	g.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					zap.L().Error("job failed", zap.String("job", name), zap.Error(err))
+				}
+			}
+		}
+	})
+}
+
+// Wait blocks until every job has returned or ctx is done
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
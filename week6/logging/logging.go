@@ -0,0 +1,132 @@
+// Package logging sets up the zap logger the server writes JSON logs with.
+// Packages log through zap.L(), which main replaces with the configured
+// logger; request scoped code uses FromContext to get the request id along.
+package logging
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New builds a JSON logger writing to stderr at level ("debug", "info",
+// "warn" or "error"). Fields that look like secrets are redacted.
+func New(level string) (*zap.Logger, error) {
+	var zapLevel zapcore.Level
+	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+
+	config := zap.NewProductionConfig()
+	config.Level = zap.NewAtomicLevelAt(zapLevel)
+	config.EncoderConfig.TimeKey = "time"
+	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	// Sampling would drop access logs under load
+	config.Sampling = nil
+
+	return config.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return redactCore{core}
+	}))
+}
+
+type requestIDKey struct{}
+
+// WithRequestID stores the request id in ctx for FromContext
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request id stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// FromContext returns the global logger, tagged with the request id when ctx
+// belongs to a request and the trace id when it carries a sampled span
+func FromContext(ctx context.Context) *zap.Logger {
+	logger := zap.L()
+	if requestID := RequestID(ctx); requestID != "" {
+		logger = logger.With(zap.String("request_id", requestID))
+	}
+	if ctx != nil {
+		if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+			logger = logger.With(zap.String("trace_id", span.TraceID().String()))
+		}
+	}
+	return logger
+}
+
+// Redacted stands in for a secret in log lines
+const Redacted = "[REDACTED]"
+
+var secretKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+
+// IsSecret reports whether a field or parameter named key holds a secret
+func IsSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bcrypt hashes and JWTs are secrets wherever they show up, SQL included
+var secretValues = regexp.MustCompile(`\$2[aby]\$\d\d\$[./A-Za-z0-9]{53}|eyJ[\w-]+\.[\w-]+\.[\w-]+`)
+
+// Redact masks password hashes and tokens inside s
+func Redact(s string) string {
+	return secretValues.ReplaceAllString(s, Redacted)
+}
+
+// redactCore masks fields named like a secret and secret looking values in
+// string fields and messages before they reach the output
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = Redact(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redactedFields := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		switch {
+		case IsSecret(field.Key):
+			redactedFields[i] = zap.String(field.Key, Redacted)
+		case field.Type == zapcore.StringType:
+			redactedFields[i] = zap.String(field.Key, Redact(field.String))
+		case field.Type == zapcore.ErrorType:
+			if err, ok := field.Interface.(error); ok {
+				redactedFields[i] = zap.String(field.Key, Redact(err.Error()))
+				continue
+			}
+			redactedFields[i] = field
+		default:
+			redactedFields[i] = field
+		}
+	}
+	return redactedFields
+}
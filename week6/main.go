@@ -1,69 +1,60 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"os/signal"
+	"syscall"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/app"
 	"github.com/trung/backend-engineerpro/initializers"
-	"github.com/trung/backend-engineerpro/routes"
+	"go.uber.org/zap"
 )
 
-var (
-	server              *gin.Engine
-	AuthController      controllers.AuthController
-	AuthRouteController routes.AuthRouteController
-
-	UserController      controllers.UserController
-	UserRouteController routes.UserRouteController
-
-	PostController      controllers.PostController
-	PostRouteController routes.PostRouteController
-)
-
-func init() {
+func main() {
 	config, err := initializers.LoadConfig(".")
+	// Without a config LOG_LEVEL is empty, which logs at info
+	logger := initializers.SetupLogger(&config)
+	defer logger.Sync()
 	if err != nil {
-		log.Fatal("🚀 Could not load environment variables", err)
+		logger.Fatal("could not load environment variables", zap.Error(err))
 	}
+	shutdownTracing := initializers.SetupTracing(&config)
 
 	initializers.ConnectDB(&config)
 	initializers.ConnectRedis(&config)
+	initializers.ConnectStorage(&config)
 
-	AuthController = controllers.NewAuthController(initializers.DB)
-	AuthRouteController = routes.NewAuthRouteController(AuthController)
-
-	UserController = controllers.NewUserController(initializers.DB)
-	UserRouteController = routes.NewRouteUserController(UserController)
-
-	PostController = controllers.NewPostController(initializers.DB, initializers.RedisClient)
-	PostRouteController = routes.NewRoutePostController(PostController)
-
-	server = gin.Default()
-}
-
-func main() {
-	config, err := initializers.LoadConfig(".")
-	if err != nil {
-		log.Fatal("🚀 Could not load environment variables", err)
+	server := app.New(config, initializers.DB, initializers.RedisClient, initializers.Storage)
+	if err := server.Start(); err != nil {
+		logger.Fatal("could not start the server", zap.Error(err))
 	}
 
-	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowOrigins = []string{"http://localhost:8000", config.ClientOrigin}
-	corsConfig.AllowCredentials = true
-
-	server.Use(cors.New(corsConfig))
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case <-signals.Done():
+		logger.Info("shutting down")
+	case err := <-server.Errors():
+		logger.Error("server failed, shutting down", zap.Error(err))
+	}
+	// A second signal kills the process instead of waiting for the drain
+	stopSignals()
 
-	router := server.Group("/api")
-	router.GET("/healthchecker", func(ctx *gin.Context) {
-		message := "Welcome To Engineer Pro project!"
-		ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": message})
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
 
-	AuthRouteController.AuthRoute(router)
-	UserRouteController.UserRoute(router)
-	PostRouteController.PostRoute(router)
-	log.Fatal(server.Run(":" + config.ServerPort))
+	// Requests and jobs first, they still use everything closed after them
+	if err := server.Stop(ctx); err != nil {
+		logger.Error("could not stop the server cleanly", zap.Error(err))
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("could not flush traces", zap.Error(err))
+	}
+	if err := initializers.CloseRedis(); err != nil {
+		logger.Error("could not close redis", zap.Error(err))
+	}
+	if err := initializers.CloseDB(); err != nil {
+		logger.Error("could not close the database", zap.Error(err))
+	}
+	logger.Info("stopped")
 }
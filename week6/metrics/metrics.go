@@ -0,0 +1,87 @@
+// Package metrics holds the Prometheus collectors the server exports on
+// /metrics. Counters and histograms are package level so any package can
+// record to them; NewRegistry adds the pool stats of a database and Redis
+// client and the Go runtime on top.
+package metrics
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const namespace = "engineerpro"
+
+var (
+	// HTTPRequests counts requests by route template, not by path, so ids
+	// don't blow up the number of series
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// CacheRequests counts cache lookups with result "hit", "miss" or
+	// "error" when Redis failed or its breaker is open
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result.",
+	}, []string{"cache", "result"})
+
+	Signups = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Accounts registered.",
+	})
+
+	// Logins counts password logins with result "success" or "failure"
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Password logins by result.",
+	}, []string{"result"})
+
+	PostsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "Posts created by initial status.",
+	}, []string{"status"})
+)
+
+// Cache results
+const (
+	Hit   = "hit"
+	Miss  = "miss"
+	Error = "error"
+)
+
+// NewRegistry returns a registry with the package collectors, the Go runtime
+// and process stats, and the connection pool stats of db and redisClient.
+// Either client may be nil.
+func NewRegistry(db *gorm.DB, redisClient *redis.Client) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration, CacheRequests, Signups, Logins, PostsCreated,
+	)
+
+	if db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, db.Dialector.Name()))
+		}
+	}
+	if redisClient != nil {
+		registry.MustRegister(newRedisCollector(redisClient))
+	}
+	return registry
+}
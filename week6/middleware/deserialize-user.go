@@ -1,50 +1,70 @@
 package middleware
 
 import (
-	"fmt"
-	"net/http"
+	"context"
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/apperror"
 	"github.com/trung/backend-engineerpro/models"
-	"github.com/trung/backend-engineerpro/utils"
+	"github.com/trung/backend-engineerpro/services"
 )
 
-func DeserializeUser() gin.HandlerFunc {
+// Authenticator turns an access token into its user, it is implemented by
+// *services.AuthService
+type Authenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (models.User, error)
+}
+
+func DeserializeUser(auth Authenticator) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		var access_token string
-		cookieAccessToken, err := ctx.Cookie("access_token")
+		user, err := userFromRequest(ctx, auth)
+		if err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
 
-		authorizationHeader := ctx.Request.Header.Get("Authorization")
-		fields := strings.Fields(authorizationHeader)
+		ctx.Set("currentUser", user)
+		ctx.Next()
+	}
+}
 
-		if len(fields) != 0 && fields[0] == "Bearer" {
-			access_token = fields[1]
-		} else if err == nil {
-			access_token = cookieAccessToken
+// OptionalUser sets currentUser when the request carries a valid token and
+// lets anonymous requests through, for public routes that show more to the owner
+func OptionalUser(auth Authenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if user, err := userFromRequest(ctx, auth); err == nil {
+			ctx.Set("currentUser", user)
 		}
+		ctx.Next()
+	}
+}
 
-		if access_token == "" {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "You are not logged in"})
-			return
-		}
+func userFromRequest(ctx *gin.Context, auth Authenticator) (models.User, error) {
+	var user models.User
+	var access_token string
+	cookieAccessToken, err := ctx.Cookie("access_token")
 
-		config, _ := initializers.LoadConfig(".")
-		sub, err := utils.ValidateToken(access_token, config.AccessTokenPublicKey)
-		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": err.Error()})
-			return
-		}
+	authorizationHeader := ctx.Request.Header.Get("Authorization")
+	fields := strings.Fields(authorizationHeader)
 
-		var user models.User
-		result := initializers.DB.First(&user, "id = ?", fmt.Sprint(sub))
-		if result.Error != nil {
-			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": "the user belonging to this token no logger exists"})
-			return
-		}
+	if len(fields) == 2 && fields[0] == "Bearer" {
+		access_token = fields[1]
+	} else if err == nil {
+		access_token = cookieAccessToken
+	}
 
-		ctx.Set("currentUser", user)
-		ctx.Next()
+	if access_token == "" {
+		return user, apperror.Unauthorized("You are not logged in")
+	}
+
+	user, err = auth.Authenticate(ctx.Request.Context(), access_token)
+	if errors.Is(err, services.ErrUserGone) || errors.Is(err, services.ErrSuspended) {
+		return user, apperror.Forbidden(err.Error())
+	} else if err != nil {
+		return user, apperror.Unauthorized(err.Error())
 	}
+	return user, nil
 }
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/apperror"
+)
+
+// Problem is an RFC 7807 problem details body, with the error code, the
+// request id and the invalid fields as extension members
+type Problem struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail"`
+	Instance  string                `json:"instance"`
+	Code      string                `json:"code"`
+	RequestID string                `json:"request_id"`
+	Errors    []apperror.FieldError `json:"errors,omitempty"`
+}
+
+// ErrorHandler renders the last error a handler reported with ctx.Error as
+// application/problem+json. Errors that aren't an *apperror.Error are
+// internal ones and their text stays out of the response.
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		var appErr *apperror.Error
+		if err := ctx.Errors.Last().Err; !errors.As(err, &appErr) {
+			appErr = apperror.Internal(err)
+		}
+
+		ctx.Header("Content-Type", "application/problem+json")
+		ctx.JSON(appErr.Status, Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(appErr.Status),
+			Status:    appErr.Status,
+			Detail:    appErr.Message,
+			Instance:  ctx.Request.URL.Path,
+			Code:      appErr.Code,
+			RequestID: ctx.GetString("requestID"),
+			Errors:    appErr.Fields,
+		})
+	}
+}
+
+// Recover turns a panic into an internal error, it goes after ErrorHandler
+// so the error is rendered like any other
+func Recover() gin.HandlerFunc {
+	return gin.CustomRecovery(func(ctx *gin.Context, recovered interface{}) {
+		ctx.Error(apperror.Internal(fmt.Errorf("panic: %v", recovered)))
+		ctx.Abort()
+	})
+}
+
+// NoRoute answers requests no route matched
+func NoRoute(ctx *gin.Context) {
+	ctx.Error(apperror.NotFound("No route for " + ctx.Request.Method + " " + ctx.Request.URL.Path))
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/logging"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID tags every request with an id, the caller's own when it sent one,
+// and echoes it in the response so a report can be matched with the logs.
+// The id also goes into the request context, where logging.FromContext and
+// the SQL logs pick it up.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		ctx.Set("requestID", id)
+		ctx.Request = ctx.Request.WithContext(logging.WithRequestID(ctx.Request.Context(), id))
+		ctx.Header(RequestIDHeader, id)
+		ctx.Next()
+	}
+}
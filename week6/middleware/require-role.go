@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/apperror"
+	"github.com/trung/backend-engineerpro/models"
+)
+
+// RequireRole only lets users with the given role through, it goes after DeserializeUser
+func RequireRole(role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.MustGet("currentUser").(models.User).Role != role {
+			ctx.Error(apperror.Forbidden("You are not allowed to do this"))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
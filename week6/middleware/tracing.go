@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for every request, continuing the caller's
+// trace when it sent a traceparent header. The span goes into the request
+// context, so the queries and Redis commands made with it become its
+// children. It goes after RequestID and before the other middleware so their
+// work and the final status are part of the span.
+func Tracing() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		name := "HTTP " + ctx.Request.Method
+		route := ctx.FullPath()
+		if route != "" {
+			name = ctx.Request.Method + " " + route
+		}
+		spanCtx, span := tracing.Tracer().Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(httpconv.ServerRequest("", ctx.Request)...),
+			trace.WithAttributes(attribute.String("request_id", ctx.GetString("requestID"))),
+		)
+		defer span.End()
+		if route != "" {
+			span.SetAttributes(semconv.HTTPRouteKey.String(route))
+		}
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		span.SetStatus(httpconv.ServerStatus(status))
+		if status >= 500 && len(ctx.Errors) > 0 {
+			span.RecordError(ctx.Errors.Last().Err)
+		}
+		if user, ok := ctx.Get("currentUser"); ok {
+			span.SetAttributes(semconv.EnduserIDKey.String(user.(models.User).ID.String()))
+		}
+	}
+}
@@ -1,24 +1,122 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 
 	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/migrations"
 	"github.com/trung/backend-engineerpro/models"
 )
 
-func init() {
+const usage = `Usage: go run migrate/migrate.go <command>
+
+  up            apply all pending migrations (the default)
+  down [N]      roll back the last N migrations, 1 by default
+  status        list the migrations and when they were applied
+  create NAME   add an empty up/down pair to migrations/
+  check         compare the models with the database schema`
+
+func main() {
+	command := "up"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	// Creating files doesn't need a database
+	if command == "create" {
+		if len(os.Args) != 3 {
+			log.Fatal(usage)
+		}
+		paths, err := migrations.Create("migrations", os.Args[2])
+		if err != nil {
+			log.Fatal("Could not create the migration: ", err)
+		}
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		return
+	}
+
 	config, err := initializers.LoadConfig(".")
 	if err != nil {
-		log.Fatal("🚀 Could not load environment variables", err)
+		log.Fatal("Could not load environment variables: ", err)
 	}
-
+	defer initializers.SetupLogger(&config).Sync()
 	initializers.ConnectDB(&config)
+
+	sqlDB, err := initializers.DB.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		log.Fatal("Could not load the migrations: ", err)
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		check()
+		fmt.Println("👍 Migration complete")
+
+	case "down":
+		n := 1
+		if len(os.Args) > 2 {
+			if n, err = strconv.Atoi(os.Args[2]); err != nil || n < 1 {
+				log.Fatal(usage)
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, n)
+		for _, migration := range rolledBack {
+			fmt.Printf("Rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal("Rollback failed: ", err)
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, applied)
+		}
+
+	case "check":
+		check()
+		fmt.Println("👍 The models match the schema")
+
+	default:
+		log.Fatal(usage)
+	}
 }
 
-func main() {
-	initializers.DB.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"")
-	initializers.DB.AutoMigrate(&models.User{}, &models.Post{}, &models.Comment{}, &models.Like{}, &models.UserFollower{})
-	fmt.Println("👍 Migration complete")
+// check exits with the differences when the schema doesn't match the models
+func check() {
+	problems, err := migrations.Check(initializers.DB, models.All...)
+	if err != nil {
+		log.Fatal("Could not check the schema: ", err)
+	}
+	for _, problem := range problems {
+		fmt.Println("✗", problem)
+	}
+	if len(problems) > 0 {
+		log.Fatalf("The schema doesn't match the models, %d problems", len(problems))
+	}
 }
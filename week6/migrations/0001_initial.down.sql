@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS "reports";
+DROP TABLE IF EXISTS "bookmarks";
+DROP TABLE IF EXISTS "collections";
+DROP TABLE IF EXISTS "messages";
+DROP TABLE IF EXISTS "conversation_members";
+DROP TABLE IF EXISTS "conversations";
+DROP TABLE IF EXISTS "user_blocks";
+DROP TABLE IF EXISTS "notification_preferences";
+DROP TABLE IF EXISTS "notification_actors";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "mentions";
+DROP TABLE IF EXISTS "post_tags";
+DROP TABLE IF EXISTS "tags";
+DROP TABLE IF EXISTS "comment_revisions";
+DROP TABLE IF EXISTS "post_revisions";
+DROP TABLE IF EXISTS "comment_reactions";
+DROP TABLE IF EXISTS "reactions";
+DROP TABLE IF EXISTS "comments";
+DROP TABLE IF EXISTS "posts";
+DROP TABLE IF EXISTS "user_followers";
+DROP TABLE IF EXISTS "users";
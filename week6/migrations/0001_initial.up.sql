@@ -0,0 +1,271 @@
+-- Schema as it was last created by AutoMigrate. Everything is IF NOT EXISTS so
+-- databases set up before versioned migrations are adopted as they are.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(255) NOT NULL,
+    "username" varchar(30) NOT NULL DEFAULT '',
+    "email" text NOT NULL,
+    "age" bigint,
+    "password" text NOT NULL,
+    "role" varchar(255) NOT NULL,
+    "provider" text NOT NULL,
+    "profile_image" text NOT NULL,
+    "verified" boolean NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    "updated_at" timestamptz NOT NULL,
+    "banned_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username") WHERE username <> '';
+
+CREATE TABLE IF NOT EXISTS "user_followers" (
+    "follower_id" uuid NOT NULL,
+    "following_id" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("follower_id","following_id")
+);
+
+CREATE TABLE IF NOT EXISTS "posts" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "title" text NOT NULL,
+    "content" text NOT NULL,
+    "image" text NOT NULL,
+    "user_id" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    "updated_at" timestamptz NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'published',
+    "publish_at" timestamptz,
+    "published_at" timestamptz,
+    "edited" boolean NOT NULL DEFAULT false,
+    "edited_at" timestamptz,
+    "deleted_at" timestamptz,
+    "repost_of_id" uuid,
+    "quote_of_id" uuid,
+    "comments_count" bigint NOT NULL DEFAULT 0,
+    "reactions_count" bigint NOT NULL DEFAULT 0,
+    "reposts_count" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_post" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_posts_publish_at" ON "posts" ("publish_at");
+CREATE INDEX IF NOT EXISTS "idx_posts_status" ON "posts" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_posts_user_repost" ON "posts" ("user_id","repost_of_id") WHERE repost_of_id IS NOT NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_posts_title_nonempty" ON "posts" ("title") WHERE title <> '';
+CREATE INDEX IF NOT EXISTS "idx_posts_quote_of_id" ON "posts" ("quote_of_id");
+CREATE INDEX IF NOT EXISTS "idx_posts_repost_of_id" ON "posts" ("repost_of_id");
+CREATE INDEX IF NOT EXISTS "idx_posts_deleted_at" ON "posts" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "comments" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "content" text NOT NULL,
+    "post_id" uuid NOT NULL,
+    "user_id" text NOT NULL,
+    "parent_id" uuid,
+    "depth" bigint NOT NULL DEFAULT 0,
+    "create_at" timestamptz,
+    "updated_at" timestamptz,
+    "edited" boolean NOT NULL DEFAULT false,
+    "edited_at" timestamptz,
+    "hidden_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_posts_comments" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_comments_hidden_at" ON "comments" ("hidden_at");
+CREATE INDEX IF NOT EXISTS "idx_comments_thread" ON "comments" ("post_id","parent_id","create_at");
+
+CREATE TABLE IF NOT EXISTS "reactions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "post_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    "updated_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_posts_reactions" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reactions_post_user" ON "reactions" ("post_id","user_id");
+
+CREATE TABLE IF NOT EXISTS "comment_reactions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "comment_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    "updated_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_comment_reactions_comment_user" ON "comment_reactions" ("comment_id","user_id");
+
+CREATE TABLE IF NOT EXISTS "post_revisions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "post_id" uuid NOT NULL,
+    "version" bigint NOT NULL,
+    "title" text NOT NULL,
+    "content" text NOT NULL,
+    "image" text NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_revisions_version" ON "post_revisions" ("post_id","version");
+
+CREATE TABLE IF NOT EXISTS "comment_revisions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "comment_id" uuid NOT NULL,
+    "version" bigint NOT NULL,
+    "content" text NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_comment_revisions_version" ON "comment_revisions" ("comment_id","version");
+
+CREATE TABLE IF NOT EXISTS "tags" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(50) NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tags_name" ON "tags" ("name");
+
+CREATE TABLE IF NOT EXISTS "post_tags" (
+    "post_id" uuid,
+    "tag_id" uuid,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("post_id","tag_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_tags_created_at" ON "post_tags" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_post_tags_tag_id" ON "post_tags" ("tag_id");
+
+CREATE TABLE IF NOT EXISTS "mentions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "mentioned_user_id" uuid NOT NULL,
+    "author_id" uuid NOT NULL,
+    "post_id" uuid NOT NULL,
+    "comment_id" uuid,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_mentions_comment_id" ON "mentions" ("comment_id");
+CREATE INDEX IF NOT EXISTS "idx_mentions_post_id" ON "mentions" ("post_id");
+CREATE INDEX IF NOT EXISTS "idx_mentions_user_created" ON "mentions" ("mentioned_user_id","created_at");
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "group_key" varchar(200) NOT NULL,
+    "actor_id" uuid NOT NULL,
+    "actor_count" bigint NOT NULL DEFAULT 1,
+    "post_id" uuid,
+    "comment_id" uuid,
+    "read_at" timestamptz,
+    "created_at" timestamptz NOT NULL,
+    "updated_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notifications_comment_id" ON "notifications" ("comment_id");
+CREATE INDEX IF NOT EXISTS "idx_notifications_post_id" ON "notifications" ("post_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_notifications_unread_group" ON "notifications" ("group_key") WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_updated" ON "notifications" ("user_id","updated_at");
+
+CREATE TABLE IF NOT EXISTS "notification_actors" (
+    "notification_id" uuid,
+    "actor_id" uuid,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("notification_id","actor_id")
+);
+
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+    "user_id" uuid,
+    "type" varchar(20),
+    "enabled" boolean NOT NULL,
+    PRIMARY KEY ("user_id","type")
+);
+
+CREATE TABLE IF NOT EXISTS "user_blocks" (
+    "blocker_id" uuid NOT NULL,
+    "blocked_id" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("blocker_id","blocked_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_blocks_blocked_id" ON "user_blocks" ("blocked_id");
+
+CREATE TABLE IF NOT EXISTS "conversations" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "is_group" boolean NOT NULL DEFAULT false,
+    "title" varchar(100) NOT NULL DEFAULT '',
+    "created_by_id" uuid NOT NULL,
+    "direct_key" varchar(80),
+    "created_at" timestamptz NOT NULL,
+    "updated_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_conversations_updated_at" ON "conversations" ("updated_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_conversations_direct_key" ON "conversations" ("direct_key");
+
+CREATE TABLE IF NOT EXISTS "conversation_members" (
+    "conversation_id" uuid,
+    "user_id" uuid,
+    "joined_at" timestamptz NOT NULL,
+    "last_read_at" timestamptz,
+    PRIMARY KEY ("conversation_id","user_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_conversation_members_user_id" ON "conversation_members" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "messages" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "conversation_id" uuid NOT NULL,
+    "sender_id" uuid NOT NULL,
+    "content" text NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_messages_conversation_created" ON "messages" ("conversation_id","created_at");
+
+CREATE TABLE IF NOT EXISTS "collections" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "created_at" timestamptz NOT NULL,
+    "updated_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_collections_user_name" ON "collections" ("user_id","name");
+
+CREATE TABLE IF NOT EXISTS "bookmarks" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "post_id" uuid NOT NULL,
+    "collection_id" uuid,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_collection_id" ON "bookmarks" ("collection_id");
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_post_id" ON "bookmarks" ("post_id");
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_user_created" ON "bookmarks" ("user_id","created_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_bookmarks_user_post" ON "bookmarks" ("user_id","post_id");
+
+CREATE TABLE IF NOT EXISTS "reports" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "reporter_id" uuid,
+    "target_type" varchar(20) NOT NULL,
+    "target_id" uuid NOT NULL,
+    "author_id" uuid NOT NULL,
+    "reason" varchar(20) NOT NULL,
+    "details" varchar(1000) NOT NULL DEFAULT '',
+    "status" varchar(20) NOT NULL DEFAULT 'open',
+    "created_at" timestamptz NOT NULL,
+    "action" varchar(20) NOT NULL DEFAULT '',
+    "resolved_by_id" uuid,
+    "resolved_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_reports_target" ON "reports" ("target_type","target_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reports_reporter_target" ON "reports" ("reporter_id","target_type","target_id");
+CREATE INDEX IF NOT EXISTS "idx_reports_status" ON "reports" ("status");
+CREATE INDEX IF NOT EXISTS "idx_reports_author_id" ON "reports" ("author_id");
@@ -0,0 +1 @@
+-- Data fixes only, there is nothing to undo.
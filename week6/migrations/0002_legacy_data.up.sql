@@ -0,0 +1,24 @@
+-- One-off fixes for databases created before the baseline, a no-op on new ones.
+
+-- Reposts have no title, the title only has to be unique when there is one
+DROP INDEX IF EXISTS "idx_posts_title";
+
+-- Likes became "like" reactions
+DO $$
+BEGIN
+    IF to_regclass('likes') IS NOT NULL THEN
+        INSERT INTO reactions (id, post_id, user_id, type, created_at, updated_at)
+            SELECT id, post_id, user_id, 'like', COALESCE(create_at, now()), COALESCE(updated_at, now()) FROM likes
+            ON CONFLICT DO NOTHING;
+        DROP TABLE likes;
+    END IF;
+END $$;
+
+-- Posts from before the publishing workflow count as published when they were created
+UPDATE posts SET published_at = created_at WHERE status = 'published' AND published_at IS NULL;
+
+-- Backfill the denormalized counters for posts created before they existed
+UPDATE posts SET
+    comments_count = (SELECT count(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL),
+    reactions_count = (SELECT count(*) FROM reactions WHERE reactions.post_id = posts.id),
+    reposts_count = (SELECT count(*) FROM posts shares WHERE (shares.repost_of_id = posts.id OR shares.quote_of_id = posts.id) AND shares.deleted_at IS NULL);
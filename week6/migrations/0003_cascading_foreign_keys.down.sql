@@ -0,0 +1,14 @@
+ALTER TABLE bookmarks DROP CONSTRAINT IF EXISTS fk_collections_bookmarks;
+ALTER TABLE bookmarks DROP CONSTRAINT IF EXISTS fk_posts_bookmarks;
+ALTER TABLE post_tags DROP CONSTRAINT IF EXISTS fk_tags_posts;
+ALTER TABLE post_tags DROP CONSTRAINT IF EXISTS fk_posts_tags;
+ALTER TABLE comment_revisions DROP CONSTRAINT IF EXISTS fk_comments_revisions;
+ALTER TABLE post_revisions DROP CONSTRAINT IF EXISTS fk_posts_revisions;
+ALTER TABLE comment_reactions DROP CONSTRAINT IF EXISTS fk_comments_reactions;
+
+ALTER TABLE reactions DROP CONSTRAINT IF EXISTS fk_posts_reactions;
+ALTER TABLE reactions ADD CONSTRAINT fk_posts_reactions FOREIGN KEY (post_id) REFERENCES posts (id) NOT VALID;
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS fk_posts_comments;
+ALTER TABLE comments ADD CONSTRAINT fk_posts_comments FOREIGN KEY (post_id) REFERENCES posts (id) NOT VALID;
+
+ALTER TABLE comments ALTER COLUMN user_id TYPE text USING user_id::text;
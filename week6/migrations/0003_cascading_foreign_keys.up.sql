@@ -0,0 +1,19 @@
+-- comments.user_id was created as text because the model had no column type
+ALTER TABLE comments ALTER COLUMN user_id TYPE uuid USING user_id::uuid;
+
+-- Rows hanging off a post or comment go away with it, so hard deleting a
+-- repost no longer fails on the comments or reactions it got. NOT VALID only
+-- checks new rows, orphans left by older versions don't block the migration.
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS fk_posts_comments;
+ALTER TABLE comments ADD CONSTRAINT fk_posts_comments FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE reactions DROP CONSTRAINT IF EXISTS fk_posts_reactions;
+ALTER TABLE reactions ADD CONSTRAINT fk_posts_reactions FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE NOT VALID;
+
+ALTER TABLE comment_reactions ADD CONSTRAINT fk_comments_reactions FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE post_revisions ADD CONSTRAINT fk_posts_revisions FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE comment_revisions ADD CONSTRAINT fk_comments_revisions FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE post_tags ADD CONSTRAINT fk_posts_tags FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE post_tags ADD CONSTRAINT fk_tags_posts FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE NOT VALID;
+ALTER TABLE bookmarks ADD CONSTRAINT fk_posts_bookmarks FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE NOT VALID;
+-- Deleting a collection leaves its bookmarks unsorted
+ALTER TABLE bookmarks ADD CONSTRAINT fk_collections_bookmarks FOREIGN KEY (collection_id) REFERENCES collections (id) ON DELETE SET NULL NOT VALID;
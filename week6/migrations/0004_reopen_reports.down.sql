@@ -0,0 +1,7 @@
+-- Keeps the newest report of every reporter and target so the full unique index can be built
+DELETE FROM reports older USING reports newer
+WHERE older.reporter_id = newer.reporter_id AND older.target_type = newer.target_type AND older.target_id = newer.target_id
+AND (older.created_at, older.id) < (newer.created_at, newer.id);
+
+DROP INDEX IF EXISTS "idx_reports_reporter_target";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reports_reporter_target" ON "reports" ("reporter_id","target_type","target_id");
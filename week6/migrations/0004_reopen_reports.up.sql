@@ -0,0 +1,4 @@
+-- A user can report a target again once their earlier report was dismissed
+-- or actioned, only one of their reports per target can be open at a time
+DROP INDEX IF EXISTS "idx_reports_reporter_target";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reports_reporter_target" ON "reports" ("reporter_id","target_type","target_id") WHERE status = 'open';
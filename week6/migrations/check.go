@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Postgres reports column types by their internal names
+var typeAliases = map[string]string{
+	"boolean":          "bool",
+	"smallint":         "int2",
+	"integer":          "int4",
+	"bigint":           "int8",
+	"smallserial":      "int2",
+	"serial":           "int4",
+	"bigserial":        "int8",
+	"decimal":          "numeric",
+	"real":             "float4",
+	"double precision": "float8",
+}
+
+// Check compares the tables, columns with their types and the indexes the
+// models expect with the database and describes every difference, e.g. a
+// model field without a migration adding its column. No problems means the
+// two match.
+func Check(db *gorm.DB, models ...interface{}) ([]string, error) {
+	var problems []string
+	migrator := db.Migrator()
+
+	// Parsing a relationship gives the foreign key on the other side the type
+	// of the key it references, so every model is parsed before comparing
+	for _, model := range models {
+		if err := (&gorm.Statement{DB: db}).Parse(model); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		table := stmt.Schema.Table
+		if !migrator.HasTable(model) {
+			problems = append(problems, fmt.Sprintf("table %s is missing", table))
+			continue
+		}
+
+		columnTypes, err := migrator.ColumnTypes(model)
+		if err != nil {
+			return nil, err
+		}
+		columns := make(map[string]gorm.ColumnType, len(columnTypes))
+		for _, column := range columnTypes {
+			columns[column.Name()] = column
+		}
+		expected := map[string]bool{}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || field.IgnoreMigration {
+				continue
+			}
+			expected[field.DBName] = true
+			column, ok := columns[field.DBName]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("column %s.%s is missing", table, field.DBName))
+				continue
+			}
+			if want, got := modelType(db.Dialector, field), databaseType(column); want != got {
+				problems = append(problems, fmt.Sprintf("column %s.%s is %s, the model wants %s", table, field.DBName, got, want))
+			}
+		}
+		for _, column := range columnTypes {
+			if !expected[column.Name()] {
+				problems = append(problems, fmt.Sprintf("column %s.%s is not in the model", table, column.Name()))
+			}
+		}
+
+		for name := range stmt.Schema.ParseIndexes() {
+			if !migrator.HasIndex(model, name) {
+				problems = append(problems, fmt.Sprintf("index %s on %s is missing", name, table))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// modelType is the column type the field asks for, spelled the way the
+// database reports it. Only varchar keeps its length.
+func modelType(dialector gorm.Dialector, field *schema.Field) string {
+	dataType := strings.ToLower(strings.TrimSpace(dialector.DataTypeOf(field)))
+	size := ""
+	if i := strings.IndexByte(dataType, '('); i >= 0 {
+		dataType, size = strings.TrimSpace(dataType[:i]), dataType[i:]
+	}
+	if alias, ok := typeAliases[dataType]; ok {
+		dataType = alias
+	}
+	if dataType == "varchar" {
+		return dataType + size
+	}
+	return dataType
+}
+
+func databaseType(column gorm.ColumnType) string {
+	dataType := strings.ToLower(column.DatabaseTypeName())
+	if length, ok := column.Length(); ok && length > 0 && dataType == "varchar" {
+		return fmt.Sprintf("%s(%d)", dataType, length)
+	}
+	return dataType
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/trung/backend-engineerpro/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+func TestColumnTypes(t *testing.T) {
+	dialector := postgres.Dialector{Config: &postgres.Config{}}
+	cache := &sync.Map{}
+	tables := map[string]*schema.Schema{}
+	for _, model := range models.All {
+		s, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables[s.Table] = s
+	}
+
+	tests := []struct {
+		table, column string
+		dataType      string
+		length        int64
+		match         bool
+	}{
+		// comments.user_id was text until 0003 changed it to uuid
+		{"comments", "user_id", "text", 0, false},
+		{"comments", "user_id", "uuid", 0, true},
+		{"posts", "user_id", "uuid", 0, true},
+		{"posts", "status", "varchar", 20, true},
+		{"posts", "status", "varchar", 10, false},
+		{"posts", "status", "text", 0, false},
+		{"posts", "comments_count", "int8", 0, true},
+		{"posts", "comments_count", "int4", 0, false},
+		{"posts", "created_at", "timestamptz", 0, true},
+	}
+	for _, tt := range tests {
+		field := tables[tt.table].LookUpField(tt.column)
+		if field == nil {
+			t.Fatalf("%s.%s is not in the models", tt.table, tt.column)
+		}
+		column := migrator.ColumnType{
+			NameValue:     sql.NullString{String: tt.column, Valid: true},
+			DataTypeValue: sql.NullString{String: tt.dataType, Valid: true},
+			LengthValue:   sql.NullInt64{Int64: tt.length, Valid: true},
+		}
+		want, got := modelType(dialector, field), databaseType(column)
+		if (want == got) != tt.match {
+			t.Errorf("%s.%s: model wants %s, database has %s, match should be %v", tt.table, tt.column, want, got, tt.match)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Collection is a named folder of bookmarks, bookmarks outside any collection are "unsorted"
+type Collection struct {
+	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_collections_user_name" json:"user_id"`
+	Name      string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_collections_user_name" json:"name"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+
+	BookmarksCount int64 `gorm:"-" json:"bookmarks_count"`
+}
+
+type Bookmark struct {
+	ID           string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bookmarks_user_post;index:idx_bookmarks_user_created,priority:1" json:"user_id"`
+	PostID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bookmarks_user_post;index" json:"post_id"`
+	CollectionID *string   `gorm:"type:uuid;index" json:"collection_id,omitempty"`
+	CreatedAt    time.Time `gorm:"not null;index:idx_bookmarks_user_created,priority:2" json:"created_at"`
+}
+
+type CollectionInput struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type BookmarkInput struct {
+	CollectionID string `json:"collection_id"`
+}
+
+type MoveBookmarksInput struct {
+	PostIDs      []uuid.UUID `json:"post_ids" binding:"required,min=1,max=100"`
+	CollectionID string      `json:"collection_id"`
+}
+
+type BookmarkResponse struct {
+	Post         Post      `json:"post"`
+	CollectionID *string   `json:"collection_id,omitempty"`
+	SavedAt      time.Time `json:"saved_at"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxGroupMembers caps a group conversation, the creator included
+const MaxGroupMembers = 50
+
+type Conversation struct {
+	ID          string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	IsGroup     bool      `gorm:"not null;default:false" json:"is_group"`
+	Title       string    `gorm:"type:varchar(100);not null;default:''" json:"title,omitempty"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null" json:"created_by_id"`
+	// DirectKey is "<smaller user id>:<bigger user id>" for 1:1 conversations so
+	// a pair of users can only ever have one
+	DirectKey *string   `gorm:"type:varchar(80);uniqueIndex" json:"-"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	// UpdatedAt moves with every new message, conversations are listed by it
+	UpdatedAt time.Time `gorm:"not null;index" json:"updated_at"`
+}
+
+type ConversationMember struct {
+	ConversationID string     `gorm:"type:uuid;primaryKey" json:"conversation_id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	JoinedAt       time.Time  `gorm:"not null" json:"joined_at"`
+	LastReadAt     *time.Time `json:"last_read_at,omitempty"`
+}
+
+type Message struct {
+	ID             string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ConversationID string    `gorm:"type:uuid;not null;index:idx_messages_conversation_created,priority:1" json:"conversation_id"`
+	SenderID       uuid.UUID `gorm:"type:uuid;not null" json:"sender_id"`
+	Content        string    `gorm:"not null" json:"content"`
+	CreatedAt      time.Time `gorm:"not null;index:idx_messages_conversation_created,priority:2" json:"created_at"`
+}
+
+type CreateConversationInput struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1"`
+	Title   string      `json:"title" binding:"max=100"`
+}
+
+type SendMessageInput struct {
+	Content string `json:"content" binding:"required,max=5000"`
+}
+
+type MarkReadInput struct {
+	MessageID string `json:"message_id"`
+}
+
+type ConversationMemberResponse struct {
+	UserSummary
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+}
+
+type ConversationResponse struct {
+	Conversation
+	Members     []ConversationMemberResponse `json:"members"`
+	LastMessage *Message                     `json:"last_message,omitempty"`
+	UnreadCount int64                        `json:"unread_count"`
+}
+
+// ReadReceipt is pushed to the other members when someone reads a conversation
+type ReadReceipt struct {
+	ConversationID string    `json:"conversation_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	LastReadAt     time.Time `json:"last_read_at"`
+}
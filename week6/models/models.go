@@ -0,0 +1,8 @@
+package models
+
+// All lists the model of every table, used to check them against the migrations
+var All = []interface{}{
+	&User{}, &Post{}, &Comment{}, &Reaction{}, &CommentReaction{}, &UserFollower{}, &PostRevision{}, &CommentRevision{},
+	&Tag{}, &PostTag{}, &Mention{}, &Notification{}, &NotificationActor{}, &NotificationPreference{}, &UserBlock{},
+	&Conversation{}, &ConversationMember{}, &Message{}, &Collection{}, &Bookmark{}, &Report{},
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationFollow  = "follow"
+	NotificationLike    = "like"
+	NotificationComment = "comment"
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+	NotificationRepost  = "repost"
+	NotificationQuote   = "quote"
+)
+
+var NotificationTypes = []string{NotificationFollow, NotificationLike, NotificationComment, NotificationReply, NotificationMention, NotificationRepost, NotificationQuote}
+
+// Notification is one line in a user's inbox. While it is unread, new events
+// with the same GroupKey are folded into it ("A and 5 others liked your post").
+type Notification struct {
+	ID         string     `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_notifications_user_updated,priority:1" json:"user_id"`
+	Type       string     `gorm:"type:varchar(20);not null" json:"type"`
+	GroupKey   string     `gorm:"type:varchar(200);not null;uniqueIndex:idx_notifications_unread_group,where:read_at IS NULL" json:"-"`
+	ActorID    uuid.UUID  `gorm:"type:uuid;not null" json:"actor_id"`
+	ActorCount int        `gorm:"not null;default:1" json:"actor_count"`
+	PostID     *uuid.UUID `gorm:"type:uuid;index" json:"post_id,omitempty"`
+	CommentID  *string    `gorm:"type:uuid;index" json:"comment_id,omitempty"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"not null;index:idx_notifications_user_updated,priority:2" json:"updated_at"`
+}
+
+// NotificationActor keeps the distinct users folded into a notification
+type NotificationActor struct {
+	NotificationID string    `gorm:"type:uuid;primaryKey" json:"notification_id"`
+	ActorID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"actor_id"`
+	CreatedAt      time.Time `gorm:"not null" json:"created_at"`
+}
+
+// NotificationPreference only exists for types a user changed, everything is on by default
+type NotificationPreference struct {
+	UserID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Type    string    `gorm:"type:varchar(20);primaryKey" json:"type"`
+	Enabled bool      `gorm:"not null" json:"enabled"`
+}
+
+type NotificationPreferencesInput struct {
+	Preferences map[string]bool `json:"preferences" binding:"required"`
+}
+
+type NotificationResponse struct {
+	Notification
+	Actor   UserSummary `json:"actor"`
+	Message string      `json:"message"`
+}
@@ -4,27 +4,76 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Post lifecycle, only published posts show up in listings and feeds
+const (
+	PostDraft     = "draft"
+	PostScheduled = "scheduled"
+	PostPublished = "published"
+	PostArchived  = "archived"
+	// Taken down by moderation, see the moderation package
+	PostHidden = "hidden"
 )
 
 type Post struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
-	Title     string    `gorm:"uniqueIndex;not null" json:"title,omitempty"`
-	Content   string    `gorm:"not null" json:"content,omitempty"`
-	Image     string    `gorm:"not null" json:"image,omitempty"`
-	UserID    uuid.UUID `gorm:"not null" json:"user_id,omitempty"`
-	Comments  []Comment `gorm:"foreignKey:PostID" json:"comments,omitempty"`
-	Likes     []Like    `gorm:"foreignKey:PostID" json:"likes,omitempty"`
-	CreatedAt time.Time `gorm:"not null" json:"created_at,omitempty"`
-	UpdatedAt time.Time `gorm:"not null" json:"updated_at,omitempty"`
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
+	Title     string     `gorm:"not null;uniqueIndex:idx_posts_title_nonempty,where:title <> ''" json:"title,omitempty"`
+	Content   string     `gorm:"not null" json:"content,omitempty"`
+	Image     string     `gorm:"not null" json:"image,omitempty"`
+	UserID    uuid.UUID  `gorm:"not null;uniqueIndex:idx_posts_user_repost,where:repost_of_id IS NOT NULL AND deleted_at IS NULL" json:"user_id,omitempty"`
+	Comments  []Comment  `gorm:"foreignKey:PostID" json:"comments,omitempty"`
+	Reactions []Reaction `gorm:"foreignKey:PostID" json:"-"`
+	CreatedAt time.Time  `gorm:"not null" json:"created_at,omitempty"`
+	UpdatedAt time.Time  `gorm:"not null" json:"updated_at,omitempty"`
+
+	Status      string     `gorm:"type:varchar(20);not null;default:'published';index" json:"status"`
+	PublishAt   *time.Time `gorm:"index" json:"publish_at,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+
+	Edited   bool       `gorm:"not null;default:false" json:"edited"`
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// A repost shares another post as is, a quote adds its own content on top.
+	// Both point at the original, never at another repost.
+	RepostOfID *uuid.UUID `gorm:"type:uuid;index;uniqueIndex:idx_posts_user_repost,where:repost_of_id IS NOT NULL AND deleted_at IS NULL" json:"repost_of_id,omitempty"`
+	QuoteOfID  *uuid.UUID `gorm:"type:uuid;index" json:"quote_of_id,omitempty"`
+	// The shared post, OriginalUnavailable is set instead once it was deleted
+	Original            *Post `gorm:"-" json:"original,omitempty"`
+	OriginalUnavailable bool  `gorm:"-" json:"original_unavailable,omitempty"`
+
+	// Denormalized counters, kept up to date by the counters package
+	CommentsCount  int64 `gorm:"not null;default:0" json:"comments_count"`
+	ReactionsCount int64 `gorm:"not null;default:0" json:"reactions_count"`
+	RepostsCount   int64 `gorm:"not null;default:0" json:"reposts_count"`
+
+	// Per-type breakdown, filled in by GET /api/posts and GET /api/posts/:postId
+	ReactionCounts map[string]int64 `gorm:"-" json:"reaction_counts,omitempty"`
+	// How many users saved the post, only shown to its author
+	BookmarksCount *int64 `gorm:"-" json:"bookmarks_count,omitempty"`
 }
 
 type CreatePostRequest struct {
-	Title     string    `json:"title"  binding:"required"`
-	Content   string    `json:"content" binding:"required"`
-	Image     string    `json:"image" binding:"required"`
-	UserID    string    `json:"user_id,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	Title     string     `json:"title"  binding:"required"`
+	Content   string     `json:"content" binding:"required"`
+	Image     string     `json:"image" binding:"required"`
+	UserID    string     `json:"user_id,omitempty"`
+	Status    string     `json:"status,omitempty" binding:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+}
+
+type QuotePostInput struct {
+	Content string `json:"content" binding:"required"`
+	Image   string `json:"image"`
+}
+
+type SchedulePostInput struct {
+	PublishAt time.Time `json:"publish_at" binding:"required"`
 }
 
 type UpdatePost struct {
@@ -36,27 +85,45 @@ type UpdatePost struct {
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
 }
 
+// MaxCommentDepth is how deep replies can be nested, top-level comments have depth 0
+const MaxCommentDepth = 3
+
 type Comment struct {
 	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
 	Content   string    `gorm:"not null" json:"content,omitempty"`
-	PostID    uuid.UUID `gorm:"not null" json:"post_id,omitempty"`
-	UserID    uuid.UUID `gorm:"not null" json:"user_id,omitempty"`
-	CreateAt  time.Time `json:"created_at,omitempty"`
+	PostID    uuid.UUID `gorm:"not null;index:idx_comments_thread,priority:1" json:"post_id,omitempty"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id,omitempty"`
+	ParentID  *string   `gorm:"type:uuid;index:idx_comments_thread,priority:2" json:"parent_id,omitempty"`
+	Depth     int       `gorm:"not null;default:0" json:"depth"`
+	CreateAt  time.Time `gorm:"index:idx_comments_thread,priority:3" json:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
+
+	Edited   bool       `gorm:"not null;default:false" json:"edited"`
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+
+	// Hidden comments are left out of the thread until a moderator dismisses the reports
+	HiddenAt *time.Time `gorm:"index" json:"hidden_at,omitempty"`
+
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 type CreateComment struct {
-	Content string `gorm:"not null" json:"content,omitempty"`
+	Content  string `gorm:"not null" json:"content,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+type CommentResponse struct {
+	Comment
+	ReplyCount     int64            `json:"reply_count"`
+	ReactionCounts map[string]int64 `json:"reaction_counts"`
 }
 
 type UpdateComment struct {
 	Content string `gorm:"not null" json:"content,omitempty"`
 }
 
-type Like struct {
-	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
-	PostID    uuid.UUID `gorm:"not null" json:"post_id,omitempty"`
-	UserID    uuid.UUID `gorm:"not null" json:"user_id,omitempty"`
-	CreateAt  time.Time `json:"created_at,omitempty"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
+// TrashResponse is what the current user deleted and can still restore
+type TrashResponse struct {
+	Posts    []Post    `json:"posts"`
+	Comments []Comment `json:"comments"`
 }
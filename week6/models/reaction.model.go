@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionHaha  = "haha"
+	ReactionSad   = "sad"
+	ReactionAngry = "angry"
+)
+
+// A user has at most one reaction per post, changing it replaces the type
+type Reaction struct {
+	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reactions_post_user" json:"post_id,omitempty"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reactions_post_user" json:"user_id,omitempty"`
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at,omitempty"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at,omitempty"`
+}
+
+type CommentReaction struct {
+	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
+	CommentID string    `gorm:"type:uuid;not null;uniqueIndex:idx_comment_reactions_comment_user" json:"comment_id,omitempty"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_comment_reactions_comment_user" json:"user_id,omitempty"`
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at,omitempty"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at,omitempty"`
+}
+
+type ReactionInput struct {
+	Type string `json:"type" binding:"required,oneof=like love haha sad angry"`
+}
+
+type ReactorResponse struct {
+	ID           string    `json:"-"`
+	UserID       uuid.UUID `json:"user_id"`
+	Name         string    `json:"name"`
+	ProfileImage string    `json:"profile_image,omitempty"`
+	Type         string    `json:"type"`
+	ReactedAt    time.Time `json:"reacted_at"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What can be reported
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+)
+
+// A report stays open until a moderator acts on its target
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed"
+	ReportActioned  = "actioned"
+)
+
+// Moderator actions on a reported target
+const (
+	ModerationDismiss = "dismiss"
+	ModerationHide    = "hide"
+	ModerationBan     = "ban"
+)
+
+// ReportFiltered is only used by the word filter, users pick one of ReportReasons
+const ReportFiltered = "filtered"
+
+var ReportReasons = []string{"spam", "harassment", "hate", "violence", "nudity", "misinformation", "other"}
+
+type Report struct {
+	ID string `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	// Nil for reports raised by the word filter. A user has one open report per
+	// target at most, after it is resolved they can report the target again.
+	ReporterID *uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_reports_reporter_target,priority:1,where:status = 'open'" json:"reporter_id,omitempty"`
+	TargetType string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_reports_reporter_target,priority:2,where:status = 'open';index:idx_reports_target,priority:1" json:"target_type"`
+	TargetID   string     `gorm:"type:uuid;not null;uniqueIndex:idx_reports_reporter_target,priority:3,where:status = 'open';index:idx_reports_target,priority:2" json:"target_id"`
+	// Whoever wrote the reported post or comment, the user itself for user reports
+	AuthorID  uuid.UUID `gorm:"type:uuid;not null;index" json:"author_id"`
+	Reason    string    `gorm:"type:varchar(20);not null" json:"reason"`
+	Details   string    `gorm:"type:varchar(1000);not null;default:''" json:"details,omitempty"`
+	Status    string    `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+
+	Action       string     `gorm:"type:varchar(20);not null;default:''" json:"action,omitempty"`
+	ResolvedByID *uuid.UUID `gorm:"type:uuid" json:"resolved_by_id,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+}
+
+type ReportInput struct {
+	Reason  string `json:"reason" binding:"required,oneof=spam harassment hate violence nudity misinformation other"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+type ModerationActionInput struct {
+	Action string `json:"action" binding:"required,oneof=dismiss hide ban"`
+}
+
+// ModerationItem is one reported target in the moderation queue
+type ModerationItem struct {
+	TargetType      string           `json:"target_type"`
+	TargetID        string           `json:"target_id"`
+	AuthorID        uuid.UUID        `json:"author_id"`
+	ReportsCount    int64            `json:"reports_count"`
+	ReportersCount  int64            `json:"reporters_count"`
+	Reasons         map[string]int64 `json:"reasons" gorm:"-"`
+	Hidden          bool             `json:"hidden" gorm:"-"`
+	FirstReportedAt time.Time        `json:"first_reported_at"`
+	LastReportedAt  time.Time        `json:"last_reported_at"`
+}
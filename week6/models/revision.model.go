@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/utils"
+)
+
+// PostRevision is a snapshot of a post taken right before it was edited.
+// Version 1 is the original text, the current text lives on the post itself.
+type PostRevision struct {
+	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_post_revisions_version" json:"post_id"`
+	Version   int       `gorm:"not null;uniqueIndex:idx_post_revisions_version" json:"version"`
+	Title     string    `gorm:"not null" json:"title"`
+	Content   string    `gorm:"not null" json:"content"`
+	Image     string    `gorm:"not null" json:"image"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+
+	// Changes from this revision to the next one (or to the current post)
+	Diff []utils.DiffLine `gorm:"-" json:"diff,omitempty"`
+}
+
+type CommentRevision struct {
+	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
+	CommentID string    `gorm:"type:uuid;not null;uniqueIndex:idx_comment_revisions_version" json:"comment_id"`
+	Version   int       `gorm:"not null;uniqueIndex:idx_comment_revisions_version" json:"version"`
+	Content   string    `gorm:"not null" json:"content"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+
+	Diff []utils.DiffLine `gorm:"-" json:"diff,omitempty"`
+}
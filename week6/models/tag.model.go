@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Tag struct {
+	ID        string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
+	Name      string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"name"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+type PostTag struct {
+	PostID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"post_id"`
+	TagID     string    `gorm:"type:uuid;primaryKey;index" json:"tag_id"`
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// Mention records that a post or a comment (CommentID set) mentioned a user
+type Mention struct {
+	ID              string    `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id,omitempty"`
+	MentionedUserID uuid.UUID `gorm:"type:uuid;not null;index:idx_mentions_user_created,priority:1" json:"mentioned_user_id"`
+	AuthorID        uuid.UUID `gorm:"type:uuid;not null" json:"author_id"`
+	PostID          uuid.UUID `gorm:"type:uuid;not null;index" json:"post_id"`
+	CommentID       *string   `gorm:"type:uuid;index" json:"comment_id,omitempty"`
+	CreatedAt       time.Time `gorm:"not null;index:idx_mentions_user_created,priority:2" json:"created_at"`
+}
+
+type TrendingTag struct {
+	Name  string `json:"name"`
+	Score int64  `json:"score"`
+}
@@ -6,9 +6,15 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
 	Name         string    `gorm:"type:varchar(255);not null"`
+	Username     string    `gorm:"type:varchar(30);not null;default:'';uniqueIndex:idx_users_username,where:username <> ''"`
 	Email        string    `gorm:"uniqueIndex;not null"`
 	Age          int64     `json:"age"`
 	Password     string    `gorm:"not null"`
@@ -20,6 +26,9 @@ type User struct {
 	CreatedAt    time.Time `gorm:"not null"`
 	UpdatedAt    time.Time `gorm:"not null"`
 
+	// Set by a moderator, banned users can't log in or use their tokens
+	BannedAt *time.Time
+
 	// Many-to-Many Relationship for Followers/Following
 	Followers []*User `gorm:"many2many:user_followers;joinForeignKey:FollowerID;JoinReferences:FollowingID"` // other users following current user
 	Following []*User `gorm:"many2many:user_followers;joinForeignKey:FollowingID;JoinReferences:FollowerID"` // current user following other users
@@ -27,6 +36,7 @@ type User struct {
 
 type SignUpInput struct {
 	Name            string `json:"name" binding:"required"`
+	Username        string `json:"username"`
 	Age             int64  `json:"age" binding:"required"`
 	Email           string `json:"email" binding:"required"`
 	Password        string `json:"password" binding:"required,min=8"`
@@ -39,9 +49,19 @@ type SignInInput struct {
 	Password string `json:"password"  binding:"required"`
 }
 
+// UpdateProfileInput changes the fields that are not empty
+type UpdateProfileInput struct {
+	Name         string `json:"name"`
+	Username     string `json:"username"`
+	Age          int64  `json:"age"`
+	Email        string `json:"email"`
+	ProfileImage string `json:"profile_image"`
+}
+
 type UserResponse struct {
 	ID           uuid.UUID `json:"id,omitempty"`
 	Name         string    `json:"name,omitempty"`
+	Username     string    `json:"username,omitempty"`
 	Email        string    `json:"email,omitempty"`
 	Age          int64     `json:"age"`
 	Role         string    `json:"role,omitempty"`
@@ -51,9 +71,24 @@ type UserResponse struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// UserSummary is how other users appear inside notifications, conversations etc.
+type UserSummary struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Username     string    `json:"username,omitempty"`
+	ProfileImage string    `json:"profile_image,omitempty"`
+}
+
 // Follow struct for the many-to-many relationship
 type UserFollower struct {
 	FollowerID  uuid.UUID `gorm:"type:uuid;not null;primaryKey"`
 	FollowingID uuid.UUID `gorm:"type:uuid;not null;primaryKey"`
 	CreatedAt   time.Time `gorm:"not null"`
 }
+
+// UserBlock hides the two users from each other: no follows, no messages
+type UserBlock struct {
+	BlockerID uuid.UUID `gorm:"type:uuid;not null;primaryKey" json:"blocker_id"`
+	BlockedID uuid.UUID `gorm:"type:uuid;not null;primaryKey;index" json:"blocked_id"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
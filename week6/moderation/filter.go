@@ -0,0 +1,45 @@
+package moderation
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Filter looks at new content and tells whether a moderator should have a
+// look at it. The reason ends up in the details of the report.
+type Filter interface {
+	Check(text string) (flagged bool, reason string)
+}
+
+// WordFilter flags text containing any of a list of words, ignoring case
+type WordFilter struct {
+	words map[string]struct{}
+}
+
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{words: make(map[string]struct{}, len(words))}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			f.words[word] = struct{}{}
+		}
+	}
+	return f
+}
+
+func (f *WordFilter) Check(text string) (bool, string) {
+	if len(f.words) == 0 {
+		return false, ""
+	}
+
+	// Whole words only, "class" must not match "ass"
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, token := range tokens {
+		if _, ok := f.words[token]; ok {
+			return true, fmt.Sprintf("contains %q", token)
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,173 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/logging"
+	"github.com/trung/backend-engineerpro/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlreadyReported = errors.New("you already reported this")
+	ErrNoOpenReports   = errors.New("there are no open reports for that target")
+	ErrCannotHideUser  = errors.New("a user can't be hidden, ban them instead")
+	ErrCannotBanAdmin  = errors.New("admins can't be banned")
+)
+
+type Service struct {
+	DB     *gorm.DB
+	Filter Filter
+	// Posts and comments reported by this many different users are hidden
+	// until a moderator looks at them, 0 turns automatic hiding off
+	HideThreshold int
+}
+
+func NewService(DB *gorm.DB, Filter Filter, HideThreshold int) *Service {
+	return &Service{DB: DB, Filter: Filter, HideThreshold: HideThreshold}
+}
+
+// Report files a user's report and hides the target once enough users reported it
+func (s *Service) Report(ctx context.Context, report *models.Report) (hidden bool, err error) {
+	db := s.DB.WithContext(ctx)
+	report.Status = models.ReportOpen
+	report.CreatedAt = time.Now()
+	if err := db.Create(report).Error; err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return false, ErrAlreadyReported
+		}
+		return false, err
+	}
+	if s.HideThreshold <= 0 || report.TargetType == models.ReportTargetUser {
+		return false, nil
+	}
+
+	// The unique index on reporter and target makes every report here a different user
+	var reporters int64
+	err = db.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ? AND reporter_id IS NOT NULL", report.TargetType, report.TargetID, models.ReportOpen).
+		Count(&reporters).Error
+	if err != nil || reporters < int64(s.HideThreshold) {
+		return false, err
+	}
+	return hide(db, report.TargetType, report.TargetID)
+}
+
+// Screen runs the filter over new content and files a report for the
+// moderators when it matches. Failures are only logged, the content was
+// already saved and the author should not see an error for it.
+func (s *Service) Screen(ctx context.Context, targetType, targetID string, authorID uuid.UUID, text string) {
+	if s.Filter == nil {
+		return
+	}
+	flagged, reason := s.Filter.Check(text)
+	if !flagged {
+		return
+	}
+
+	db := s.DB.WithContext(ctx)
+	// One open filter report per target is enough, edits don't pile up more
+	var existing int64
+	err := db.Model(&models.Report{}).
+		Where("reporter_id IS NULL AND target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportOpen).
+		Count(&existing).Error
+	if err == nil && existing == 0 {
+		err = db.Create(&models.Report{
+			TargetType: targetType,
+			TargetID:   targetID,
+			AuthorID:   authorID,
+			Reason:     models.ReportFiltered,
+			Details:    reason,
+			Status:     models.ReportOpen,
+			CreatedAt:  time.Now(),
+		}).Error
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("moderation: screen failed", zap.String("target_type", targetType), zap.String("target_id", targetID), zap.Error(err))
+	}
+}
+
+// Resolve closes every open report on the target with the moderator's action.
+// Dismissing brings back content that was hidden automatically, banning also
+// hides the reported content.
+func (s *Service) Resolve(ctx context.Context, targetType, targetID, action string, moderatorID uuid.UUID) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var open []models.Report
+		err := tx.Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportOpen).Find(&open).Error
+		if err != nil {
+			return err
+		}
+		if len(open) == 0 {
+			return ErrNoOpenReports
+		}
+
+		status := models.ReportActioned
+		switch action {
+		case models.ModerationDismiss:
+			status = models.ReportDismissed
+			err = unhide(tx, targetType, targetID)
+		case models.ModerationHide:
+			if targetType == models.ReportTargetUser {
+				return ErrCannotHideUser
+			}
+			_, err = hide(tx, targetType, targetID)
+		case models.ModerationBan:
+			if err = ban(tx, open[0].AuthorID); err == nil {
+				_, err = hide(tx, targetType, targetID)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportOpen).
+			Updates(map[string]interface{}{"status": status, "action": action, "resolved_by_id": moderatorID, "resolved_at": time.Now()}).Error
+	})
+}
+
+// Unban lets the user log in again, false if they were not banned
+func (s *Service) Unban(ctx context.Context, userID uuid.UUID) (bool, error) {
+	result := s.DB.WithContext(ctx).Model(&models.User{}).Where("id = ? AND banned_at IS NOT NULL", userID).UpdateColumn("banned_at", nil)
+	return result.RowsAffected > 0, result.Error
+}
+
+func ban(tx *gorm.DB, userID uuid.UUID) error {
+	var user models.User
+	if err := tx.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+	if user.Role == models.RoleAdmin {
+		return ErrCannotBanAdmin
+	}
+	return tx.Model(&models.User{}).Where("id = ? AND banned_at IS NULL", userID).UpdateColumn("banned_at", time.Now()).Error
+}
+
+// hide takes a post or comment out of every listing, users can't be hidden
+func hide(tx *gorm.DB, targetType, targetID string) (bool, error) {
+	var result *gorm.DB
+	switch targetType {
+	case models.ReportTargetPost:
+		result = tx.Model(&models.Post{}).Where("id = ? AND status = ?", targetID, models.PostPublished).UpdateColumn("status", models.PostHidden)
+	case models.ReportTargetComment:
+		result = tx.Model(&models.Comment{}).Where("id = ? AND hidden_at IS NULL", targetID).UpdateColumn("hidden_at", time.Now())
+	default:
+		return false, nil
+	}
+	return result.RowsAffected > 0, result.Error
+}
+
+func unhide(tx *gorm.DB, targetType, targetID string) error {
+	switch targetType {
+	case models.ReportTargetPost:
+		return tx.Model(&models.Post{}).Where("id = ? AND status = ?", targetID, models.PostHidden).UpdateColumn("status", models.PostPublished).Error
+	case models.ReportTargetComment:
+		return tx.Model(&models.Comment{}).Where("id = ?", targetID).UpdateColumn("hidden_at", nil).Error
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/logging"
+	"github.com/trung/backend-engineerpro/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Event is something that happened to Recipient because of Actor
+type Event struct {
+	Type        string
+	RecipientID uuid.UUID
+	ActorID     uuid.UUID
+	PostID      *uuid.UUID
+	CommentID   *string
+}
+
+// groupKey decides which events fold into the same notification
+func (e Event) groupKey() string {
+	switch e.Type {
+	case models.NotificationFollow:
+		return fmt.Sprintf("%s:%s", e.Type, e.RecipientID)
+	case models.NotificationReply, models.NotificationMention:
+		if e.CommentID != nil {
+			return fmt.Sprintf("%s:%s:%s", e.Type, e.RecipientID, *e.CommentID)
+		}
+	}
+	return fmt.Sprintf("%s:%s:%s", e.Type, e.RecipientID, e.PostID)
+}
+
+type Service struct {
+	DB *gorm.DB
+
+	// OnNotify is called with every notification created or bumped
+	OnNotify func(ctx context.Context, notification models.Notification)
+}
+
+func NewService(DB *gorm.DB) *Service {
+	return &Service{DB: DB}
+}
+
+// Notify records the event unless the user would be notifying themselves or
+// turned the type off. Failures are only logged, a missing notification must
+// never fail the action that caused it.
+func (s *Service) Notify(ctx context.Context, event Event) {
+	if err := s.notify(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("notifications: notify failed", zap.String("type", event.Type), zap.Stringer("recipient_id", event.RecipientID), zap.Error(err))
+	}
+}
+
+// NotifyAll sends the same event to several recipients, e.g. everyone mentioned in a post
+func (s *Service) NotifyAll(ctx context.Context, event Event, recipients []uuid.UUID) {
+	for _, recipient := range recipients {
+		event.RecipientID = recipient
+		s.Notify(ctx, event)
+	}
+}
+
+func (s *Service) notify(ctx context.Context, event Event) error {
+	if event.RecipientID == event.ActorID {
+		return nil
+	}
+
+	enabled, err := s.Enabled(ctx, event.RecipientID, event.Type)
+	if err != nil || !enabled {
+		return err
+	}
+
+	// Nothing reaches a user from someone they blocked
+	var blocks int64
+	err = s.DB.WithContext(ctx).Model(&models.UserBlock{}).Where("blocker_id = ? AND blocked_id = ?", event.RecipientID, event.ActorID).Count(&blocks).Error
+	if err != nil || blocks > 0 {
+		return err
+	}
+
+	var notification models.Notification
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		notification = models.Notification{
+			UserID:     event.RecipientID,
+			Type:       event.Type,
+			GroupKey:   event.groupKey(),
+			ActorID:    event.ActorID,
+			ActorCount: 1,
+			PostID:     event.PostID,
+			CommentID:  event.CommentID,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		// Only one unread notification per group can exist, a second event
+		// lands on the existing row and bumps it to the top of the inbox
+		err := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "group_key"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "read_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"actor_id", "updated_at"}),
+		}).Create(&notification).Error
+		if err != nil {
+			return err
+		}
+		// Not every driver hands back the id of the row a conflict updated, look it up
+		group := notification.GroupKey
+		notification = models.Notification{}
+		if err := tx.First(&notification, "group_key = ? AND read_at IS NULL", group).Error; err != nil {
+			return err
+		}
+
+		actor := models.NotificationActor{NotificationID: notification.ID, ActorID: event.ActorID, CreatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&actor).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&models.Notification{}).Where("id = ?", notification.ID).
+			Update("actor_count", tx.Model(&models.NotificationActor{}).Select("count(*)").Where("notification_id = ?", notification.ID)).Error
+		if err != nil {
+			return err
+		}
+		return tx.First(&notification, "id = ?", notification.ID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.OnNotify != nil {
+		s.OnNotify(ctx, notification)
+	}
+	return nil
+}
+
+// Enabled reports whether the user wants notifications of that type
+func (s *Service) Enabled(ctx context.Context, userID uuid.UUID, notificationType string) (bool, error) {
+	var preferences []models.NotificationPreference
+	err := s.DB.WithContext(ctx).Where("user_id = ? AND type = ?", userID, notificationType).Limit(1).Find(&preferences).Error
+	if err != nil || len(preferences) == 0 {
+		return true, err
+	}
+	return preferences[0].Enabled, nil
+}
+
+// Preferences returns the setting of every notification type for the user
+func (s *Service) Preferences(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	var stored []models.NotificationPreference
+	if err := s.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[string]bool, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		preferences[notificationType] = true
+	}
+	for _, preference := range stored {
+		preferences[preference.Type] = preference.Enabled
+	}
+	return preferences, nil
+}
+
+func (s *Service) SetPreferences(ctx context.Context, userID uuid.UUID, preferences map[string]bool) error {
+	rows := make([]models.NotificationPreference, 0, len(preferences))
+	for notificationType, enabled := range preferences {
+		rows = append(rows, models.NotificationPreference{UserID: userID, Type: notificationType, Enabled: enabled})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&rows).Error
+}
+
+// Message renders the inbox line, e.g. "Alice and 5 others liked your post"
+func Message(notificationType, actorName string, actorCount int) string {
+	subject := actorName
+	switch {
+	case actorCount == 2:
+		subject += " and 1 other"
+	case actorCount > 2:
+		subject += fmt.Sprintf(" and %d others", actorCount-1)
+	}
+
+	switch notificationType {
+	case models.NotificationFollow:
+		return subject + " followed you"
+	case models.NotificationLike:
+		return subject + " liked your post"
+	case models.NotificationComment:
+		return subject + " commented on your post"
+	case models.NotificationReply:
+		return subject + " replied to your comment"
+	case models.NotificationMention:
+		return subject + " mentioned you"
+	case models.NotificationRepost:
+		return subject + " reposted your post"
+	case models.NotificationQuote:
+		return subject + " quoted your post"
+	}
+	return subject
+}
@@ -0,0 +1,202 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/trung/backend-engineerpro/models"
+)
+
+type auth int
+
+const (
+	authNone auth = iota
+	authRequired
+	// The route is public but shows more to a logged in user
+	authOptional
+)
+
+// route documents one route. path is the gin path including /api, handler
+// becomes the operationId, request is a value of the bound body type and
+// response the success body.
+type route struct {
+	method   string
+	path     string
+	handler  string
+	tag      string
+	summary  string
+	auth     auth
+	query    []Parameter
+	request  interface{}
+	status   int
+	response *Schema
+}
+
+// apiRoutes lists the routes of AuthRoute, UserRoute and PostRoute, keep it
+// in step with routes/*.go
+func apiRoutes(g *generator) []route {
+	var (
+		post    = g.data(models.Post{})
+		comment = g.data(models.Comment{})
+		user    = g.object(reflect.TypeOf(struct {
+			User models.UserResponse `json:"user"`
+		}{}))
+		posts    = g.list(models.Post{}, false)
+		reactors = g.list(models.ReactorResponse{}, true)
+		comments = g.list(models.CommentResponse{}, true)
+
+		paged        = []Parameter{cursorParam, limitParam}
+		numbered     = []Parameter{pageParam, limitParam}
+		reactorQuery = []Parameter{
+			{Name: "type", In: "query", Description: "Only reactions of this type", Schema: &Schema{Type: "string", Enum: []string{models.ReactionLike, models.ReactionLove, models.ReactionHaha, models.ReactionSad, models.ReactionAngry}}},
+			cursorParam, limitParam,
+		}
+	)
+
+	return []route{
+		{method: http.MethodPost, path: "/api/auth/register", handler: "SignUpUser", tag: "Auth", summary: "Register a new user",
+			request: models.SignUpInput{}, status: http.StatusCreated, response: g.data(user)},
+		{method: http.MethodPost, path: "/api/auth/login", handler: "SignInUser", tag: "Auth", summary: "Log in, sets the access_token, refresh_token and logged_in cookies",
+			request: models.SignInInput{}, status: http.StatusOK, response: tokenBody},
+		{method: http.MethodGet, path: "/api/auth/refresh", handler: "RefreshAccessToken", tag: "Auth", summary: "Trade the refresh_token cookie for a new access token",
+			status: http.StatusOK, response: tokenBody},
+		{method: http.MethodGet, path: "/api/auth/logout", handler: "LogoutUser", tag: "Auth", summary: "Log out by clearing the auth cookies", auth: authRequired,
+			status: http.StatusOK, response: statusBody},
+
+		{method: http.MethodGet, path: "/api/users/profile", handler: "UserProfile", tag: "Users", summary: "Get the current user", auth: authRequired,
+			status: http.StatusOK, response: g.data(user)},
+		{method: http.MethodGet, path: "/api/users/profile/:userID", handler: "UserProfileByID", tag: "Users", summary: "Get a user's profile", auth: authRequired,
+			status: http.StatusOK, response: g.data(user)},
+		{method: http.MethodPut, path: "/api/users/profile", handler: "UpdateUserProfile", tag: "Users", summary: "Update the non-empty fields of the current user", auth: authRequired,
+			request: models.UpdateProfileInput{}, status: http.StatusOK, response: g.data(user)},
+		{method: http.MethodPost, path: "/api/users/follow/:userID", handler: "FollowUser", tag: "Users", summary: "Follow a user", auth: authRequired,
+			status: http.StatusOK, response: messageBody},
+		{method: http.MethodDelete, path: "/api/users/unfollow/:userID", handler: "UnfollowerUser", tag: "Users", summary: "Stop following a user", auth: authRequired,
+			status: http.StatusOK, response: messageBody},
+		{method: http.MethodGet, path: "/api/users/newsfeeds", handler: "GetNewsFeed", tag: "Users", summary: "Posts of the users the current user follows, newest first", auth: authRequired,
+			query: numbered, status: http.StatusOK, response: g.data(g.object(reflect.TypeOf(struct {
+				Newfeeds []models.Post `json:"newfeeds"`
+			}{})))},
+		{method: http.MethodGet, path: "/api/users/mentions", handler: "FindMentions", tag: "Users", summary: "Where the current user was @mentioned, newest first", auth: authRequired,
+			query: paged, status: http.StatusOK, response: g.list(models.Mention{}, true)},
+		{method: http.MethodGet, path: "/api/users/blocks", handler: "FindBlockedUsers", tag: "Users", summary: "Users the current user blocked", auth: authRequired,
+			status: http.StatusOK, response: g.list(models.UserSummary{}, false)},
+		{method: http.MethodPost, path: "/api/users/block/:userID", handler: "BlockUser", tag: "Users", summary: "Block a user, removing any follow between the two", auth: authRequired,
+			status: http.StatusOK, response: messageBody},
+		{method: http.MethodDelete, path: "/api/users/unblock/:userID", handler: "UnblockUser", tag: "Users", summary: "Unblock a user", auth: authRequired,
+			status: http.StatusOK, response: messageBody},
+
+		{method: http.MethodPost, path: "/api/posts", handler: "CreatePost", tag: "Posts", summary: "Create a post, published right away unless status says otherwise", auth: authRequired,
+			request: models.CreatePostRequest{}, status: http.StatusCreated, response: post},
+		{method: http.MethodGet, path: "/api/posts", handler: "FindPosts", tag: "Posts", summary: "List published posts",
+			query: numbered, status: http.StatusOK, response: posts},
+		{method: http.MethodGet, path: "/api/posts/drafts", handler: "FindDrafts", tag: "Posts", summary: "The current user's drafts and scheduled posts", auth: authRequired,
+			status: http.StatusOK, response: posts},
+		{method: http.MethodGet, path: "/api/posts/trash", handler: "FindTrash", tag: "Posts", summary: "Posts and comments the current user deleted and can restore", auth: authRequired,
+			status: http.StatusOK, response: g.data(models.TrashResponse{})},
+		{method: http.MethodGet, path: "/api/posts/archive", handler: "FindArchived", tag: "Posts", summary: "The current user's archived posts", auth: authRequired,
+			status: http.StatusOK, response: posts},
+		{method: http.MethodPut, path: "/api/posts/:postId", handler: "UpdatePost", tag: "Posts", summary: "Edit a post, the previous text is kept as a revision", auth: authRequired,
+			request: models.UpdatePost{}, status: http.StatusOK, response: post},
+		{method: http.MethodGet, path: "/api/posts/:postId", handler: "FindPostById", tag: "Posts", summary: "Get a post, its author also sees drafts and the bookmark count", auth: authOptional,
+			status: http.StatusOK, response: post},
+		{method: http.MethodDelete, path: "/api/posts/:postId", handler: "DeletePost", tag: "Posts", summary: "Move a post to the trash", auth: authRequired,
+			status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/api/posts/:postId/restore", handler: "RestorePost", tag: "Posts", summary: "Restore a post from the trash", auth: authRequired,
+			status: http.StatusOK, response: post},
+		{method: http.MethodPost, path: "/api/posts/:postId/schedule", handler: "SchedulePost", tag: "Posts", summary: "Schedule a draft to be published later", auth: authRequired,
+			request: models.SchedulePostInput{}, status: http.StatusOK, response: post},
+		{method: http.MethodDelete, path: "/api/posts/:postId/schedule", handler: "UnschedulePost", tag: "Posts", summary: "Turn a scheduled post back into a draft", auth: authRequired,
+			status: http.StatusOK, response: post},
+		{method: http.MethodPost, path: "/api/posts/:postId/publish", handler: "PublishPost", tag: "Posts", summary: "Publish a draft or scheduled post now", auth: authRequired,
+			status: http.StatusOK, response: post},
+		{method: http.MethodPost, path: "/api/posts/:postId/archive", handler: "ArchivePost", tag: "Posts", summary: "Hide a published post from everyone but its author", auth: authRequired,
+			status: http.StatusOK, response: post},
+		{method: http.MethodDelete, path: "/api/posts/:postId/archive", handler: "UnarchivePost", tag: "Posts", summary: "Publish an archived post again", auth: authRequired,
+			status: http.StatusOK, response: post},
+		{method: http.MethodGet, path: "/api/posts/:postId/revisions", handler: "FindPostRevisions", tag: "Posts", summary: "Earlier versions of a post with a diff to the next one",
+			status: http.StatusOK, response: g.list(models.PostRevision{}, false)},
+		{method: http.MethodPost, path: "/api/posts/:postId/revisions/:revisionId/restore", handler: "RestorePostRevision", tag: "Posts", summary: "Bring back the text of an earlier version", auth: authRequired,
+			status: http.StatusOK, response: post},
+		{method: http.MethodPost, path: "/api/posts/:postId/repost", handler: "Repost", tag: "Posts", summary: "Share a post with your followers", auth: authRequired,
+			status: http.StatusCreated, response: post},
+		{method: http.MethodDelete, path: "/api/posts/:postId/repost", handler: "Unrepost", tag: "Posts", summary: "Take a repost back", auth: authRequired,
+			status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/api/posts/:postId/quote", handler: "QuotePost", tag: "Posts", summary: "Share a post with a comment on top", auth: authRequired,
+			request: models.QuotePostInput{}, status: http.StatusCreated, response: post},
+		{method: http.MethodPost, path: "/api/posts/:postId/like", handler: "ToggleLike", tag: "Posts", summary: "Like a post, or remove the current reaction (older clients)", auth: authRequired,
+			status: http.StatusOK, response: messageBody},
+		{method: http.MethodGet, path: "/api/posts/:postId/reactions", handler: "FindPostReactions", tag: "Posts", summary: "Who reacted to a post, newest first",
+			query: reactorQuery, status: http.StatusOK, response: reactors},
+		{method: http.MethodPut, path: "/api/posts/:postId/reactions", handler: "SetPostReaction", tag: "Posts", summary: "React to a post or change the reaction", auth: authRequired,
+			request: models.ReactionInput{}, status: http.StatusOK, response: g.data(models.Reaction{})},
+		{method: http.MethodDelete, path: "/api/posts/:postId/reactions", handler: "RemovePostReaction", tag: "Posts", summary: "Remove the reaction to a post", auth: authRequired,
+			status: http.StatusNoContent},
+
+		{method: http.MethodGet, path: "/api/posts/:postId/comments", handler: "FindComments", tag: "Comments", summary: "Top-level comments of a post, oldest first",
+			query: paged, status: http.StatusOK, response: comments},
+		{method: http.MethodPost, path: "/api/posts/:postId/comments", handler: "AddComment", tag: "Comments", summary: "Comment on a post, or reply to a comment with parent_id", auth: authRequired,
+			request: models.CreateComment{}, status: http.StatusCreated, response: comment},
+		{method: http.MethodGet, path: "/api/posts/:postId/comments/:commentId/replies", handler: "FindReplies", tag: "Comments", summary: "Direct replies of a comment, oldest first",
+			query: paged, status: http.StatusOK, response: comments},
+		{method: http.MethodPut, path: "/api/posts/:postId/comments/:commentId", handler: "UpdateComment", tag: "Comments", summary: "Edit a comment, the previous text is kept as a revision", auth: authRequired,
+			request: models.UpdateComment{}, status: http.StatusOK, response: comment},
+		{method: http.MethodDelete, path: "/api/posts/:postId/comments/:commentId", handler: "DeleteComment", tag: "Comments", summary: "Move a comment and its replies to the trash", auth: authRequired,
+			status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/api/posts/:postId/comments/:commentId/restore", handler: "RestoreComment", tag: "Comments", summary: "Restore a comment and its replies from the trash", auth: authRequired,
+			status: http.StatusOK, response: comment},
+		{method: http.MethodGet, path: "/api/posts/:postId/comments/:commentId/reactions", handler: "FindCommentReactions", tag: "Comments", summary: "Who reacted to a comment, newest first",
+			query: reactorQuery, status: http.StatusOK, response: reactors},
+		{method: http.MethodPut, path: "/api/posts/:postId/comments/:commentId/reactions", handler: "SetCommentReaction", tag: "Comments", summary: "React to a comment or change the reaction", auth: authRequired,
+			request: models.ReactionInput{}, status: http.StatusOK, response: g.data(models.CommentReaction{})},
+		{method: http.MethodDelete, path: "/api/posts/:postId/comments/:commentId/reactions", handler: "RemoveCommentReaction", tag: "Comments", summary: "Remove the reaction to a comment", auth: authRequired,
+			status: http.StatusNoContent},
+		{method: http.MethodGet, path: "/api/posts/:postId/comments/:commentId/revisions", handler: "FindCommentRevisions", tag: "Comments", summary: "Earlier versions of a comment with a diff to the next one",
+			status: http.StatusOK, response: g.list(models.CommentRevision{}, false)},
+		{method: http.MethodPost, path: "/api/posts/:postId/comments/:commentId/revisions/:revisionId/restore", handler: "RestoreCommentRevision", tag: "Comments", summary: "Bring back the text of an earlier version", auth: authRequired,
+			status: http.StatusOK, response: comment},
+	}
+}
+
+var (
+	cursorParam = Parameter{Name: "cursor", In: "query", Description: "next_cursor of the previous page", Schema: &Schema{Type: "string"}}
+	limitParam  = Parameter{Name: "limit", In: "query", Description: "Page size, at most 100", Schema: &Schema{Type: "integer", Format: "int32"}}
+	pageParam   = Parameter{Name: "page", In: "query", Description: "Page number, starting at 1", Schema: &Schema{Type: "integer", Format: "int32"}}
+
+	statusBody = &Schema{Type: "object", Properties: map[string]*Schema{
+		"status": {Type: "string", Enum: []string{"success"}},
+	}}
+	messageBody = &Schema{Type: "object", Properties: map[string]*Schema{
+		"status":  {Type: "string", Enum: []string{"success"}},
+		"message": {Type: "string"},
+	}}
+	tokenBody = &Schema{Type: "object", Properties: map[string]*Schema{
+		"status":       {Type: "string", Enum: []string{"success"}},
+		"access_token": {Type: "string"},
+	}}
+)
+
+// data wraps v, a value or an already built schema, in {"status", "data"}
+func (g *generator) data(v interface{}) *Schema {
+	data, ok := v.(*Schema)
+	if !ok {
+		data = g.schema(reflect.TypeOf(v))
+	}
+	return &Schema{Type: "object", Properties: map[string]*Schema{
+		"status": {Type: "string", Enum: []string{"success"}},
+		"data":   data,
+	}}
+}
+
+// list is the body of listings of item, cursor paginated ones add next_cursor
+func (g *generator) list(item interface{}, paged bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{
+		"status":  {Type: "string", Enum: []string{"success"}},
+		"results": {Type: "integer", Format: "int32"},
+		"data":    {Type: "array", Items: g.schema(reflect.TypeOf(item))},
+	}}
+	if paged {
+		s.Properties["next_cursor"] = &Schema{Type: "string", Description: "Empty on the last page"}
+	}
+	return s
+}
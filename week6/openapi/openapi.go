@@ -0,0 +1,208 @@
+// Package openapi describes the auth, user and post routes as an OpenAPI 3
+// document. Request and response schemas are derived from the models by
+// reflection, so they follow the structs; the routes are listed by hand in
+// api.go and a test checks the list against the registered routes.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/trung/backend-engineerpro/middleware"
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Tags       []Tag                `json:"tags"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+type Operation struct {
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	document     *Document
+	documentOnce sync.Once
+)
+
+// New returns the document, it is built once and must not be modified
+func New() *Document {
+	documentOnce.Do(func() {
+		document = build()
+	})
+	return document
+}
+
+func build() *Document {
+	g := newGenerator()
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "Engineer Pro API",
+			Version: "1.0.0",
+			Description: "Errors are application/problem+json bodies (RFC 7807) with a stable `code` and the `request_id`. " +
+				"Authenticated routes take the access token as a Bearer token or in the access_token cookie.",
+		},
+		Tags: []Tag{
+			{Name: "Auth", Description: "Registration, login and tokens"},
+			{Name: "Users", Description: "Profiles, follows, blocks, news feed and mentions"},
+			{Name: "Posts", Description: "Posts, their lifecycle, revisions, reposts and reactions"},
+			{Name: "Comments", Description: "Comment threads of a post"},
+		},
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "access_token"},
+			},
+		},
+	}
+	problem := g.schema(reflect.TypeOf(middleware.Problem{}))
+
+	for _, r := range apiRoutes(g) {
+		path, params := openAPIPath(r.path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		operation := &Operation{
+			Tags:        []string{r.tag},
+			Summary:     r.summary,
+			OperationID: r.handler,
+			Parameters:  append(params, r.query...),
+			Responses: map[string]Response{
+				"default": {Description: "Error", Content: map[string]MediaType{"application/problem+json": {Schema: problem}}},
+			},
+		}
+		switch r.auth {
+		case authRequired:
+			operation.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+		case authOptional:
+			operation.Security = []map[string][]string{{}, {"bearerAuth": {}}, {"cookieAuth": {}}}
+		}
+		if r.request != nil {
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: g.schema(reflect.TypeOf(r.request))}},
+			}
+		}
+		success := Response{Description: http.StatusText(r.status)}
+		if r.response != nil {
+			success.Content = map[string]MediaType{"application/json": {Schema: r.response}}
+		}
+		operation.Responses[strconv.Itoa(r.status)] = success
+
+		switch r.method {
+		case http.MethodGet:
+			item.Get = operation
+		case http.MethodPut:
+			item.Put = operation
+		case http.MethodPost:
+			item.Post = operation
+		case http.MethodDelete:
+			item.Delete = operation
+		}
+	}
+
+	doc.Components.Schemas = g.schemas
+	return doc
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPath turns a gin path into an OpenAPI one plus its path parameters,
+// every id in this API is a uuid
+func openAPIPath(path string) (string, []Parameter) {
+	var params []Parameter
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		params = append(params, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string", Format: "uuid"},
+		})
+	}
+	return pathParam.ReplaceAllString(path, "{$1}"), params
+}
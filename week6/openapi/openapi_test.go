@@ -0,0 +1,99 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/openapi"
+	"github.com/trung/backend-engineerpro/routes"
+)
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// TestEveryRouteIsDocumented registers the auth, user and post routes on a
+// bare engine and compares them with the document, both ways
+func TestEveryRouteIsDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	api := engine.Group("/api")
+	authRoutes := routes.NewAuthRouteController(controllers.AuthController{}, nil)
+	authRoutes.AuthRoute(api)
+	userRoutes := routes.NewRouteUserController(controllers.UserController{}, nil)
+	userRoutes.UserRoute(api)
+	postRoutes := routes.NewRoutePostController(controllers.PostController{}, nil)
+	postRoutes.PostRoute(api)
+
+	documented := map[string]bool{}
+	for path, item := range openapi.New().Paths {
+		for method, operation := range map[string]*openapi.Operation{
+			http.MethodGet: item.Get, http.MethodPut: item.Put, http.MethodPost: item.Post, http.MethodDelete: item.Delete,
+		} {
+			if operation != nil {
+				documented[method+" "+path] = true
+			}
+		}
+	}
+
+	registered := map[string]bool{}
+	for _, r := range engine.Routes() {
+		path := pathParam.ReplaceAllString(r.Path, "{$1}")
+		key := r.Method + " " + path
+		registered[key] = true
+		if !documented[key] {
+			t.Errorf("%s %s is registered but not documented in openapi/api.go", r.Method, r.Path)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("%s is documented but no longer registered", key)
+		}
+	}
+}
+
+func TestDocumentIsValid(t *testing.T) {
+	doc := openapi.New()
+
+	operationIDs := map[string]bool{}
+	for path, item := range doc.Paths {
+		for _, operation := range []*openapi.Operation{item.Get, item.Put, item.Post, item.Delete} {
+			if operation == nil {
+				continue
+			}
+			if operationIDs[operation.OperationID] {
+				t.Errorf("operationId %s is used twice", operation.OperationID)
+			}
+			operationIDs[operation.OperationID] = true
+
+			for _, param := range operation.Parameters {
+				if param.In == "path" && !strings.Contains(path, "{"+param.Name+"}") {
+					t.Errorf("%s: path parameter %s is not in the path", path, param.Name)
+				}
+			}
+		}
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every $ref has to point at a schema that exists
+	var refs []string
+	for _, part := range strings.Split(string(raw), `"$ref":"#/components/schemas/`)[1:] {
+		refs = append(refs, part[:strings.Index(part, `"`)])
+	}
+	for _, ref := range refs {
+		if _, ok := doc.Components.Schemas[ref]; !ok {
+			t.Errorf("$ref to unknown schema %s", ref)
+		}
+	}
+
+	post := doc.Components.Schemas["CreatePostRequest"]
+	if post == nil || strings.Join(post.Required, ",") != "title,content,image" {
+		t.Errorf("CreatePostRequest schema: %+v", post)
+	}
+}
@@ -0,0 +1,40 @@
+package realtime
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+)
+
+// Event types sent to clients
+const (
+	EventFeedPost     = "feed.post"
+	EventNotification = "notification"
+	EventPostCounters = "post.counters"
+	EventMessage      = "message"
+	EventMessageRead  = "message.read"
+)
+
+type counterChange struct {
+	PostID uuid.UUID `json:"post_id"`
+	Field  string    `json:"field"`
+	Delta  int64     `json:"delta"`
+}
+
+// FeedPost tells the followers a post just landed in their feed, it fits Feed.OnFanOut
+func (h *Hub) FeedPost(ctx context.Context, post models.Post, followerIDs []uuid.UUID) {
+	for _, followerID := range followerIDs {
+		h.PublishUser(ctx, followerID, EventFeedPost, post)
+	}
+}
+
+// Notification fits notifications.Service.OnNotify
+func (h *Hub) Notification(ctx context.Context, notification models.Notification) {
+	h.PublishUser(ctx, notification.UserID, EventNotification, notification)
+}
+
+// CounterChanged fits counters.Store.OnChange
+func (h *Hub) CounterChanged(ctx context.Context, postID uuid.UUID, field string, delta int64) {
+	h.PublishPost(ctx, postID, EventPostCounters, counterChange{PostID: postID, Field: field, Delta: delta})
+}
@@ -0,0 +1,303 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	channelPrefix = "realtime:"
+	streamPrefix  = "realtime:stream:"
+
+	// How much of a user's history is kept for Last-Event-ID replay
+	streamMaxLen = 500
+	streamTTL    = 24 * time.Hour
+
+	// Events a connection may fall behind by before it is dropped
+	BufferSize = 64
+)
+
+// Event is one message pushed to clients. User events have an ID taken from
+// the user's Redis stream so a reconnecting client can resume after it;
+// post events are ephemeral and carry no ID.
+type Event struct {
+	ID    string          `json:"id,omitempty"`
+	Topic string          `json:"-"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func UserTopic(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+func PostTopic(postID uuid.UUID) string {
+	return "post:" + postID.String()
+}
+
+// publishUserEvent appends the event to the user's stream and announces it on
+// Pub/Sub in one round trip, the message is "<id>\n<type>\n<data>"
+var publishUserEvent = redis.NewScript(`
+local id = redis.call("XADD", KEYS[1], "MAXLEN", "~", ARGV[1], "*", "type", ARGV[2], "data", ARGV[3])
+redis.call("EXPIRE", KEYS[1], ARGV[4])
+redis.call("PUBLISH", KEYS[2], id .. "\n" .. ARGV[2] .. "\n" .. ARGV[3])
+return id`)
+
+// Hub delivers events to the SSE connections of this instance. Events are
+// published through Redis Pub/Sub so a user connected to another instance
+// gets them too; without Redis delivery falls back to this instance only.
+type Hub struct {
+	Redis *redis.Client
+
+	mu     sync.Mutex
+	topics map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+func NewHub(Redis *redis.Client) *Hub {
+	return &Hub{Redis: Redis, topics: make(map[string]map[*Subscription]struct{})}
+}
+
+// Subscription receives the events of its topics on C. When the client can't
+// keep up and C fills, the subscription is dropped and Done is closed, the
+// client then reconnects and replays what it missed.
+type Subscription struct {
+	C      chan Event
+	done   chan struct{}
+	once   sync.Once
+	topics []string
+}
+
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	sub := &Subscription{C: make(chan Event, BufferSize), done: make(chan struct{}), topics: topics}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.done)
+		return sub
+	}
+	for _, topic := range topics {
+		if h.topics[topic] == nil {
+			h.topics[topic] = make(map[*Subscription]struct{})
+		}
+		h.topics[topic][sub] = struct{}{}
+	}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Close drops every subscription so the streams end and their clients
+// reconnect, to another instance while this one shuts down. Later
+// subscriptions are done right away.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.topics {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	for _, topic := range sub.topics {
+		delete(h.topics[topic], sub)
+		if len(h.topics[topic]) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+	sub.once.Do(func() { close(sub.done) })
+}
+
+// dispatch hands the event to the local subscribers of its topic without
+// ever blocking on a slow one
+func (h *Hub) dispatch(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.topics[event.Topic] {
+		select {
+		case sub.C <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// PublishUser sends an event to every connection of the user
+func (h *Hub) PublishUser(ctx context.Context, userID uuid.UUID, eventType string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logging.FromContext(ctx).Error("realtime: encode failed", zap.String("event", eventType), zap.Error(err))
+		return
+	}
+
+	topic := UserTopic(userID)
+	if initializers.RedisAvailable() {
+		keys := []string{streamPrefix + topic, channelPrefix + topic}
+		err := publishUserEvent.Run(ctx, h.Redis, keys, streamMaxLen, eventType, string(data), int(streamTTL.Seconds())).Err()
+		if err == nil {
+			return
+		}
+		logging.FromContext(ctx).Warn("realtime: publish failed", zap.String("event", eventType), zap.Error(err))
+	}
+	h.dispatch(Event{Topic: topic, Type: eventType, Data: data})
+}
+
+// PublishPost sends an event to everyone watching the post
+func (h *Hub) PublishPost(ctx context.Context, postID uuid.UUID, eventType string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logging.FromContext(ctx).Error("realtime: encode failed", zap.String("event", eventType), zap.Error(err))
+		return
+	}
+
+	topic := PostTopic(postID)
+	if initializers.RedisAvailable() {
+		err := h.Redis.Publish(ctx, channelPrefix+topic, "\n"+eventType+"\n"+string(data)).Err()
+		if err == nil {
+			return
+		}
+		logging.FromContext(ctx).Warn("realtime: publish failed", zap.String("event", eventType), zap.Error(err))
+	}
+	h.dispatch(Event{Topic: topic, Type: eventType, Data: data})
+}
+
+// Replay returns the user's events after lastID, oldest first
+func (h *Hub) Replay(ctx context.Context, userID uuid.UUID, lastID string) ([]Event, error) {
+	if !initializers.RedisAvailable() {
+		return nil, nil
+	}
+
+	// The start of XRANGE is inclusive, the entry with lastID itself is skipped below
+	messages, err := h.Redis.XRangeN(ctx, streamPrefix+UserTopic(userID), lastID, "+", streamMaxLen+1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(messages))
+	for _, message := range messages {
+		if message.ID == lastID {
+			continue
+		}
+		eventType, _ := message.Values["type"].(string)
+		data, _ := message.Values["data"].(string)
+		events = append(events, Event{ID: message.ID, Topic: UserTopic(userID), Type: eventType, Data: json.RawMessage(data)})
+	}
+	return events, nil
+}
+
+// Run listens on Redis Pub/Sub and dispatches to local connections until ctx
+// is cancelled, resubscribing whenever the connection drops.
+func (h *Hub) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if initializers.RedisAvailable() {
+			if err := h.listen(ctx); err != nil && ctx.Err() == nil {
+				zap.L().Warn("realtime: pubsub failed", zap.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context) error {
+	pubsub := h.Redis.PSubscribe(ctx, channelPrefix+"*")
+	defer pubsub.Close()
+
+	// Receiving ignores cancellation, closing the subscription ends it
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pubsub.Close()
+		case <-done:
+		}
+	}()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+
+		parts := strings.SplitN(msg.Payload, "\n", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		h.dispatch(Event{
+			ID:    parts[0],
+			Topic: strings.TrimPrefix(msg.Channel, channelPrefix),
+			Type:  parts[1],
+			Data:  json.RawMessage(parts[2]),
+		})
+	}
+}
+
+// After reports whether stream id a comes after b, ids look like "<ms>-<seq>"
+func After(a, b string) bool {
+	aMs, aSeq := splitID(a)
+	bMs, bSeq := splitID(b)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+func splitID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
+
+// ValidID checks a Last-Event-ID before it goes into XRANGE
+func ValidID(id string) bool {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return false
+	}
+	_, err1 := strconv.ParseUint(msPart, 10, 64)
+	_, err2 := strconv.ParseUint(seqPart, 10, 64)
+	return err1 == nil && err2 == nil
+}
+
+// Format writes the event in the text/event-stream format
+func (e Event) Format() string {
+	var b strings.Builder
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", e.ID)
+	}
+	fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", e.Type, e.Data)
+	return b.String()
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommentRepository interface {
+	// FindByID finds a comment of the post, but not one in the trash
+	FindByID(ctx context.Context, postID uuid.UUID, id string) (models.Comment, error)
+	// List lists the comments of the post below parentID, the top-level ones
+	// when it is nil, oldest first and starting after the cursor if there is
+	// one. Comments hidden by moderation are left out.
+	List(ctx context.Context, postID uuid.UUID, parentID *string, after *Cursor, limit int) ([]models.Comment, error)
+	Create(ctx context.Context, comment *models.Comment) error
+	// Edit replaces the content and keeps the previous one as a revision, it
+	// has to run in a transaction
+	Edit(ctx context.Context, comment *models.Comment, content string) error
+	// Revisions lists the earlier versions of the comment, newest first
+	Revisions(ctx context.Context, commentID string) ([]models.CommentRevision, error)
+	FindRevision(ctx context.Context, commentID, id string) (models.CommentRevision, error)
+	// Delete moves the author's comment and every reply below it to the trash
+	// and returns how many comments that were, 0 when there was no such
+	// comment. It has to run in a transaction.
+	Delete(ctx context.Context, authorID, postID uuid.UUID, id string) (int64, error)
+	// ListTrashed lists the user's comments moved to the trash after since, the last deleted first
+	ListTrashed(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Comment, error)
+	// FindTrashed finds the user's comment of the post if it went to the trash after since
+	FindTrashed(ctx context.Context, userID, postID uuid.UUID, id string, since time.Time) (models.Comment, error)
+	// Restore takes the trashed comment out of the trash together with the
+	// replies deleted with it and returns how many comments that were. It has
+	// to run in a transaction.
+	Restore(ctx context.Context, comment models.Comment) (int64, error)
+	// ReplyCounts returns the number of visible replies of each comment
+	ReplyCounts(ctx context.Context, ids []string) (map[string]int64, error)
+	// ReactionCounts returns the reactions per type of each comment, keyed by comment id
+	ReactionCounts(ctx context.Context, ids []string) (map[string]map[string]int64, error)
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+func (r *commentRepository) FindByID(ctx context.Context, postID uuid.UUID, id string) (models.Comment, error) {
+	var comment models.Comment
+	err := DB(ctx, r.db).First(&comment, "id = ? AND post_id = ?", id, postID).Error
+	return comment, notFound(err)
+}
+
+func (r *commentRepository) List(ctx context.Context, postID uuid.UUID, parentID *string, after *Cursor, limit int) ([]models.Comment, error) {
+	query := DB(ctx, r.db).Where("post_id = ? AND hidden_at IS NULL", postID)
+	if parentID != nil {
+		query = query.Where("parent_id = ?", *parentID)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+	if after != nil {
+		query = query.Where("(create_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	var comments []models.Comment
+	err := query.Order("create_at ASC, id ASC").Limit(limit).Find(&comments).Error
+	return comments, err
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	return DB(ctx, r.db).Create(comment).Error
+}
+
+func (r *commentRepository) Edit(ctx context.Context, comment *models.Comment, content string) error {
+	return EditComment(DB(ctx, r.db), comment, content)
+}
+
+func (r *commentRepository) Revisions(ctx context.Context, commentID string) ([]models.CommentRevision, error) {
+	var revisions []models.CommentRevision
+	err := DB(ctx, r.db).Where("comment_id = ?", commentID).Order("version DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *commentRepository) FindRevision(ctx context.Context, commentID, id string) (models.CommentRevision, error) {
+	var revision models.CommentRevision
+	err := DB(ctx, r.db).First(&revision, "id = ? AND comment_id = ?", id, commentID).Error
+	return revision, notFound(err)
+}
+
+func (r *commentRepository) Delete(ctx context.Context, authorID, postID uuid.UUID, id string) (int64, error) {
+	tx := DB(ctx, r.db)
+	// The whole thread shares one deleted_at so restoring the comment brings the replies back too
+	now := time.Now()
+	result := tx.Model(&models.Comment{}).Where("id = ? AND post_id = ? AND user_id = ?", id, postID, authorID).Update("deleted_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return 0, result.Error
+	}
+
+	deleted := result.RowsAffected
+	parentIDs := []string{id}
+	for depth := 0; len(parentIDs) > 0 && depth < models.MaxCommentDepth; depth++ {
+		var childIDs []string
+		if err := tx.Model(&models.Comment{}).Where("parent_id IN ?", parentIDs).Pluck("id", &childIDs).Error; err != nil {
+			return deleted, err
+		}
+		if len(childIDs) == 0 {
+			break
+		}
+		result := tx.Model(&models.Comment{}).Where("id IN ?", childIDs).Update("deleted_at", now)
+		if result.Error != nil {
+			return deleted, result.Error
+		}
+		deleted += result.RowsAffected
+		parentIDs = childIDs
+	}
+	return deleted, nil
+}
+
+func (r *commentRepository) ListTrashed(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := DB(ctx, r.db).Unscoped().Where("user_id = ? AND deleted_at > ?", userID, since).Order("deleted_at DESC").Find(&comments).Error
+	return comments, err
+}
+
+func (r *commentRepository) FindTrashed(ctx context.Context, userID, postID uuid.UUID, id string, since time.Time) (models.Comment, error) {
+	var comment models.Comment
+	err := DB(ctx, r.db).Unscoped().First(&comment, "id = ? AND post_id = ? AND user_id = ? AND deleted_at > ?", id, postID, userID, since).Error
+	return comment, notFound(err)
+}
+
+func (r *commentRepository) Restore(ctx context.Context, comment models.Comment) (int64, error) {
+	tx := DB(ctx, r.db)
+	// Replies deleted together with the comment share its deleted_at, ones
+	// deleted on their own before stay in the trash
+	deletedAt := comment.DeletedAt.Time
+	result := tx.Unscoped().Model(&models.Comment{}).Where("id = ?", comment.ID).Update("deleted_at", nil)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	restored := result.RowsAffected
+	parentIDs := []string{comment.ID}
+	for depth := 0; len(parentIDs) > 0 && depth < models.MaxCommentDepth; depth++ {
+		var childIDs []string
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("parent_id IN ? AND deleted_at = ?", parentIDs, deletedAt).Pluck("id", &childIDs).Error; err != nil {
+			return restored, err
+		}
+		if len(childIDs) == 0 {
+			break
+		}
+		result := tx.Unscoped().Model(&models.Comment{}).Where("id IN ?", childIDs).Update("deleted_at", nil)
+		if result.Error != nil {
+			return restored, result.Error
+		}
+		restored += result.RowsAffected
+		parentIDs = childIDs
+	}
+	return restored, nil
+}
+
+func (r *commentRepository) ReplyCounts(ctx context.Context, ids []string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentID string
+		Count    int64
+	}
+	err := DB(ctx, r.db).Model(&models.Comment{}).
+		Select("parent_id, count(*) AS count").
+		Where("parent_id IN ? AND hidden_at IS NULL", ids).
+		Group("parent_id").
+		Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+	return counts, err
+}
+
+func (r *commentRepository) ReactionCounts(ctx context.Context, ids []string) (map[string]map[string]int64, error) {
+	return reactionCounts(DB(ctx, r.db), &models.CommentReaction{}, "comment_id", ids)
+}
+
+// EditComment replaces the comment's content and keeps the previous one as a
+// revision. Nothing is written when the content is empty or unchanged.
+func EditComment(tx *gorm.DB, comment *models.Comment, content string) error {
+	// Lock the comment so concurrent edits get consecutive versions
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(comment, "id = ?", comment.ID).Error; err != nil {
+		return err
+	}
+	if content == "" || content == comment.Content {
+		return nil
+	}
+
+	var version int
+	if err := tx.Model(&models.CommentRevision{}).Where("comment_id = ?", comment.ID).Select("COALESCE(MAX(version), 0) + 1").Scan(&version).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	revision := models.CommentRevision{
+		CommentID: comment.ID,
+		Version:   version,
+		Content:   comment.Content,
+		CreatedAt: now,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"content": content, "edited": true, "edited_at": now, "updated_at": now}
+	if err := tx.Model(comment).Updates(updates).Error; err != nil {
+		return err
+	}
+	return tx.First(comment, "id = ?", comment.ID).Error
+}
+
+// reactionCounts returns per-type counts of the reactions in model, keyed by the target id in column
+func reactionCounts(db *gorm.DB, model interface{}, column string, ids interface{}) (map[string]map[string]int64, error) {
+	var rows []struct {
+		TargetID string
+		Type     string
+		Count    int64
+	}
+	err := db.Model(model).
+		Select(column+" AS target_id, type, count(*) AS count").
+		Where(column+" IN ?", ids).
+		Group(column + ", type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]map[string]int64)
+	for _, row := range rows {
+		if counts[row.TargetID] == nil {
+			counts[row.TargetID] = make(map[string]int64)
+		}
+		counts[row.TargetID][row.Type] = row.Count
+	}
+	return counts, nil
+}
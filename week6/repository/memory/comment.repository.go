@@ -0,0 +1,238 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"gorm.io/gorm"
+)
+
+type commentRepository struct {
+	*Store
+}
+
+func (r *commentRepository) FindByID(ctx context.Context, postID uuid.UUID, id string) (models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comment, ok := r.comments[id]
+	if !ok || comment.PostID != postID || comment.DeletedAt.Valid {
+		return models.Comment{}, repository.ErrNotFound
+	}
+	return comment, nil
+}
+
+func (r *commentRepository) List(ctx context.Context, postID uuid.UUID, parentID *string, after *repository.Cursor, limit int) ([]models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var comments []models.Comment
+	for _, comment := range r.comments {
+		if comment.PostID != postID || comment.DeletedAt.Valid || comment.HiddenAt != nil {
+			continue
+		}
+		if (parentID == nil) != (comment.ParentID == nil) || (parentID != nil && *parentID != *comment.ParentID) {
+			continue
+		}
+		if after != nil && !before(after.CreatedAt, after.ID, comment.CreateAt, comment.ID) {
+			continue
+		}
+		comments = append(comments, comment)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return before(comments[i].CreateAt, comments[i].ID, comments[j].CreateAt, comments[j].ID)
+	})
+	if len(comments) > limit {
+		comments = comments[:limit]
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if comment.ID == "" {
+		comment.ID = uuid.NewString()
+	}
+	r.comments[comment.ID] = *comment
+	return nil
+}
+
+func (r *commentRepository) Edit(ctx context.Context, comment *models.Comment, content string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.comments[comment.ID]
+	if !ok || current.DeletedAt.Valid {
+		return repository.ErrNotFound
+	}
+	*comment = current
+	if content == "" || content == current.Content {
+		return nil
+	}
+
+	version := 1
+	for _, revision := range r.commentRevisions {
+		if revision.CommentID == comment.ID && revision.Version >= version {
+			version = revision.Version + 1
+		}
+	}
+	now := time.Now()
+	r.commentRevisions = append(r.commentRevisions, models.CommentRevision{
+		ID:        uuid.NewString(),
+		CommentID: comment.ID,
+		Version:   version,
+		Content:   current.Content,
+		CreatedAt: now,
+	})
+
+	current.Content = content
+	current.Edited = true
+	current.EditedAt = &now
+	current.UpdatedAt = now
+	r.comments[comment.ID] = current
+	*comment = current
+	return nil
+}
+
+func (r *commentRepository) Revisions(ctx context.Context, commentID string) ([]models.CommentRevision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	revisions := []models.CommentRevision{}
+	for _, revision := range r.commentRevisions {
+		if revision.CommentID == commentID {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Version > revisions[j].Version })
+	return revisions, nil
+}
+
+func (r *commentRepository) FindRevision(ctx context.Context, commentID, id string) (models.CommentRevision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, revision := range r.commentRevisions {
+		if revision.ID == id && revision.CommentID == commentID {
+			return revision, nil
+		}
+	}
+	return models.CommentRevision{}, repository.ErrNotFound
+}
+
+func (r *commentRepository) Delete(ctx context.Context, authorID, postID uuid.UUID, id string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comment, ok := r.comments[id]
+	if !ok || comment.PostID != postID || comment.UserID != authorID || comment.DeletedAt.Valid {
+		return 0, nil
+	}
+
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	thread := map[string]bool{id: true}
+	for depth := 0; depth <= models.MaxCommentDepth; depth++ {
+		for _, reply := range r.comments {
+			if reply.ParentID != nil && thread[*reply.ParentID] {
+				thread[reply.ID] = true
+			}
+		}
+	}
+
+	var deleted int64
+	for threadID := range thread {
+		comment := r.comments[threadID]
+		if !comment.DeletedAt.Valid {
+			deleted++
+		}
+		comment.DeletedAt = deletedAt
+		r.comments[threadID] = comment
+	}
+	return deleted, nil
+}
+
+func (r *commentRepository) ListTrashed(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var comments []models.Comment
+	for _, comment := range r.comments {
+		if comment.UserID == userID && comment.DeletedAt.Valid && comment.DeletedAt.Time.After(since) {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].DeletedAt.Time.After(comments[j].DeletedAt.Time) })
+	return comments, nil
+}
+
+func (r *commentRepository) FindTrashed(ctx context.Context, userID, postID uuid.UUID, id string, since time.Time) (models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comment, ok := r.comments[id]
+	if !ok || comment.UserID != userID || comment.PostID != postID || !comment.DeletedAt.Valid || !comment.DeletedAt.Time.After(since) {
+		return models.Comment{}, repository.ErrNotFound
+	}
+	return comment, nil
+}
+
+func (r *commentRepository) Restore(ctx context.Context, comment models.Comment) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.comments[comment.ID]
+	if !ok || !current.DeletedAt.Valid {
+		return 0, nil
+	}
+
+	deletedAt := current.DeletedAt
+	thread := map[string]bool{comment.ID: true}
+	for depth := 0; depth < models.MaxCommentDepth; depth++ {
+		for _, reply := range r.comments {
+			if reply.ParentID != nil && thread[*reply.ParentID] && reply.DeletedAt == deletedAt {
+				thread[reply.ID] = true
+			}
+		}
+	}
+
+	for threadID := range thread {
+		restored := r.comments[threadID]
+		restored.DeletedAt = gorm.DeletedAt{}
+		r.comments[threadID] = restored
+	}
+	return int64(len(thread)), nil
+}
+
+func (r *commentRepository) ReplyCounts(ctx context.Context, ids []string) (map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	counts := make(map[string]int64)
+	for _, comment := range r.comments {
+		if comment.ParentID != nil && wanted[*comment.ParentID] && !comment.DeletedAt.Valid && comment.HiddenAt == nil {
+			counts[*comment.ParentID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *commentRepository) ReactionCounts(ctx context.Context, ids []string) (map[string]map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	counts := make(map[string]map[string]int64)
+	for _, reaction := range r.commentReactions {
+		if !wanted[reaction.CommentID] {
+			continue
+		}
+		if counts[reaction.CommentID] == nil {
+			counts[reaction.CommentID] = make(map[string]int64)
+		}
+		counts[reaction.CommentID][reaction.Type]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,79 @@
+// Package memory implements the repository interfaces on top of maps, for
+// tests that exercise the services without a database. All repositories of a
+// Store share its data, the way the gorm ones share the database.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+)
+
+type Store struct {
+	mu               sync.Mutex
+	users            map[uuid.UUID]models.User
+	follows          map[[2]uuid.UUID]models.UserFollower
+	blocks           map[[2]uuid.UUID]models.UserBlock
+	mentions         []models.Mention
+	posts            map[uuid.UUID]models.Post
+	revisions        []models.PostRevision
+	comments         map[string]models.Comment
+	commentRevisions []models.CommentRevision
+	reactions        []models.Reaction
+	commentReactions []models.CommentReaction
+	bookmarks        []models.Bookmark
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:    map[uuid.UUID]models.User{},
+		follows:  map[[2]uuid.UUID]models.UserFollower{},
+		blocks:   map[[2]uuid.UUID]models.UserBlock{},
+		posts:    map[uuid.UUID]models.Post{},
+		comments: map[string]models.Comment{},
+	}
+}
+
+func (s *Store) Users() repository.UserRepository         { return &userRepository{s} }
+func (s *Store) Follows() repository.FollowRepository     { return &followRepository{s} }
+func (s *Store) Blocks() repository.BlockRepository       { return &blockRepository{s} }
+func (s *Store) Mentions() repository.MentionRepository   { return &mentionRepository{s} }
+func (s *Store) Posts() repository.PostRepository         { return &postRepository{s} }
+func (s *Store) Comments() repository.CommentRepository   { return &commentRepository{s} }
+func (s *Store) Reactions() repository.ReactionRepository { return &reactionRepository{s} }
+
+// Transactor runs fn straight away. Nothing is rolled back when it fails, so
+// tests of failure paths should not rely on the store being left untouched.
+func (s *Store) Transactor() repository.Transactor { return transactor{} }
+
+type transactor struct{}
+
+func (transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Rows no repository method creates can be added directly
+
+func (s *Store) AddMention(mention models.Mention) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if mention.ID == "" {
+		mention.ID = uuid.NewString()
+	}
+	s.mentions = append(s.mentions, mention)
+}
+
+func (s *Store) AddReaction(reaction models.Reaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reactions = append(s.reactions, reaction)
+}
+
+func (s *Store) AddBookmark(bookmark models.Bookmark) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bookmarks = append(s.bookmarks, bookmark)
+}
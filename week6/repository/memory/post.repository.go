@@ -0,0 +1,331 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"gorm.io/gorm"
+)
+
+type postRepository struct {
+	*Store
+}
+
+func (r *postRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	post, ok := r.posts[id]
+	if !ok || post.DeletedAt.Valid {
+		return models.Post{}, repository.ErrNotFound
+	}
+	return post, nil
+}
+
+func (r *postRepository) FindWithTrashed(ctx context.Context, id uuid.UUID) (models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	post, ok := r.posts[id]
+	if !ok {
+		return models.Post{}, repository.ErrNotFound
+	}
+	return post, nil
+}
+
+func (r *postRepository) FindPublished(ctx context.Context, ids []uuid.UUID) ([]models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var posts []models.Post
+	for _, id := range ids {
+		if post, ok := r.posts[id]; ok && !post.DeletedAt.Valid && post.Status == models.PostPublished {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func (r *postRepository) ListPublished(ctx context.Context, offset, limit int) ([]models.Post, error) {
+	posts := r.list(func(post models.Post) bool { return post.Status == models.PostPublished })
+	// Postgres gives no order either, creation order at least keeps pages stable
+	sort.Slice(posts, func(i, j int) bool { return posts[i].CreatedAt.Before(posts[j].CreatedAt) })
+	if offset >= len(posts) {
+		return nil, nil
+	}
+	posts = posts[offset:]
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (r *postRepository) ListByStatus(ctx context.Context, userID uuid.UUID, statuses []string) ([]models.Post, error) {
+	posts := r.list(func(post models.Post) bool { return post.UserID == userID && contains(statuses, post.Status) })
+	sort.Slice(posts, func(i, j int) bool { return posts[i].UpdatedAt.After(posts[j].UpdatedAt) })
+	return posts, nil
+}
+
+func (r *postRepository) list(match func(models.Post) bool) []models.Post {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var posts []models.Post
+	for _, post := range r.posts {
+		if !post.DeletedAt.Valid && match(post) {
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if post.ID == uuid.Nil {
+		post.ID = uuid.New()
+	}
+	if post.Status == "" {
+		post.Status = models.PostPublished
+	}
+	if err := r.checkTitle(*post); err != nil {
+		return err
+	}
+	if err := r.checkRepost(*post); err != nil {
+		return err
+	}
+	r.posts[post.ID] = *post
+	return nil
+}
+
+func (r *postRepository) Edit(ctx context.Context, post *models.Post, changes map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.posts[post.ID]
+	if !ok || current.DeletedAt.Valid {
+		return repository.ErrNotFound
+	}
+	*post = current
+
+	edited := current
+	for column, value := range changes {
+		switch column {
+		case "title":
+			edited.Title = value
+		case "content":
+			edited.Content = value
+		case "image":
+			edited.Image = value
+		}
+	}
+	if edited.Title == current.Title && edited.Content == current.Content && edited.Image == current.Image {
+		return nil
+	}
+	if err := r.checkTitle(edited); err != nil {
+		return err
+	}
+
+	version := 1
+	for _, revision := range r.revisions {
+		if revision.PostID == post.ID && revision.Version >= version {
+			version = revision.Version + 1
+		}
+	}
+	now := time.Now()
+	r.revisions = append(r.revisions, models.PostRevision{
+		ID:        uuid.NewString(),
+		PostID:    post.ID,
+		Version:   version,
+		Title:     current.Title,
+		Content:   current.Content,
+		Image:     current.Image,
+		CreatedAt: now,
+	})
+
+	edited.Edited = true
+	edited.EditedAt = &now
+	edited.UpdatedAt = now
+	r.posts[post.ID] = edited
+	*post = edited
+	return nil
+}
+
+func (r *postRepository) Revisions(ctx context.Context, postID uuid.UUID) ([]models.PostRevision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	revisions := []models.PostRevision{}
+	for _, revision := range r.revisions {
+		if revision.PostID == postID {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Version > revisions[j].Version })
+	return revisions, nil
+}
+
+func (r *postRepository) FindRevision(ctx context.Context, postID uuid.UUID, id string) (models.PostRevision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, revision := range r.revisions {
+		if revision.ID == id && revision.PostID == postID {
+			return revision, nil
+		}
+	}
+	return models.PostRevision{}, repository.ErrNotFound
+}
+
+// checkTitle mirrors the unique index on non-empty titles
+func (r *postRepository) checkTitle(post models.Post) error {
+	if post.Title == "" {
+		return nil
+	}
+	for _, other := range r.posts {
+		if other.ID != post.ID && other.Title == post.Title {
+			return &repository.ConflictError{Field: "title"}
+		}
+	}
+	return nil
+}
+
+// checkRepost mirrors the unique index on a user's reposts that are not in the trash
+func (r *postRepository) checkRepost(post models.Post) error {
+	if post.RepostOfID == nil {
+		return nil
+	}
+	for _, other := range r.posts {
+		if other.ID != post.ID && other.UserID == post.UserID && other.RepostOfID != nil &&
+			*other.RepostOfID == *post.RepostOfID && !other.DeletedAt.Valid {
+			return &repository.ConflictError{Field: "repost"}
+		}
+	}
+	return nil
+}
+
+func (r *postRepository) ChangeStatus(ctx context.Context, post *models.Post, from []string, updates map[string]interface{}) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.posts[post.ID]
+	if !ok || current.DeletedAt.Valid || !contains(from, current.Status) {
+		return false, nil
+	}
+
+	for column, value := range updates {
+		switch column {
+		case "status":
+			current.Status = value.(string)
+		case "publish_at":
+			current.PublishAt = timePointer(value)
+		case "published_at":
+			current.PublishedAt = timePointer(value)
+		case "updated_at":
+			current.UpdatedAt = value.(time.Time)
+		}
+	}
+	r.posts[post.ID] = current
+	*post = current
+	return true, nil
+}
+
+func (r *postRepository) Delete(ctx context.Context, post models.Post) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.posts[post.ID]
+	if !ok || current.DeletedAt.Valid {
+		return false, nil
+	}
+	current.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.posts[post.ID] = current
+	return true, nil
+}
+
+func (r *postRepository) ListTrashed(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var posts []models.Post
+	for _, post := range r.posts {
+		if post.UserID == userID && post.DeletedAt.Valid && post.DeletedAt.Time.After(since) {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].DeletedAt.Time.After(posts[j].DeletedAt.Time) })
+	return posts, nil
+}
+
+func (r *postRepository) Restore(ctx context.Context, userID, id uuid.UUID, since time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	post, ok := r.posts[id]
+	if !ok || post.UserID != userID || !post.DeletedAt.Valid || !post.DeletedAt.Time.After(since) {
+		return false, nil
+	}
+	post.DeletedAt = gorm.DeletedAt{}
+	if err := r.checkRepost(post); err != nil {
+		return false, err
+	}
+	r.posts[id] = post
+	return true, nil
+}
+
+func (r *postRepository) RepostIDs(ctx context.Context, userID, id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, post := range r.list(func(post models.Post) bool {
+		return post.UserID == userID && post.RepostOfID != nil && *post.RepostOfID == id
+	}) {
+		ids = append(ids, post.ID)
+	}
+	return ids, nil
+}
+
+func (r *postRepository) ReactionCounts(ctx context.Context, ids []uuid.UUID) (map[string]map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wanted := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	counts := make(map[string]map[string]int64)
+	for _, reaction := range r.reactions {
+		if !wanted[reaction.PostID] {
+			continue
+		}
+		key := reaction.PostID.String()
+		if counts[key] == nil {
+			counts[key] = make(map[string]int64)
+		}
+		counts[key][reaction.Type]++
+	}
+	return counts, nil
+}
+
+func (r *postRepository) CountBookmarks(ctx context.Context, id uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, bookmark := range r.bookmarks {
+		if bookmark.PostID == id {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// timePointer accepts the values the services pass for nullable time columns
+func timePointer(value interface{}) *time.Time {
+	switch t := value.(type) {
+	case time.Time:
+		return &t
+	case *time.Time:
+		return t
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+)
+
+type reactionRepository struct {
+	*Store
+}
+
+func (r *reactionRepository) AddPostReaction(ctx context.Context, reaction *models.Reaction) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.reactions {
+		if existing.PostID == reaction.PostID && existing.UserID == reaction.UserID {
+			return false, nil
+		}
+	}
+	if reaction.ID == "" {
+		reaction.ID = uuid.NewString()
+	}
+	r.reactions = append(r.reactions, *reaction)
+	return true, nil
+}
+
+func (r *reactionRepository) UpdatePostReaction(ctx context.Context, reaction *models.Reaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.reactions {
+		if existing.PostID == reaction.PostID && existing.UserID == reaction.UserID {
+			r.reactions[i].Type = reaction.Type
+			r.reactions[i].UpdatedAt = reaction.UpdatedAt
+			*reaction = r.reactions[i]
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *reactionRepository) RemovePostReaction(ctx context.Context, postID, userID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.reactions {
+		if existing.PostID == postID && existing.UserID == userID {
+			r.reactions = append(r.reactions[:i], r.reactions[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *reactionRepository) SetCommentReaction(ctx context.Context, reaction *models.CommentReaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.commentReactions {
+		if existing.CommentID == reaction.CommentID && existing.UserID == reaction.UserID {
+			r.commentReactions[i].Type = reaction.Type
+			r.commentReactions[i].UpdatedAt = reaction.UpdatedAt
+			*reaction = r.commentReactions[i]
+			return nil
+		}
+	}
+	if reaction.ID == "" {
+		reaction.ID = uuid.NewString()
+	}
+	r.commentReactions = append(r.commentReactions, *reaction)
+	return nil
+}
+
+func (r *reactionRepository) RemoveCommentReaction(ctx context.Context, commentID string, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.commentReactions {
+		if existing.CommentID == commentID && existing.UserID == userID {
+			r.commentReactions = append(r.commentReactions[:i], r.commentReactions[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *reactionRepository) PostReactors(ctx context.Context, postID uuid.UUID, reactionType string, after *repository.Cursor, limit int) ([]models.ReactorResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reactors []models.ReactorResponse
+	for _, reaction := range r.reactions {
+		if reaction.PostID == postID {
+			reactors = r.appendReactor(reactors, reaction.ID, reaction.UserID, reaction.Type, reaction.CreatedAt)
+		}
+	}
+	return pageReactors(reactors, reactionType, after, limit), nil
+}
+
+func (r *reactionRepository) CommentReactors(ctx context.Context, commentID string, reactionType string, after *repository.Cursor, limit int) ([]models.ReactorResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reactors []models.ReactorResponse
+	for _, reaction := range r.commentReactions {
+		if reaction.CommentID == commentID {
+			reactors = r.appendReactor(reactors, reaction.ID, reaction.UserID, reaction.Type, reaction.CreatedAt)
+		}
+	}
+	return pageReactors(reactors, reactionType, after, limit), nil
+}
+
+// appendReactor joins the reaction with its user, like the JOIN on users does
+func (r *reactionRepository) appendReactor(reactors []models.ReactorResponse, id string, userID uuid.UUID, reactionType string, createdAt time.Time) []models.ReactorResponse {
+	user, ok := r.users[userID]
+	if !ok {
+		return reactors
+	}
+	return append(reactors, models.ReactorResponse{
+		ID:           id,
+		UserID:       user.ID,
+		Name:         user.Name,
+		ProfileImage: user.ProfileImage,
+		Type:         reactionType,
+		ReactedAt:    createdAt,
+	})
+}
+
+func pageReactors(reactors []models.ReactorResponse, reactionType string, after *repository.Cursor, limit int) []models.ReactorResponse {
+	var page []models.ReactorResponse
+	for _, reactor := range reactors {
+		if reactionType != "" && reactor.Type != reactionType {
+			continue
+		}
+		if after != nil && !before(reactor.ReactedAt, reactor.ID, after.CreatedAt, after.ID) {
+			continue
+		}
+		page = append(page, reactor)
+	}
+	sort.Slice(page, func(i, j int) bool {
+		return before(page[j].ReactedAt, page[j].ID, page[i].ReactedAt, page[i].ID)
+	})
+	if len(page) > limit {
+		page = page[:limit]
+	}
+	return page
+}
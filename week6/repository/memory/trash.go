@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Purger stands in for *trash.Purger on a Store
+type Purger struct {
+	*Store
+	Retention time.Duration
+}
+
+func (s *Store) Purger(retention time.Duration) *Purger {
+	return &Purger{Store: s, Retention: retention}
+}
+
+func (p *Purger) Cutoff() time.Time {
+	return time.Now().Add(-p.Retention)
+}
+
+// PurgePosts drops the posts, trashed or not, with their comments, reactions,
+// bookmarks and revisions
+func (p *Purger) PurgePosts(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	purge := map[uuid.UUID]bool{}
+	var purged int64
+	for _, id := range ids {
+		if _, ok := p.posts[id]; ok {
+			delete(p.posts, id)
+			purge[id] = true
+			purged++
+		}
+	}
+
+	for id, comment := range p.comments {
+		if purge[comment.PostID] {
+			delete(p.comments, id)
+		}
+	}
+	reactions := p.reactions[:0]
+	for _, reaction := range p.reactions {
+		if !purge[reaction.PostID] {
+			reactions = append(reactions, reaction)
+		}
+	}
+	p.reactions = reactions
+	bookmarks := p.bookmarks[:0]
+	for _, bookmark := range p.bookmarks {
+		if !purge[bookmark.PostID] {
+			bookmarks = append(bookmarks, bookmark)
+		}
+	}
+	p.bookmarks = bookmarks
+	revisions := p.revisions[:0]
+	for _, revision := range p.revisions {
+		if !purge[revision.PostID] {
+			revisions = append(revisions, revision)
+		}
+	}
+	p.revisions = revisions
+	return purged, nil
+}
@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+)
+
+type userRepository struct {
+	*Store
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return user, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.find(func(user models.User) bool { return user.Email == email })
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (models.User, error) {
+	return r.find(func(user models.User) bool { return user.Username == username })
+}
+
+func (r *userRepository) find(match func(models.User) bool) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return models.User{}, repository.ErrNotFound
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	if err := r.checkUnique(*user); err != nil {
+		return err
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkUnique(*user); err != nil {
+		return err
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+// checkUnique mirrors the unique indexes on email and non-empty usernames
+func (r *userRepository) checkUnique(user models.User) error {
+	for _, other := range r.users {
+		if other.ID == user.ID {
+			continue
+		}
+		if other.Email == user.Email {
+			return &repository.ConflictError{Field: "email"}
+		}
+		if user.Username != "" && other.Username == user.Username {
+			return &repository.ConflictError{Field: "username"}
+		}
+	}
+	return nil
+}
+
+type followRepository struct {
+	*Store
+}
+
+func (r *followRepository) IsFollowing(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.follows[[2]uuid.UUID{followerID, followingID}]
+	return ok, nil
+}
+
+func (r *followRepository) Follow(ctx context.Context, followerID, followingID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]uuid.UUID{followerID, followingID}
+	if _, ok := r.follows[key]; ok {
+		return &repository.ConflictError{Field: "record"}
+	}
+	r.follows[key] = models.UserFollower{FollowerID: followerID, FollowingID: followingID, CreatedAt: at}
+	return nil
+}
+
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]uuid.UUID{followerID, followingID}
+	_, ok := r.follows[key]
+	delete(r.follows, key)
+	return ok, nil
+}
+
+type blockRepository struct {
+	*Store
+}
+
+func (r *blockRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]uuid.UUID{blockerID, blockedID}
+	if _, ok := r.blocks[key]; !ok {
+		r.blocks[key] = models.UserBlock{BlockerID: blockerID, BlockedID: blockedID, CreatedAt: at}
+	}
+	return nil
+}
+
+func (r *blockRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]uuid.UUID{blockerID, blockedID}
+	_, ok := r.blocks[key]
+	delete(r.blocks, key)
+	return ok, nil
+}
+
+func (r *blockRepository) BlockedBetween(ctx context.Context, a, b uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ab := r.blocks[[2]uuid.UUID{a, b}]
+	_, ba := r.blocks[[2]uuid.UUID{b, a}]
+	return ab || ba, nil
+}
+
+func (r *blockRepository) FindBlocked(ctx context.Context, blockerID uuid.UUID) ([]models.UserSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var blocks []models.UserBlock
+	for _, block := range r.blocks {
+		if block.BlockerID == blockerID {
+			blocks = append(blocks, block)
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].CreatedAt.After(blocks[j].CreatedAt) })
+
+	var users []models.UserSummary
+	for _, block := range blocks {
+		if user, ok := r.users[block.BlockedID]; ok {
+			users = append(users, models.UserSummary{ID: user.ID, Name: user.Name, Username: user.Username, ProfileImage: user.ProfileImage})
+		}
+	}
+	return users, nil
+}
+
+type mentionRepository struct {
+	*Store
+}
+
+func (r *mentionRepository) FindForUser(ctx context.Context, userID uuid.UUID, after *repository.Cursor, limit int) ([]models.Mention, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var mentions []models.Mention
+	for _, mention := range r.mentions {
+		if mention.MentionedUserID != userID {
+			continue
+		}
+		if after != nil && !before(mention.CreatedAt, mention.ID, after.CreatedAt, after.ID) {
+			continue
+		}
+		mentions = append(mentions, mention)
+	}
+	sort.Slice(mentions, func(i, j int) bool {
+		return before(mentions[j].CreatedAt, mentions[j].ID, mentions[i].CreatedAt, mentions[i].ID)
+	})
+	if len(mentions) > limit {
+		mentions = mentions[:limit]
+	}
+	return mentions, nil
+}
+
+// before compares (created_at, id) pairs the way the SQL row comparison does
+func before(createdAt time.Time, id string, thanCreatedAt time.Time, thanID string) bool {
+	if !createdAt.Equal(thanCreatedAt) {
+		return createdAt.Before(thanCreatedAt)
+	}
+	return id < thanID
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostRepository interface {
+	// FindByID finds a post whatever its status, but not one in the trash
+	FindByID(ctx context.Context, id uuid.UUID) (models.Post, error)
+	// FindWithTrashed finds a post even if it is in the trash
+	FindWithTrashed(ctx context.Context, id uuid.UUID) (models.Post, error)
+	FindPublished(ctx context.Context, ids []uuid.UUID) ([]models.Post, error)
+	ListPublished(ctx context.Context, offset, limit int) ([]models.Post, error)
+	// ListByStatus lists the user's posts in one of statuses, the last updated first
+	ListByStatus(ctx context.Context, userID uuid.UUID, statuses []string) ([]models.Post, error)
+	// Create returns a ConflictError for a taken "title"
+	Create(ctx context.Context, post *models.Post) error
+	// Edit applies changes (column -> new value) and keeps the previous text
+	// as a revision, it has to run in a transaction
+	Edit(ctx context.Context, post *models.Post, changes map[string]string) error
+	// Revisions lists the earlier versions of the post, newest first
+	Revisions(ctx context.Context, postID uuid.UUID) ([]models.PostRevision, error)
+	FindRevision(ctx context.Context, postID uuid.UUID, id string) (models.PostRevision, error)
+	// ChangeStatus applies updates if the post is still in one of the from
+	// states and reloads it, false means it wasn't
+	ChangeStatus(ctx context.Context, post *models.Post, from []string, updates map[string]interface{}) (bool, error)
+	// Delete moves the post to the trash, false means it was already there
+	Delete(ctx context.Context, post models.Post) (bool, error)
+	// ListTrashed lists the user's posts moved to the trash after since, the last deleted first
+	ListTrashed(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Post, error)
+	// Restore takes the user's post out of the trash if it went there after
+	// since, false means there was no such post. It returns a ConflictError
+	// for a "repost" of a post the user reposted again in the meantime.
+	Restore(ctx context.Context, userID, id uuid.UUID, since time.Time) (bool, error)
+	// RepostIDs lists the user's reposts of the post that are not in the trash
+	RepostIDs(ctx context.Context, userID, id uuid.UUID) ([]uuid.UUID, error)
+	// ReactionCounts returns the reactions per type of each post, keyed by post id
+	ReactionCounts(ctx context.Context, ids []uuid.UUID) (map[string]map[string]int64, error)
+	CountBookmarks(ctx context.Context, id uuid.UUID) (int64, error)
+}
+
+var postIndexes = map[string]string{
+	"idx_posts_title_nonempty": "title", "posts.title": "title",
+	"idx_posts_user_repost": "repost", "posts.user_id": "repost",
+}
+
+type postRepository struct {
+	db *gorm.DB
+}
+
+func NewPostRepository(db *gorm.DB) PostRepository {
+	return &postRepository{db: db}
+}
+
+func (r *postRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Post, error) {
+	var post models.Post
+	err := DB(ctx, r.db).First(&post, "id = ?", id).Error
+	return post, notFound(err)
+}
+
+func (r *postRepository) FindWithTrashed(ctx context.Context, id uuid.UUID) (models.Post, error) {
+	var post models.Post
+	err := DB(ctx, r.db).Unscoped().First(&post, "id = ?", id).Error
+	return post, notFound(err)
+}
+
+func (r *postRepository) FindPublished(ctx context.Context, ids []uuid.UUID) ([]models.Post, error) {
+	var posts []models.Post
+	err := DB(ctx, r.db).Where("id IN ? AND status = ?", ids, models.PostPublished).Find(&posts).Error
+	return posts, err
+}
+
+func (r *postRepository) ListPublished(ctx context.Context, offset, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := DB(ctx, r.db).Where("status = ?", models.PostPublished).Limit(limit).Offset(offset).Find(&posts).Error
+	return posts, err
+}
+
+func (r *postRepository) ListByStatus(ctx context.Context, userID uuid.UUID, statuses []string) ([]models.Post, error) {
+	var posts []models.Post
+	err := DB(ctx, r.db).Where("user_id = ? AND status IN ?", userID, statuses).Order("updated_at DESC").Find(&posts).Error
+	return posts, err
+}
+
+func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	return conflict(DB(ctx, r.db).Create(post).Error, postIndexes)
+}
+
+func (r *postRepository) Edit(ctx context.Context, post *models.Post, changes map[string]string) error {
+	return conflict(EditPost(DB(ctx, r.db), post, changes), postIndexes)
+}
+
+func (r *postRepository) Revisions(ctx context.Context, postID uuid.UUID) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	err := DB(ctx, r.db).Where("post_id = ?", postID).Order("version DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *postRepository) FindRevision(ctx context.Context, postID uuid.UUID, id string) (models.PostRevision, error) {
+	var revision models.PostRevision
+	err := DB(ctx, r.db).First(&revision, "id = ? AND post_id = ?", id, postID).Error
+	return revision, notFound(err)
+}
+
+func (r *postRepository) ChangeStatus(ctx context.Context, post *models.Post, from []string, updates map[string]interface{}) (bool, error) {
+	db := DB(ctx, r.db)
+	result := db.Model(post).Where("status IN ?", from).Updates(updates)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	return true, db.First(post, "id = ?", post.ID).Error
+}
+
+func (r *postRepository) Delete(ctx context.Context, post models.Post) (bool, error) {
+	result := DB(ctx, r.db).Delete(&post)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *postRepository) ListTrashed(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Post, error) {
+	var posts []models.Post
+	err := DB(ctx, r.db).Unscoped().Where("user_id = ? AND deleted_at > ?", userID, since).Order("deleted_at DESC").Find(&posts).Error
+	return posts, err
+}
+
+func (r *postRepository) Restore(ctx context.Context, userID, id uuid.UUID, since time.Time) (bool, error) {
+	result := DB(ctx, r.db).Unscoped().Model(&models.Post{}).
+		Where("id = ? AND user_id = ? AND deleted_at > ?", id, userID, since).
+		Update("deleted_at", nil)
+	return result.RowsAffected > 0, conflict(result.Error, postIndexes)
+}
+
+func (r *postRepository) RepostIDs(ctx context.Context, userID, id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := DB(ctx, r.db).Model(&models.Post{}).Where("user_id = ? AND repost_of_id = ?", userID, id).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *postRepository) ReactionCounts(ctx context.Context, ids []uuid.UUID) (map[string]map[string]int64, error) {
+	return reactionCounts(DB(ctx, r.db), &models.Reaction{}, "post_id", ids)
+}
+
+func (r *postRepository) CountBookmarks(ctx context.Context, id uuid.UUID) (int64, error) {
+	var count int64
+	err := DB(ctx, r.db).Model(&models.Bookmark{}).Where("post_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// EditPost applies changes (column -> new value) to post and keeps the
+// previous text as a revision. Nothing is written when no value changes.
+func EditPost(tx *gorm.DB, post *models.Post, changes map[string]string) error {
+	// Lock the post so concurrent edits get consecutive versions
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(post, "id = ?", post.ID).Error; err != nil {
+		return err
+	}
+
+	current := map[string]string{"title": post.Title, "content": post.Content, "image": post.Image}
+	updates := map[string]interface{}{}
+	for column, value := range changes {
+		if current[column] != value {
+			updates[column] = value
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	var version int
+	if err := tx.Model(&models.PostRevision{}).Where("post_id = ?", post.ID).Select("COALESCE(MAX(version), 0) + 1").Scan(&version).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	revision := models.PostRevision{
+		PostID:    post.ID,
+		Version:   version,
+		Title:     post.Title,
+		Content:   post.Content,
+		Image:     post.Image,
+		CreatedAt: now,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return err
+	}
+
+	updates["edited"] = true
+	updates["edited_at"] = now
+	updates["updated_at"] = now
+	if err := tx.Model(post).Updates(updates).Error; err != nil {
+		return err
+	}
+	return tx.First(post, "id = ?", post.ID).Error
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReactionRepository stores the reactions on posts and comments, a user has
+// at most one on each
+type ReactionRepository interface {
+	// AddPostReaction inserts the reaction, false means the user had already
+	// reacted to the post and nothing was written
+	AddPostReaction(ctx context.Context, reaction *models.Reaction) (bool, error)
+	// UpdatePostReaction changes the type of the user's reaction and reloads it
+	UpdatePostReaction(ctx context.Context, reaction *models.Reaction) error
+	// RemovePostReaction deletes the user's reaction, false means there was none
+	RemovePostReaction(ctx context.Context, postID, userID uuid.UUID) (bool, error)
+	// SetCommentReaction adds the reaction or changes the type of the user's existing one
+	SetCommentReaction(ctx context.Context, reaction *models.CommentReaction) error
+	RemoveCommentReaction(ctx context.Context, commentID string, userID uuid.UUID) error
+	// PostReactors and CommentReactors list who reacted, newest first and
+	// starting after the cursor if there is one. An empty reactionType means any.
+	PostReactors(ctx context.Context, postID uuid.UUID, reactionType string, after *Cursor, limit int) ([]models.ReactorResponse, error)
+	CommentReactors(ctx context.Context, commentID string, reactionType string, after *Cursor, limit int) ([]models.ReactorResponse, error)
+}
+
+type reactionRepository struct {
+	db *gorm.DB
+}
+
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+func (r *reactionRepository) AddPostReaction(ctx context.Context, reaction *models.Reaction) (bool, error) {
+	// The unique index turns a concurrent duplicate into a no-op
+	result := DB(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *reactionRepository) UpdatePostReaction(ctx context.Context, reaction *models.Reaction) error {
+	db := DB(ctx, r.db)
+	err := db.Model(&models.Reaction{}).Where("post_id = ? AND user_id = ?", reaction.PostID, reaction.UserID).
+		Updates(map[string]interface{}{"type": reaction.Type, "updated_at": reaction.UpdatedAt}).Error
+	if err != nil {
+		return err
+	}
+	return notFound(db.First(reaction, "post_id = ? AND user_id = ?", reaction.PostID, reaction.UserID).Error)
+}
+
+func (r *reactionRepository) RemovePostReaction(ctx context.Context, postID, userID uuid.UUID) (bool, error) {
+	result := DB(ctx, r.db).Where("post_id = ? AND user_id = ?", postID, userID).Delete(&models.Reaction{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *reactionRepository) SetCommentReaction(ctx context.Context, reaction *models.CommentReaction) error {
+	// Relies on the (comment_id, user_id) unique index so concurrent requests
+	// can't produce duplicate reactions
+	return DB(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "comment_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "updated_at"}),
+	}).Create(reaction).Error
+}
+
+func (r *reactionRepository) RemoveCommentReaction(ctx context.Context, commentID string, userID uuid.UUID) error {
+	return DB(ctx, r.db).Where("comment_id = ? AND user_id = ?", commentID, userID).Delete(&models.CommentReaction{}).Error
+}
+
+func (r *reactionRepository) PostReactors(ctx context.Context, postID uuid.UUID, reactionType string, after *Cursor, limit int) ([]models.ReactorResponse, error) {
+	return r.reactors(ctx, "reactions", "post_id", postID.String(), reactionType, after, limit)
+}
+
+func (r *reactionRepository) CommentReactors(ctx context.Context, commentID string, reactionType string, after *Cursor, limit int) ([]models.ReactorResponse, error) {
+	return r.reactors(ctx, "comment_reactions", "comment_id", commentID, reactionType, after, limit)
+}
+
+func (r *reactionRepository) reactors(ctx context.Context, table, column, targetID, reactionType string, after *Cursor, limit int) ([]models.ReactorResponse, error) {
+	query := DB(ctx, r.db).Table(table).
+		Select(table+".id, "+table+".user_id, users.name, users.profile_image, "+table+".type, "+table+".created_at AS reacted_at").
+		Joins("JOIN users ON users.id = "+table+".user_id").
+		Where(table+"."+column+" = ?", targetID)
+	if reactionType != "" {
+		query = query.Where(table+".type = ?", reactionType)
+	}
+	if after != nil {
+		query = query.Where("("+table+".created_at, "+table+".id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	var reactors []models.ReactorResponse
+	err := query.Order(table + ".created_at DESC, " + table + ".id DESC").Limit(limit).Scan(&reactors).Error
+	return reactors, err
+}
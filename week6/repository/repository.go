@@ -0,0 +1,84 @@
+// Package repository is the data access layer. Services depend on the
+// interfaces, the gorm implementations in this package are used by the app
+// and the ones in repository/memory by tests that run without Postgres.
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+// ConflictError is returned when a write breaks a unique constraint, Field
+// names what clashed, e.g. "email"
+type ConflictError struct {
+	Field string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Field + " already exists"
+}
+
+// Cursor is a position in a list ordered by creation time, see utils.EncodeCursor
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Transactor runs fn in a transaction, the repositories called with the ctx
+// fn receives take part in it
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) Transactor {
+	return &gormTransactor{db: db}
+}
+
+func (t *gormTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return DB(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// DB returns the transaction ctx carries, or db bound to ctx outside of one.
+// Code that still works with gorm directly uses it to join a service's transaction.
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// conflict turns a unique violation into a ConflictError, fields maps index
+// names to what they guard. SQLite, which the e2e tests can run on, names the
+// columns instead of the index, e.g. "users.email".
+func conflict(err error, fields map[string]string) error {
+	if err == nil || !(strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint failed")) {
+		return err
+	}
+	for index, field := range fields {
+		if strings.Contains(err.Error(), index) {
+			return &ConflictError{Field: field}
+		}
+	}
+	return &ConflictError{Field: "record"}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	FindByUsername(ctx context.Context, username string) (models.User, error)
+	// Create and Update return a ConflictError for a taken "email" or "username"
+	Create(ctx context.Context, user *models.User) error
+	Update(ctx context.Context, user *models.User) error
+}
+
+type FollowRepository interface {
+	IsFollowing(ctx context.Context, followerID, followingID uuid.UUID) (bool, error)
+	Follow(ctx context.Context, followerID, followingID uuid.UUID, at time.Time) error
+	// Unfollow reports false when there was nothing to remove
+	Unfollow(ctx context.Context, followerID, followingID uuid.UUID) (bool, error)
+}
+
+type BlockRepository interface {
+	// Block does nothing when the block already exists
+	Block(ctx context.Context, blockerID, blockedID uuid.UUID, at time.Time) error
+	Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+	// BlockedBetween reports whether either user blocked the other
+	BlockedBetween(ctx context.Context, a, b uuid.UUID) (bool, error)
+	// FindBlocked lists the users blockerID blocked, the latest first
+	FindBlocked(ctx context.Context, blockerID uuid.UUID) ([]models.UserSummary, error)
+}
+
+type MentionRepository interface {
+	// FindForUser lists where the user was mentioned, newest first, starting after the cursor if there is one
+	FindForUser(ctx context.Context, userID uuid.UUID, after *Cursor, limit int) ([]models.Mention, error)
+}
+
+var userIndexes = map[string]string{
+	"idx_users_email": "email", "users.email": "email",
+	"idx_users_username": "username", "users.username": "username",
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (models.User, error) {
+	var user models.User
+	err := DB(ctx, r.db).First(&user, "id = ?", id).Error
+	return user, notFound(err)
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := DB(ctx, r.db).First(&user, "email = ?", email).Error
+	return user, notFound(err)
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (models.User, error) {
+	var user models.User
+	err := DB(ctx, r.db).First(&user, "username = ?", username).Error
+	return user, notFound(err)
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return conflict(DB(ctx, r.db).Create(user).Error, userIndexes)
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	return conflict(DB(ctx, r.db).Save(user).Error, userIndexes)
+}
+
+type followRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) FollowRepository {
+	return &followRepository{db: db}
+}
+
+func (r *followRepository) IsFollowing(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	var count int64
+	err := DB(ctx, r.db).Model(&models.UserFollower{}).Where("follower_id = ? AND following_id = ?", followerID, followingID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *followRepository) Follow(ctx context.Context, followerID, followingID uuid.UUID, at time.Time) error {
+	follow := models.UserFollower{FollowerID: followerID, FollowingID: followingID, CreatedAt: at}
+	return conflict(DB(ctx, r.db).Create(&follow).Error, nil)
+}
+
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	result := DB(ctx, r.db).Where("follower_id = ? AND following_id = ?", followerID, followingID).Delete(&models.UserFollower{})
+	return result.RowsAffected > 0, result.Error
+}
+
+type blockRepository struct {
+	db *gorm.DB
+}
+
+func NewBlockRepository(db *gorm.DB) BlockRepository {
+	return &blockRepository{db: db}
+}
+
+func (r *blockRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID, at time.Time) error {
+	block := models.UserBlock{BlockerID: blockerID, BlockedID: blockedID, CreatedAt: at}
+	return DB(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error
+}
+
+func (r *blockRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	result := DB(ctx, r.db).Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&models.UserBlock{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *blockRepository) BlockedBetween(ctx context.Context, a, b uuid.UUID) (bool, error) {
+	var count int64
+	err := DB(ctx, r.db).Model(&models.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *blockRepository) FindBlocked(ctx context.Context, blockerID uuid.UUID) ([]models.UserSummary, error) {
+	var users []models.UserSummary
+	err := DB(ctx, r.db).Model(&models.User{}).Select("users.id, users.name, users.username, users.profile_image").
+		Joins("JOIN user_blocks ON user_blocks.blocked_id = users.id").
+		Where("user_blocks.blocker_id = ?", blockerID).
+		Order("user_blocks.created_at DESC").Scan(&users).Error
+	return users, err
+}
+
+type mentionRepository struct {
+	db *gorm.DB
+}
+
+func NewMentionRepository(db *gorm.DB) MentionRepository {
+	return &mentionRepository{db: db}
+}
+
+func (r *mentionRepository) FindForUser(ctx context.Context, userID uuid.UUID, after *Cursor, limit int) ([]models.Mention, error) {
+	query := DB(ctx, r.db).Where("mentioned_user_id = ?", userID)
+	if after != nil {
+		query = query.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	var mentions []models.Mention
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&mentions).Error
+	return mentions, err
+}
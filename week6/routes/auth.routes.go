@@ -8,10 +8,11 @@ import (
 
 type AuthRouteController struct {
 	authController controllers.AuthController
+	auth           middleware.Authenticator
 }
 
-func NewAuthRouteController(authController controllers.AuthController) AuthRouteController {
-	return AuthRouteController{authController}
+func NewAuthRouteController(authController controllers.AuthController, auth middleware.Authenticator) AuthRouteController {
+	return AuthRouteController{authController, auth}
 }
 
 func (rc *AuthRouteController) AuthRoute(rg *gin.RouterGroup) {
@@ -20,5 +21,5 @@ func (rc *AuthRouteController) AuthRoute(rg *gin.RouterGroup) {
 	router.POST("/register", rc.authController.SignUpUser)
 	router.POST("/login", rc.authController.SignInUser)
 	router.GET("/refresh", rc.authController.RefreshAccessToken)
-	router.GET("/logout", middleware.DeserializeUser(), rc.authController.LogoutUser)
+	router.GET("/logout", middleware.DeserializeUser(rc.auth), rc.authController.LogoutUser)
 }
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/middleware"
+)
+
+type BookmarkRouteController struct {
+	bookmarkController controllers.BookmarkController
+	auth               middleware.Authenticator
+}
+
+func NewRouteBookmarkController(bookmarkController controllers.BookmarkController, auth middleware.Authenticator) BookmarkRouteController {
+	return BookmarkRouteController{bookmarkController, auth}
+}
+
+func (bc *BookmarkRouteController) BookmarkRoute(rg *gin.RouterGroup) {
+
+	rg.PUT("posts/:postId/bookmark", middleware.DeserializeUser(bc.auth), bc.bookmarkController.SavePost)
+	rg.DELETE("posts/:postId/bookmark", middleware.DeserializeUser(bc.auth), bc.bookmarkController.UnsavePost)
+
+	router := rg.Group("bookmarks")
+	router.GET("", middleware.DeserializeUser(bc.auth), bc.bookmarkController.FindBookmarks)
+	router.POST("/move", middleware.DeserializeUser(bc.auth), bc.bookmarkController.MoveBookmarks)
+
+	collections := rg.Group("collections")
+	collections.GET("", middleware.DeserializeUser(bc.auth), bc.bookmarkController.FindCollections)
+	collections.POST("", middleware.DeserializeUser(bc.auth), bc.bookmarkController.CreateCollection)
+	collections.PUT("/:collectionId", middleware.DeserializeUser(bc.auth), bc.bookmarkController.UpdateCollection)
+	collections.DELETE("/:collectionId", middleware.DeserializeUser(bc.auth), bc.bookmarkController.DeleteCollection)
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/middleware"
+)
+
+type ConversationRouteController struct {
+	conversationController controllers.ConversationController
+	auth                   middleware.Authenticator
+}
+
+func NewRouteConversationController(conversationController controllers.ConversationController, auth middleware.Authenticator) ConversationRouteController {
+	return ConversationRouteController{conversationController, auth}
+}
+
+func (cc *ConversationRouteController) ConversationRoute(rg *gin.RouterGroup) {
+
+	router := rg.Group("conversations")
+	router.POST("", middleware.DeserializeUser(cc.auth), cc.conversationController.CreateConversation)
+	router.GET("", middleware.DeserializeUser(cc.auth), cc.conversationController.FindConversations)
+	router.GET("/:conversationId", middleware.DeserializeUser(cc.auth), cc.conversationController.FindConversation)
+	router.GET("/:conversationId/messages", middleware.DeserializeUser(cc.auth), cc.conversationController.FindMessages)
+	router.POST("/:conversationId/messages", middleware.DeserializeUser(cc.auth), cc.conversationController.SendMessage)
+	router.POST("/:conversationId/read", middleware.DeserializeUser(cc.auth), cc.conversationController.MarkRead)
+}
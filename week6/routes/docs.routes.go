@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+)
+
+type DocsRouteController struct {
+	docsController controllers.DocsController
+}
+
+func NewRouteDocsController(docsController controllers.DocsController) DocsRouteController {
+	return DocsRouteController{docsController}
+}
+
+func (dc *DocsRouteController) DocsRoute(rg *gin.RouterGroup) {
+	rg.GET("/openapi.json", dc.docsController.OpenAPI)
+	rg.GET("/docs", dc.docsController.SwaggerUI)
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/middleware"
+)
+
+type EventRouteController struct {
+	eventController controllers.EventController
+	auth            middleware.Authenticator
+}
+
+func NewRouteEventController(eventController controllers.EventController, auth middleware.Authenticator) EventRouteController {
+	return EventRouteController{eventController, auth}
+}
+
+func (ec *EventRouteController) EventRoute(rg *gin.RouterGroup) {
+
+	router := rg.Group("events")
+	router.GET("", middleware.DeserializeUser(ec.auth), ec.eventController.StreamEvents)
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/middleware"
+	"github.com/trung/backend-engineerpro/models"
+)
+
+type ModerationRouteController struct {
+	moderationController controllers.ModerationController
+	auth                 middleware.Authenticator
+}
+
+func NewRouteModerationController(moderationController controllers.ModerationController, auth middleware.Authenticator) ModerationRouteController {
+	return ModerationRouteController{moderationController, auth}
+}
+
+func (mc *ModerationRouteController) ModerationRoute(rg *gin.RouterGroup) {
+
+	rg.POST("posts/:postId/report", middleware.DeserializeUser(mc.auth), mc.moderationController.ReportPost)
+	rg.POST("posts/:postId/comments/:commentId/report", middleware.DeserializeUser(mc.auth), mc.moderationController.ReportComment)
+	rg.POST("users/report/:userID", middleware.DeserializeUser(mc.auth), mc.moderationController.ReportUser)
+
+	router := rg.Group("admin", middleware.DeserializeUser(mc.auth), middleware.RequireRole(models.RoleAdmin))
+	router.GET("/reports", mc.moderationController.FindQueue)
+	router.GET("/reports/:targetType/:targetId", mc.moderationController.FindTargetReports)
+	router.POST("/reports/:targetType/:targetId", mc.moderationController.ResolveReports)
+	router.POST("/users/:userID/unban", mc.moderationController.UnbanUser)
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/middleware"
+)
+
+type NotificationRouteController struct {
+	notificationController controllers.NotificationController
+	auth                   middleware.Authenticator
+}
+
+func NewRouteNotificationController(notificationController controllers.NotificationController, auth middleware.Authenticator) NotificationRouteController {
+	return NotificationRouteController{notificationController, auth}
+}
+
+func (nc *NotificationRouteController) NotificationRoute(rg *gin.RouterGroup) {
+
+	router := rg.Group("notifications")
+	router.GET("", middleware.DeserializeUser(nc.auth), nc.notificationController.FindNotifications)
+	router.POST("/read", middleware.DeserializeUser(nc.auth), nc.notificationController.MarkAllRead)
+	router.POST("/:notificationId/read", middleware.DeserializeUser(nc.auth), nc.notificationController.MarkRead)
+	router.GET("/preferences", middleware.DeserializeUser(nc.auth), nc.notificationController.FindPreferences)
+	router.PUT("/preferences", middleware.DeserializeUser(nc.auth), nc.notificationController.UpdatePreferences)
+}
@@ -8,27 +8,54 @@ import (
 
 type PostRouteController struct {
 	postController controllers.PostController
+	auth           middleware.Authenticator
 }
 
-func NewRoutePostController(postController controllers.PostController) PostRouteController {
-	return PostRouteController{postController}
+func NewRoutePostController(postController controllers.PostController, auth middleware.Authenticator) PostRouteController {
+	return PostRouteController{postController, auth}
 }
 
 func (pc *PostRouteController) PostRoute(rg *gin.RouterGroup) {
 
 	router := rg.Group("posts")
-	router.POST("", middleware.DeserializeUser(), pc.postController.CreatePost)
+	router.POST("", middleware.DeserializeUser(pc.auth), pc.postController.CreatePost)
 	router.GET("", pc.postController.FindPosts)
-	router.PUT(":postId", middleware.DeserializeUser(), pc.postController.UpdatePost)
-	router.GET(":postId", pc.postController.FindPostById)
-	router.DELETE(":postId", middleware.DeserializeUser(), pc.postController.DeletePost)
+	router.GET("drafts", middleware.DeserializeUser(pc.auth), pc.postController.FindDrafts)
+	router.GET("trash", middleware.DeserializeUser(pc.auth), pc.postController.FindTrash)
+	router.GET("archive", middleware.DeserializeUser(pc.auth), pc.postController.FindArchived)
+	router.PUT(":postId", middleware.DeserializeUser(pc.auth), pc.postController.UpdatePost)
+	router.GET(":postId", middleware.OptionalUser(pc.auth), pc.postController.FindPostById)
+	router.DELETE(":postId", middleware.DeserializeUser(pc.auth), pc.postController.DeletePost)
+	router.POST(":postId/restore", middleware.DeserializeUser(pc.auth), pc.postController.RestorePost)
+	router.POST(":postId/schedule", middleware.DeserializeUser(pc.auth), pc.postController.SchedulePost)
+	router.DELETE(":postId/schedule", middleware.DeserializeUser(pc.auth), pc.postController.UnschedulePost)
+	router.POST(":postId/publish", middleware.DeserializeUser(pc.auth), pc.postController.PublishPost)
+	router.POST(":postId/archive", middleware.DeserializeUser(pc.auth), pc.postController.ArchivePost)
+	router.DELETE(":postId/archive", middleware.DeserializeUser(pc.auth), pc.postController.UnarchivePost)
+	router.GET(":postId/revisions", pc.postController.FindPostRevisions)
+	router.POST(":postId/revisions/:revisionId/restore", middleware.DeserializeUser(pc.auth), pc.postController.RestorePostRevision)
 
-	router.POST(":postId/like", middleware.DeserializeUser(), pc.postController.ToggleLike)
+	router.POST(":postId/repost", middleware.DeserializeUser(pc.auth), pc.postController.Repost)
+	router.DELETE(":postId/repost", middleware.DeserializeUser(pc.auth), pc.postController.Unrepost)
+	router.POST(":postId/quote", middleware.DeserializeUser(pc.auth), pc.postController.QuotePost)
+
+	router.POST(":postId/like", middleware.DeserializeUser(pc.auth), pc.postController.ToggleLike)
+	router.GET(":postId/reactions", pc.postController.FindPostReactions)
+	router.PUT(":postId/reactions", middleware.DeserializeUser(pc.auth), pc.postController.SetPostReaction)
+	router.DELETE(":postId/reactions", middleware.DeserializeUser(pc.auth), pc.postController.RemovePostReaction)
 
 	comments := router.Group(":postId/comments")
 	{
-		comments.POST("", middleware.DeserializeUser(), pc.postController.AddComment)
-		comments.PUT(":commentId", middleware.DeserializeUser(), pc.postController.UpdateComment)
-		comments.DELETE(":commentId", middleware.DeserializeUser(), pc.postController.DeleteComment)
+		comments.GET("", pc.postController.FindComments)
+		comments.POST("", middleware.DeserializeUser(pc.auth), pc.postController.AddComment)
+		comments.GET(":commentId/replies", pc.postController.FindReplies)
+		comments.PUT(":commentId", middleware.DeserializeUser(pc.auth), pc.postController.UpdateComment)
+		comments.DELETE(":commentId", middleware.DeserializeUser(pc.auth), pc.postController.DeleteComment)
+		comments.POST(":commentId/restore", middleware.DeserializeUser(pc.auth), pc.postController.RestoreComment)
+		comments.GET(":commentId/reactions", pc.postController.FindCommentReactions)
+		comments.PUT(":commentId/reactions", middleware.DeserializeUser(pc.auth), pc.postController.SetCommentReaction)
+		comments.DELETE(":commentId/reactions", middleware.DeserializeUser(pc.auth), pc.postController.RemoveCommentReaction)
+		comments.GET(":commentId/revisions", pc.postController.FindCommentRevisions)
+		comments.POST(":commentId/revisions/:revisionId/restore", middleware.DeserializeUser(pc.auth), pc.postController.RestoreCommentRevision)
 	}
 }
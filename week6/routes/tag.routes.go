@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+)
+
+type TagRouteController struct {
+	tagController controllers.TagController
+}
+
+func NewRouteTagController(tagController controllers.TagController) TagRouteController {
+	return TagRouteController{tagController}
+}
+
+func (tc *TagRouteController) TagRoute(rg *gin.RouterGroup) {
+
+	router := rg.Group("tags")
+	router.GET("/trending", tc.tagController.FindTrending)
+	router.GET("/:tag/posts", tc.tagController.FindTagPosts)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/trung/backend-engineerpro/controllers"
+	"github.com/trung/backend-engineerpro/middleware"
+)
+
+type UploadRouteController struct {
+	uploadController controllers.UploadController
+	auth             middleware.Authenticator
+}
+
+func NewRouteUploadController(uploadController controllers.UploadController, auth middleware.Authenticator) UploadRouteController {
+	return UploadRouteController{uploadController, auth}
+}
+
+func (uc *UploadRouteController) UploadRoute(rg *gin.RouterGroup) {
+
+	router := rg.Group("uploads")
+	router.POST("/images", middleware.DeserializeUser(uc.auth), uc.uploadController.UploadImage)
+	router.POST("/avatar", middleware.DeserializeUser(uc.auth), uc.uploadController.UploadAvatar)
+}
@@ -8,19 +8,24 @@ import (
 
 type UserRouteController struct {
 	userController controllers.UserController
+	auth           middleware.Authenticator
 }
 
-func NewRouteUserController(userController controllers.UserController) UserRouteController {
-	return UserRouteController{userController}
+func NewRouteUserController(userController controllers.UserController, auth middleware.Authenticator) UserRouteController {
+	return UserRouteController{userController, auth}
 }
 
 func (uc *UserRouteController) UserRoute(rg *gin.RouterGroup) {
 
 	router := rg.Group("users")
-	router.GET("/profile", middleware.DeserializeUser(), uc.userController.UserProfile)
-	router.GET("/profile/:userID", middleware.DeserializeUser(), uc.userController.UserProfile)
-	router.PUT("/profile", middleware.DeserializeUser(), uc.userController.UpdateUserProfile)
-	router.POST("/follow/:userID", middleware.DeserializeUser(), uc.userController.FollowUser)
-	router.DELETE("/unfollow/:userID", middleware.DeserializeUser(), uc.userController.UnfollowerUser)
-	router.GET("/newsfeeds", middleware.DeserializeUser(), uc.userController.GetNewsFeed)
+	router.GET("/profile", middleware.DeserializeUser(uc.auth), uc.userController.UserProfile)
+	router.GET("/profile/:userID", middleware.DeserializeUser(uc.auth), uc.userController.UserProfile)
+	router.PUT("/profile", middleware.DeserializeUser(uc.auth), uc.userController.UpdateUserProfile)
+	router.POST("/follow/:userID", middleware.DeserializeUser(uc.auth), uc.userController.FollowUser)
+	router.DELETE("/unfollow/:userID", middleware.DeserializeUser(uc.auth), uc.userController.UnfollowerUser)
+	router.GET("/newsfeeds", middleware.DeserializeUser(uc.auth), uc.userController.GetNewsFeed)
+	router.GET("/mentions", middleware.DeserializeUser(uc.auth), uc.userController.FindMentions)
+	router.GET("/blocks", middleware.DeserializeUser(uc.auth), uc.userController.FindBlockedUsers)
+	router.POST("/block/:userID", middleware.DeserializeUser(uc.auth), uc.userController.BlockUser)
+	router.DELETE("/unblock/:userID", middleware.DeserializeUser(uc.auth), uc.userController.UnblockUser)
 }
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/feed"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/notifications"
+	"github.com/trung/backend-engineerpro/tags"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Key for pg_try_advisory_xact_lock, any constant shared by all instances works
+const publishLockKey = 727101
+
+const batchSize = 100
+
+// Publisher turns scheduled posts into published ones once their PublishAt is due.
+type Publisher struct {
+	DB            *gorm.DB
+	Feed          *feed.Feed
+	Tags          *tags.Service
+	Notifications *notifications.Service
+}
+
+func NewPublisher(DB *gorm.DB, Feed *feed.Feed, Tags *tags.Service, Notifications *notifications.Service) *Publisher {
+	return &Publisher{DB: DB, Feed: Feed, Tags: Tags, Notifications: Notifications}
+}
+
+// PublishDue is safe to run from every server instance at the same time: only
+// the instance holding the advisory lock does the work, and the status check
+// in the UPDATE guarantees each post is published exactly once.
+func (p *Publisher) PublishDue(ctx context.Context) error {
+	var published []models.Post
+	now := time.Now()
+
+	err := p.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			var locked bool
+			if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", publishLockKey).Scan(&locked).Error; err != nil {
+				return err
+			}
+			if !locked {
+				return nil
+			}
+		}
+
+		var due []models.Post
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND publish_at <= ?", models.PostScheduled, now).
+			Order("publish_at").Limit(batchSize).Find(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(due))
+		for i, post := range due {
+			ids[i] = post.ID
+		}
+		err = tx.Model(&models.Post{}).Where("id IN ? AND status = ?", ids, models.PostScheduled).
+			Updates(map[string]interface{}{"status": models.PostPublished, "published_at": now, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, post := range due {
+			post.Status = models.PostPublished
+			post.PublishedAt = &now
+			published = append(published, post)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Fan-out and mentions happen after commit so nobody sees a post that got rolled back
+	for _, post := range published {
+		mentioned, err := p.Tags.Publish(ctx, p.DB.WithContext(ctx), post)
+		if err != nil {
+			zap.L().Warn("scheduler: tags publish failed", zap.Stringer("post_id", post.ID), zap.Error(err))
+		}
+		p.Notifications.NotifyAll(ctx, notifications.Event{
+			Type:    models.NotificationMention,
+			ActorID: post.UserID,
+			PostID:  &post.ID,
+		}, mentioned)
+		if err := p.Feed.FanOut(ctx, post); err != nil {
+			zap.L().Warn("scheduler: fan-out failed", zap.Stringer("post_id", post.ID), zap.Error(err))
+		}
+	}
+	if len(published) > 0 {
+		zap.L().Info("scheduler: published scheduled posts", zap.Int("posts", len(published)))
+	}
+	return nil
+}
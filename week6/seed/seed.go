@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/trung/backend-engineerpro/fixtures"
+	"github.com/trung/backend-engineerpro/initializers"
+)
+
+func main() {
+	var options fixtures.Options
+	flag.Int64Var(&options.Seed, "seed", 1, "the same seed gives the same data")
+	flag.IntVar(&options.Users, "users", 50, "number of users")
+	flag.IntVar(&options.FollowsPerUser, "follows", 10, "average follows per user, 0 for none")
+	flag.IntVar(&options.PostsPerUser, "posts", 5, "average posts per user, 0 for none")
+	flag.IntVar(&options.CommentsPerPost, "comments", 3, "average comments per post, 0 for none")
+	flag.IntVar(&options.LikesPerPost, "likes", 5, "average likes per post, 0 for none")
+	flag.IntVar(&options.Days, "days", 30, "spread the data over this many days")
+	now := flag.String("now", fixtures.DefaultNow.Format("2006-01-02"), "the data ends at this date (YYYY-MM-DD or RFC 3339), \"now\" for the current time")
+	wipe := flag.Bool("wipe", false, "delete all existing data first")
+	flag.Parse()
+
+	var err error
+	if options.Now, err = parseNow(*now); err != nil {
+		log.Fatal("Invalid -now: ", err)
+	}
+
+	config, err := initializers.LoadConfig(".")
+	if err != nil {
+		log.Fatal("Could not load environment variables: ", err)
+	}
+	defer initializers.SetupLogger(&config).Sync()
+	initializers.ConnectDB(&config)
+
+	if *wipe {
+		if err := fixtures.Wipe(initializers.DB); err != nil {
+			log.Fatal("Could not wipe the database: ", err)
+		}
+		fmt.Println("🧹 Wiped all data")
+	}
+
+	set, err := fixtures.Generate(initializers.DB, options)
+	if err != nil {
+		log.Fatal("Could not seed the database: ", err)
+	}
+	fmt.Printf("🌱 Seeded %d users, %d follows, %d posts, %d comments and %d reactions\n",
+		len(set.Users), len(set.Follows), len(set.Posts), len(set.Comments), len(set.Reactions))
+	fmt.Printf("Log in as %s or any other user with the password %q\n", set.Users[0].Email, fixtures.Password)
+}
+
+func parseNow(value string) (time.Time, error) {
+	if value == "now" {
+		return time.Now().Truncate(time.Minute), nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/metrics"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/utils"
+)
+
+var (
+	ErrPasswordMismatch   = errors.New("Passwords do not match")
+	ErrInvalidUsername    = errors.New("Username must be 3-30 letters, digits or underscores")
+	ErrUsernameTaken      = errors.New("That username is already taken")
+	ErrEmailTaken         = errors.New("User with that email already exists")
+	ErrInvalidCredentials = errors.New("Invalid email or Password")
+	ErrSuspended          = errors.New("Your account has been suspended")
+	ErrUserGone           = errors.New("the user belonging to this token no logger exists")
+)
+
+type Tokens struct {
+	Access  string
+	Refresh string
+}
+
+// AuthService signs users up and in and turns tokens back into users
+type AuthService struct {
+	Users  repository.UserRepository
+	Config initializers.Config
+}
+
+func NewAuthService(Users repository.UserRepository, Config initializers.Config) *AuthService {
+	return &AuthService{Users: Users, Config: Config}
+}
+
+func (s *AuthService) SignUp(ctx context.Context, input models.SignUpInput) (models.User, error) {
+	if input.Password != input.PasswordConfirm {
+		return models.User{}, ErrPasswordMismatch
+	}
+
+	username := strings.ToLower(input.Username)
+	if username != "" && !utils.ValidUsername(username) {
+		return models.User{}, ErrInvalidUsername
+	}
+
+	hashedPassword, err := utils.HashPassword(input.Password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	now := time.Now()
+	user := models.User{
+		Name:         input.Name,
+		Username:     username,
+		Email:        strings.ToLower(input.Email),
+		Password:     hashedPassword,
+		Role:         models.RoleUser,
+		Verified:     true,
+		ProfileImage: input.ProfileImage,
+		Age:          input.Age,
+		Provider:     "local",
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.Users.Create(ctx, &user); err != nil {
+		return user, takenError(err)
+	}
+	metrics.Signups.Inc()
+	return user, nil
+}
+
+func (s *AuthService) SignIn(ctx context.Context, input models.SignInInput) (Tokens, error) {
+	user, err := s.Users.FindByEmail(ctx, strings.ToLower(input.Email))
+	if err != nil {
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return Tokens{}, ErrInvalidCredentials
+	}
+	if err := utils.VerifyPassword(user.Password, input.Password); err != nil {
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return Tokens{}, ErrInvalidCredentials
+	}
+	if user.BannedAt != nil {
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return Tokens{}, ErrSuspended
+	}
+
+	access, err := utils.CreateToken(s.Config.AccessTokenExpiresIn, user.ID, s.Config.AccessTokenPrivateKey)
+	if err != nil {
+		return Tokens{}, err
+	}
+	refresh, err := utils.CreateToken(s.Config.RefreshTokenExpiresIn, user.ID, s.Config.RefreshTokenPrivateKey)
+	if err != nil {
+		return Tokens{}, err
+	}
+	metrics.Logins.WithLabelValues("success").Inc()
+	return Tokens{Access: access, Refresh: refresh}, nil
+}
+
+// Refresh returns a new access token for a refresh token
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (string, error) {
+	user, err := s.userFromToken(ctx, refreshToken, s.Config.RefreshTokenPublicKey)
+	if err != nil {
+		return "", err
+	}
+	return utils.CreateToken(s.Config.AccessTokenExpiresIn, user.ID, s.Config.AccessTokenPrivateKey)
+}
+
+// Authenticate returns the user an access token belongs to. An invalid token
+// gives the validation error, ErrUserGone and ErrSuspended mean the token is
+// fine but its user may not use it.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (models.User, error) {
+	return s.userFromToken(ctx, accessToken, s.Config.AccessTokenPublicKey)
+}
+
+func (s *AuthService) userFromToken(ctx context.Context, token, publicKey string) (models.User, error) {
+	sub, err := utils.ValidateToken(token, publicKey)
+	if err != nil {
+		return models.User{}, err
+	}
+	id, err := uuid.Parse(fmt.Sprint(sub))
+	if err != nil {
+		return models.User{}, ErrUserGone
+	}
+
+	user, err := s.Users.FindByID(ctx, id)
+	if err != nil {
+		return user, ErrUserGone
+	}
+	if user.BannedAt != nil {
+		return user, ErrSuspended
+	}
+	return user, nil
+}
+
+// takenError turns a unique violation on users into ErrUsernameTaken or ErrEmailTaken
+func takenError(err error) error {
+	var conflict *repository.ConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+	if conflict.Field == "username" {
+		return ErrUsernameTaken
+	}
+	return ErrEmailTaken
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository/memory"
+)
+
+func newAuthService(t *testing.T) (*AuthService, *memory.Store) {
+	t.Helper()
+	config, err := initializers.LoadConfig("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memory.NewStore()
+	return NewAuthService(store.Users(), config), store
+}
+
+func signUpInput(name string) models.SignUpInput {
+	return models.SignUpInput{
+		Name:            name,
+		Username:        name,
+		Age:             30,
+		Email:           name + "@example.com",
+		Password:        "password123",
+		PasswordConfirm: "password123",
+	}
+}
+
+func TestSignUp(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := newAuthService(t)
+
+	input := signUpInput("Alice")
+	input.Email = "Alice@Example.com"
+	user, err := auth.SignUp(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "alice@example.com" || user.Username != "alice" || user.Role != models.RoleUser {
+		t.Errorf("signed up %+v", user)
+	}
+	if user.Password == input.Password {
+		t.Error("password stored in plain text")
+	}
+
+	tests := []struct {
+		name   string
+		change func(*models.SignUpInput)
+		err    error
+	}{
+		{"password mismatch", func(in *models.SignUpInput) { in.PasswordConfirm = "password456" }, ErrPasswordMismatch},
+		{"invalid username", func(in *models.SignUpInput) { in.Username = "no spaces" }, ErrInvalidUsername},
+		{"email taken", func(in *models.SignUpInput) { in.Email = "ALICE@example.com" }, ErrEmailTaken},
+		{"username taken", func(in *models.SignUpInput) { in.Username = "ALICE" }, ErrUsernameTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := signUpInput("bob")
+			tt.change(&input)
+			if _, err := auth.SignUp(ctx, input); !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSignIn(t *testing.T) {
+	ctx := context.Background()
+	auth, store := newAuthService(t)
+	user, err := auth.SignUp(ctx, signUpInput("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := auth.SignIn(ctx, models.SignInInput{Email: "alice@example.com", Password: "wrong-password"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: %v", err)
+	}
+	if _, err := auth.SignIn(ctx, models.SignInInput{Email: "nobody@example.com", Password: "password123"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown email: %v", err)
+	}
+
+	tokens, err := auth.SignIn(ctx, models.SignInInput{Email: "ALICE@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := auth.Authenticate(ctx, tokens.Access); err != nil || found.ID != user.ID {
+		t.Errorf("access token belongs to %v, %v", found.ID, err)
+	}
+	if _, err := auth.Authenticate(ctx, tokens.Refresh); err == nil {
+		t.Error("refresh token accepted as access token")
+	}
+	access, err := auth.Refresh(ctx, tokens.Refresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := auth.Authenticate(ctx, access); err != nil || found.ID != user.ID {
+		t.Errorf("refreshed token belongs to %v, %v", found.ID, err)
+	}
+
+	// A ban locks out new sign ins and the tokens already handed out
+	now := time.Now()
+	user.BannedAt = &now
+	if err := store.Users().Update(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.SignIn(ctx, models.SignInInput{Email: "alice@example.com", Password: "password123"}); !errors.Is(err, ErrSuspended) {
+		t.Errorf("banned sign in: %v", err)
+	}
+	if _, err := auth.Authenticate(ctx, tokens.Access); !errors.Is(err, ErrSuspended) {
+		t.Errorf("banned token: %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/counters"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/notifications"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/utils"
+)
+
+var (
+	ErrCommentNotFound    = errors.New("Comment not exists")
+	ErrParentNotFound     = errors.New("Parent comment not found")
+	ErrReplyTooDeep       = fmt.Errorf("Replies cannot be nested more than %d levels deep", models.MaxCommentDepth)
+	ErrCommentNotInTrash  = errors.New("No deleted comment with that id in your trash")
+	ErrRestoreParentFirst = errors.New("Restore the comment this one replies to first")
+)
+
+// CommentService manages the comment threads below posts
+type CommentService struct {
+	Posts         repository.PostRepository
+	Comments      repository.CommentRepository
+	Tx            repository.Transactor
+	Tags          TagIndexer
+	Notifications Notifier
+	Moderation    Screener
+	Counters      CounterStore
+	Trash         Trash
+}
+
+func NewCommentService(Posts repository.PostRepository, Comments repository.CommentRepository, Tx repository.Transactor, Tags TagIndexer, Notifications Notifier, Moderation Screener, Counters CounterStore, Trash Trash) *CommentService {
+	return &CommentService{
+		Posts:         Posts,
+		Comments:      Comments,
+		Tx:            Tx,
+		Tags:          Tags,
+		Notifications: Notifications,
+		Moderation:    Moderation,
+		Counters:      Counters,
+		Trash:         Trash,
+	}
+}
+
+// Add comments on a post, or replies to one of its comments when input has a parent
+func (s *CommentService) Add(ctx context.Context, authorID, postID uuid.UUID, input models.CreateComment) (models.Comment, error) {
+	now := time.Now()
+	comment := models.Comment{
+		PostID:    postID,
+		UserID:    authorID,
+		Content:   input.Content,
+		CreateAt:  now,
+		UpdatedAt: now,
+	}
+
+	post, err := s.Posts.FindByID(ctx, postID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return comment, ErrPostNotFound
+	} else if err != nil {
+		return comment, err
+	}
+
+	// Replies hang off a comment of the same post
+	var parent *models.Comment
+	if input.ParentID != "" {
+		found, err := s.Find(ctx, postID, input.ParentID)
+		if errors.Is(err, ErrCommentNotFound) {
+			return comment, ErrParentNotFound
+		} else if err != nil {
+			return comment, err
+		}
+		if found.Depth >= models.MaxCommentDepth {
+			return comment, ErrReplyTooDeep
+		}
+		parent = &found
+		comment.ParentID = &found.ID
+		comment.Depth = found.Depth + 1
+	}
+
+	var mentioned []uuid.UUID
+	var trending []string
+	err = s.Tx.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		if err := s.Comments.Create(ctx, &comment); err != nil {
+			return err
+		}
+		mentioned, trending, err = s.syncTags(ctx, comment, "")
+		return err
+	})
+	if err != nil {
+		return comment, err
+	}
+	s.bumpTrending(ctx, trending)
+	s.incrComments(ctx, postID, 1)
+
+	// The author of the replied-to comment hears about a reply, the post author about a new comment
+	if s.Notifications != nil {
+		if parent != nil {
+			s.Notifications.Notify(ctx, notifications.Event{
+				Type:        models.NotificationReply,
+				RecipientID: parent.UserID,
+				ActorID:     authorID,
+				PostID:      &post.ID,
+				CommentID:   &parent.ID,
+			})
+		}
+		if parent == nil || parent.UserID != post.UserID {
+			s.Notifications.Notify(ctx, notifications.Event{
+				Type:        models.NotificationComment,
+				RecipientID: post.UserID,
+				ActorID:     authorID,
+				PostID:      &post.ID,
+			})
+		}
+	}
+	s.notifyMentions(ctx, comment, mentioned)
+	s.screen(ctx, comment)
+	return comment, nil
+}
+
+// Update edits the author's comment, the previous text is kept as a revision
+func (s *CommentService) Update(ctx context.Context, authorID, postID uuid.UUID, commentID, content string) (models.Comment, error) {
+	comment, err := s.Find(ctx, postID, commentID)
+	if err != nil {
+		return comment, err
+	}
+	if comment.UserID != authorID {
+		return models.Comment{}, ErrCommentNotFound
+	}
+
+	return s.edit(ctx, comment, content)
+}
+
+// Revisions lists the earlier versions of the comment, newest first, each
+// with the diff to the version that replaced it
+func (s *CommentService) Revisions(ctx context.Context, comment models.Comment) ([]models.CommentRevision, error) {
+	revisions, err := s.Comments.Revisions(ctx, comment.ID)
+	if err != nil {
+		return nil, err
+	}
+	next := comment.Content
+	for i := range revisions {
+		revisions[i].Diff = utils.DiffLines(revisions[i].Content, next)
+		next = revisions[i].Content
+	}
+	return revisions, nil
+}
+
+// RestoreRevision brings back an earlier version of the author's comment, the
+// replaced text becomes a revision itself
+func (s *CommentService) RestoreRevision(ctx context.Context, authorID uuid.UUID, comment models.Comment, revisionID string) (models.Comment, error) {
+	if comment.UserID != authorID {
+		return models.Comment{}, ErrCommentNotFound
+	}
+	if _, err := uuid.Parse(revisionID); err != nil {
+		return comment, ErrRevisionNotFound
+	}
+	revision, err := s.Comments.FindRevision(ctx, comment.ID, revisionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return comment, ErrRevisionNotFound
+	} else if err != nil {
+		return comment, err
+	}
+	return s.edit(ctx, comment, revision.Content)
+}
+
+// edit replaces the comment's content, syncing its tags in the same
+// transaction, then notifies the new mentions and screens the text
+func (s *CommentService) edit(ctx context.Context, comment models.Comment, content string) (models.Comment, error) {
+	previousContent := comment.Content
+	var mentioned []uuid.UUID
+	var trending []string
+	err := s.Tx.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		if err := s.Comments.Edit(ctx, &comment, content); err != nil {
+			return err
+		}
+		mentioned, trending, err = s.syncTags(ctx, comment, previousContent)
+		return err
+	})
+	if err != nil {
+		return comment, err
+	}
+	s.bumpTrending(ctx, trending)
+	s.notifyMentions(ctx, comment, mentioned)
+	s.screen(ctx, comment)
+	return comment, nil
+}
+
+// Delete moves the author's comment and the replies below it to the trash
+func (s *CommentService) Delete(ctx context.Context, authorID, postID uuid.UUID, commentID string) error {
+	if _, err := uuid.Parse(commentID); err != nil {
+		return ErrCommentNotFound
+	}
+
+	var deleted int64
+	err := s.Tx.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		deleted, err = s.Comments.Delete(ctx, authorID, postID, commentID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrCommentNotFound
+	}
+	s.incrComments(ctx, postID, -deleted)
+	return nil
+}
+
+// Trashed lists the user's deleted comments that can still be restored
+func (s *CommentService) Trashed(ctx context.Context, userID uuid.UUID) ([]models.Comment, error) {
+	return s.Comments.ListTrashed(ctx, userID, s.Trash.Cutoff())
+}
+
+// Restore brings back the user's deleted comment along with the replies
+// deleted with it. The post and the comment it replies to have to be restored first.
+func (s *CommentService) Restore(ctx context.Context, userID, postID uuid.UUID, commentID string) (models.Comment, error) {
+	if _, err := s.Posts.FindByID(ctx, postID); errors.Is(err, repository.ErrNotFound) {
+		return models.Comment{}, ErrPostNotFound
+	} else if err != nil {
+		return models.Comment{}, err
+	}
+
+	comment, err := s.Comments.FindTrashed(ctx, userID, postID, commentID, s.Trash.Cutoff())
+	if errors.Is(err, repository.ErrNotFound) {
+		return comment, ErrCommentNotInTrash
+	} else if err != nil {
+		return comment, err
+	}
+	if comment.ParentID != nil {
+		if _, err := s.Comments.FindByID(ctx, postID, *comment.ParentID); errors.Is(err, repository.ErrNotFound) {
+			return comment, ErrRestoreParentFirst
+		} else if err != nil {
+			return comment, err
+		}
+	}
+
+	var restored int64
+	err = s.Tx.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		restored, err = s.Comments.Restore(ctx, comment)
+		return err
+	})
+	if err != nil {
+		return comment, err
+	}
+	s.incrComments(ctx, postID, restored)
+	return s.Comments.FindByID(ctx, postID, comment.ID)
+}
+
+// Find returns a comment of the post
+func (s *CommentService) Find(ctx context.Context, postID uuid.UUID, commentID string) (models.Comment, error) {
+	if _, err := uuid.Parse(commentID); err != nil {
+		return models.Comment{}, ErrCommentNotFound
+	}
+	comment, err := s.Comments.FindByID(ctx, postID, commentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return comment, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+// List returns a page of the post's top-level comments, or of the replies to
+// parentID, with their reply and reaction counts
+func (s *CommentService) List(ctx context.Context, postID uuid.UUID, parentID string, after *repository.Cursor, limit int) ([]models.CommentResponse, error) {
+	var parent *string
+	if parentID != "" {
+		comment, err := s.Find(ctx, postID, parentID)
+		if err != nil {
+			return nil, err
+		}
+		parent = &comment.ID
+	} else if _, err := s.Posts.FindByID(ctx, postID); errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPostNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.Comments.List(ctx, postID, parent, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	replyCounts, err := s.Comments.ReplyCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := s.Comments.ReactionCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]models.CommentResponse, len(comments))
+	for i, comment := range comments {
+		data[i] = models.CommentResponse{Comment: comment, ReplyCount: replyCounts[comment.ID], ReactionCounts: reactions[comment.ID]}
+		if data[i].ReactionCounts == nil {
+			data[i].ReactionCounts = map[string]int64{}
+		}
+	}
+	return data, nil
+}
+
+func (s *CommentService) syncTags(ctx context.Context, comment models.Comment, previousContent string) ([]uuid.UUID, []string, error) {
+	if s.Tags == nil {
+		return nil, nil, nil
+	}
+	return s.Tags.SyncComment(ctx, comment, previousContent)
+}
+
+// bumpTrending must run after the transaction that synced the tags committed
+func (s *CommentService) bumpTrending(ctx context.Context, names []string) {
+	if s.Tags != nil {
+		s.Tags.BumpTrending(ctx, names)
+	}
+}
+
+func (s *CommentService) incrComments(ctx context.Context, postID uuid.UUID, delta int64) {
+	if s.Counters != nil {
+		s.Counters.Incr(ctx, postID, counters.Comments, delta)
+	}
+}
+
+// notifyMentions tells the users newly @mentioned in a comment
+func (s *CommentService) notifyMentions(ctx context.Context, comment models.Comment, mentioned []uuid.UUID) {
+	if len(mentioned) == 0 || s.Notifications == nil {
+		return
+	}
+	s.Notifications.NotifyAll(ctx, notifications.Event{
+		Type:      models.NotificationMention,
+		ActorID:   comment.UserID,
+		PostID:    &comment.PostID,
+		CommentID: &comment.ID,
+	}, mentioned)
+}
+
+// screen runs a new or edited comment through the moderation filter
+func (s *CommentService) screen(ctx context.Context, comment models.Comment) {
+	if s.Moderation != nil {
+		s.Moderation.Screen(ctx, models.ReportTargetComment, comment.ID, comment.UserID, comment.Content)
+	}
+}
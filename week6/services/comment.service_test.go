@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/counters"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/repository/memory"
+)
+
+// counted stands in for the counter store and sums the deltas per post and field
+type counted map[uuid.UUID]map[string]int64
+
+func (c counted) Incr(ctx context.Context, postID uuid.UUID, field string, delta int64) {
+	if c[postID] == nil {
+		c[postID] = map[string]int64{}
+	}
+	c[postID][field] += delta
+}
+
+func newCommentService(t *testing.T) (*CommentService, *memory.Store, *recorder, counted) {
+	t.Helper()
+	store := memory.NewStore()
+	rec := &recorder{}
+	counts := counted{}
+	return NewCommentService(store.Posts(), store.Comments(), store.Transactor(), nil, rec, rec, counts, store.Purger(time.Hour)), store, rec, counts
+}
+
+func createPost(t *testing.T, store *memory.Store, authorID uuid.UUID) models.Post {
+	t.Helper()
+	post := models.Post{Title: uuid.NewString(), Content: "content", UserID: authorID}
+	if err := store.Posts().Create(context.Background(), &post); err != nil {
+		t.Fatal(err)
+	}
+	return post
+}
+
+func TestAddComment(t *testing.T) {
+	ctx := context.Background()
+	comments, store, rec, counts := newCommentService(t)
+	author, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	post := createPost(t, store, author)
+
+	if _, err := comments.Add(ctx, alice, uuid.New(), models.CreateComment{Content: "hi"}); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("missing post: %v", err)
+	}
+
+	comment, err := comments.Add(ctx, alice, post.ID, models.CreateComment{Content: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := comments.Add(ctx, bob, post.ID, models.CreateComment{Content: "reply", ParentID: comment.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Depth != 1 || reply.ParentID == nil || *reply.ParentID != comment.ID {
+		t.Errorf("reply %+v", reply)
+	}
+	if counts[post.ID][counters.Comments] != 2 {
+		t.Errorf("comments counted %v", counts[post.ID])
+	}
+
+	// The post author hears about both, alice about the reply to her comment
+	var got []string
+	for _, event := range rec.events {
+		got = append(got, event.Type+" to "+event.RecipientID.String())
+	}
+	want := []string{
+		models.NotificationComment + " to " + author.String(),
+		models.NotificationReply + " to " + alice.String(),
+		models.NotificationComment + " to " + author.String(),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("notified %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("notification %d is %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	if _, err := comments.Add(ctx, bob, post.ID, models.CreateComment{Content: "reply", ParentID: uuid.NewString()}); !errors.Is(err, ErrParentNotFound) {
+		t.Errorf("missing parent: %v", err)
+	}
+	parent := reply
+	for depth := reply.Depth; depth < models.MaxCommentDepth; depth++ {
+		if parent, err = comments.Add(ctx, bob, post.ID, models.CreateComment{Content: "deeper", ParentID: parent.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := comments.Add(ctx, bob, post.ID, models.CreateComment{Content: "too deep", ParentID: parent.ID}); !errors.Is(err, ErrReplyTooDeep) {
+		t.Errorf("reply too deep: %v", err)
+	}
+}
+
+func TestUpdateAndDeleteComment(t *testing.T) {
+	ctx := context.Background()
+	comments, store, _, counts := newCommentService(t)
+	alice, bob := uuid.New(), uuid.New()
+	post := createPost(t, store, alice)
+	comment, err := comments.Add(ctx, alice, post.ID, models.CreateComment{Content: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := comments.Add(ctx, bob, post.ID, models.CreateComment{Content: "reply", ParentID: comment.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := comments.Update(ctx, bob, post.ID, comment.ID, "stolen"); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("someone else's comment: %v", err)
+	}
+	updated, err := comments.Update(ctx, alice, post.ID, comment.ID, "edited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Content != "edited" || !updated.Edited {
+		t.Errorf("updated %+v", updated)
+	}
+
+	if err := comments.Delete(ctx, bob, post.ID, comment.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("delete someone else's comment: %v", err)
+	}
+	if err := comments.Delete(ctx, alice, post.ID, "not-a-uuid"); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("delete malformed id: %v", err)
+	}
+
+	// Deleting a comment takes its replies along
+	if err := comments.Delete(ctx, alice, post.ID, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.Find(ctx, post.ID, reply.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("reply still there: %v", err)
+	}
+	if counts[post.ID][counters.Comments] != 0 {
+		t.Errorf("comments counted %v", counts[post.ID])
+	}
+}
+
+func TestCommentRevisions(t *testing.T) {
+	ctx := context.Background()
+	comments, store, rec, _ := newCommentService(t)
+	alice, bob := uuid.New(), uuid.New()
+	post := createPost(t, store, alice)
+	comment, err := comments.Add(ctx, alice, post.ID, models.CreateComment{Content: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment, err = comments.Update(ctx, alice, post.ID, comment.ID, "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := comments.Revisions(ctx, comment)
+	if err != nil || len(revisions) != 1 || revisions[0].Content != "first" || revisions[0].Version != 1 {
+		t.Fatalf("revisions %+v, %v", revisions, err)
+	}
+
+	if _, err := comments.RestoreRevision(ctx, bob, comment, revisions[0].ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("someone else's comment: %v", err)
+	}
+	if _, err := comments.RestoreRevision(ctx, alice, comment, uuid.NewString()); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("missing revision: %v", err)
+	}
+
+	screened := len(rec.screened)
+	restored, err := comments.RestoreRevision(ctx, alice, comment, revisions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Content != "first" || len(rec.screened) != screened+1 {
+		t.Errorf("restored %+v, screened %q", restored, rec.screened)
+	}
+	if revisions, err := comments.Revisions(ctx, restored); err != nil || len(revisions) != 2 || revisions[0].Content != "second" {
+		t.Errorf("revisions after restore %+v, %v", revisions, err)
+	}
+}
+
+func TestListComments(t *testing.T) {
+	ctx := context.Background()
+	comments, store, _, _ := newCommentService(t)
+	alice := uuid.New()
+	post := createPost(t, store, alice)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		comment, err := comments.Add(ctx, alice, post.ID, models.CreateComment{Content: "comment"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, comment.ID)
+	}
+	if _, err := comments.Add(ctx, alice, post.ID, models.CreateComment{Content: "reply", ParentID: ids[0]}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reactions().SetCommentReaction(ctx, &models.CommentReaction{CommentID: ids[0], UserID: alice, Type: models.ReactionLove}); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := comments.List(ctx, post.ID, "", nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].ID != ids[0] || page[1].ID != ids[1] {
+		t.Fatalf("first page %+v", page)
+	}
+	if page[0].ReplyCount != 1 || page[0].ReactionCounts[models.ReactionLove] != 1 || page[1].ReactionCounts == nil {
+		t.Errorf("counts %+v", page[0])
+	}
+
+	last := page[1]
+	page, err = comments.List(ctx, post.ID, "", &repository.Cursor{CreatedAt: last.CreateAt, ID: last.ID}, 2)
+	if err != nil || len(page) != 1 || page[0].ID != ids[2] {
+		t.Errorf("second page %+v, %v", page, err)
+	}
+
+	replies, err := comments.List(ctx, post.ID, ids[0], nil, 10)
+	if err != nil || len(replies) != 1 || replies[0].Content != "reply" {
+		t.Errorf("replies %+v, %v", replies, err)
+	}
+	if _, err := comments.List(ctx, uuid.New(), "", nil, 10); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("missing post: %v", err)
+	}
+	if _, err := comments.List(ctx, post.ID, uuid.NewString(), nil, 10); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("missing parent: %v", err)
+	}
+}
+
+func TestRestoreComment(t *testing.T) {
+	ctx := context.Background()
+	comments, store, _, counts := newCommentService(t)
+	alice, bob := uuid.New(), uuid.New()
+	post := createPost(t, store, alice)
+	comment, err := comments.Add(ctx, alice, post.ID, models.CreateComment{Content: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := comments.Add(ctx, bob, post.ID, models.CreateComment{Content: "reply", ParentID: comment.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := comments.Delete(ctx, alice, post.ID, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := comments.Restore(ctx, alice, post.ID, reply.ID); !errors.Is(err, ErrCommentNotInTrash) {
+		t.Errorf("someone else's reply: %v", err)
+	}
+	if _, err := comments.Restore(ctx, bob, post.ID, reply.ID); !errors.Is(err, ErrRestoreParentFirst) {
+		t.Errorf("reply without its parent: %v", err)
+	}
+	trashed, err := comments.Trashed(ctx, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != comment.ID {
+		t.Errorf("trash %+v", trashed)
+	}
+
+	// The reply comes back with the comment it was deleted with
+	restored, err := comments.Restore(ctx, alice, post.ID, comment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID != comment.ID {
+		t.Errorf("restored %+v", restored)
+	}
+	if _, err := comments.Find(ctx, post.ID, reply.ID); err != nil {
+		t.Errorf("reply not restored: %v", err)
+	}
+	if counts[post.ID][counters.Comments] != 2 {
+		t.Errorf("comments counted %v", counts[post.ID])
+	}
+	if _, err := comments.Restore(ctx, alice, post.ID, comment.ID); !errors.Is(err, ErrCommentNotInTrash) {
+		t.Errorf("restored twice: %v", err)
+	}
+
+	if _, err := comments.Restore(ctx, alice, uuid.New(), reply.ID); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("missing post: %v", err)
+	}
+}
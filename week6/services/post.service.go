@@ -0,0 +1,670 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/counters"
+	"github.com/trung/backend-engineerpro/logging"
+	"github.com/trung/backend-engineerpro/metrics"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/notifications"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPostNotFound        = errors.New("No post with that title exists")
+	ErrTitleTaken          = errors.New("Post with that title already exists")
+	ErrScheduleNeedsFuture = errors.New("publish_at must be in the future for a scheduled post")
+	ErrPublishAtInPast     = errors.New("publish_at must be in the future")
+	ErrRepostNotEditable   = errors.New("A repost has no content of its own to edit")
+	ErrAlreadyReposted     = errors.New("You already reposted this post")
+	ErrOriginalUnavailable = errors.New("The reposted post is no longer available")
+	ErrShareBlocked        = errors.New("You cannot share this post")
+	ErrNotReposted         = errors.New("You have not reposted this post")
+	ErrRevisionNotFound    = errors.New("Revision not found")
+	ErrNotInTrash          = errors.New("No deleted post with that id in your trash")
+	ErrRestoreConflict     = errors.New("The post conflicts with one you published since")
+)
+
+// StatusConflictError means the post is not in a state the change applies to,
+// e.g. publishing a post that was published already
+type StatusConflictError struct {
+	Status string
+}
+
+func (e *StatusConflictError) Error() string {
+	return fmt.Sprintf("Post is %s and cannot be changed that way", e.Status)
+}
+
+// PostService runs the lifecycle of a post: writing, editing, scheduling,
+// publishing and trashing it, together with the tags, feeds, notifications
+// and moderation that follow each step
+type PostService struct {
+	Posts         repository.PostRepository
+	Blocks        repository.BlockRepository
+	Tx            repository.Transactor
+	Tags          TagIndexer
+	Feed          FeedUpdater
+	Notifications Notifier
+	Moderation    Screener
+	Counters      CounterStore
+	Trash         Trash
+}
+
+func NewPostService(Posts repository.PostRepository, Blocks repository.BlockRepository, Tx repository.Transactor, Tags TagIndexer, Feed FeedUpdater, Notifications Notifier, Moderation Screener, Counters CounterStore, Trash Trash) *PostService {
+	return &PostService{
+		Posts:         Posts,
+		Blocks:        Blocks,
+		Tx:            Tx,
+		Tags:          Tags,
+		Feed:          Feed,
+		Notifications: Notifications,
+		Moderation:    Moderation,
+		Counters:      Counters,
+		Trash:         Trash,
+	}
+}
+
+func (s *PostService) Create(ctx context.Context, authorID uuid.UUID, input models.CreatePostRequest) (models.Post, error) {
+	now := time.Now()
+	post := models.Post{
+		Title:     input.Title,
+		Content:   input.Content,
+		Image:     input.Image,
+		UserID:    authorID,
+		Status:    input.Status,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	switch post.Status {
+	case models.PostDraft:
+	case models.PostScheduled:
+		if input.PublishAt == nil || !input.PublishAt.After(now) {
+			return post, ErrScheduleNeedsFuture
+		}
+		post.PublishAt = input.PublishAt
+	default:
+		post.Status = models.PostPublished
+		post.PublishedAt = &now
+	}
+
+	var mentioned []uuid.UUID
+	var trending []string
+	err := s.Tx.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		if err := s.Posts.Create(ctx, &post); err != nil {
+			return err
+		}
+		mentioned, trending, err = s.syncTags(ctx, post)
+		return err
+	})
+	if err != nil {
+		return post, titleError(err)
+	}
+	metrics.PostsCreated.WithLabelValues(post.Status).Inc()
+	s.bumpTrending(ctx, trending)
+
+	if post.Status == models.PostPublished {
+		s.fanOut(ctx, post)
+	}
+	s.notifyMentions(ctx, post, mentioned)
+	s.screen(ctx, post)
+	return post, nil
+}
+
+// Update edits the author's post, the previous text is kept as a revision
+func (s *PostService) Update(ctx context.Context, authorID, postID uuid.UUID, input models.UpdatePost) (models.Post, error) {
+	post, err := s.findOwned(ctx, authorID, postID)
+	if err != nil {
+		return post, err
+	}
+	if post.RepostOfID != nil {
+		return post, ErrRepostNotEditable
+	}
+
+	changes := map[string]string{}
+	if input.Title != "" {
+		changes["title"] = input.Title
+	}
+	if input.Content != "" {
+		changes["content"] = input.Content
+	}
+	if input.Image != "" {
+		changes["image"] = input.Image
+	}
+	return s.edit(ctx, post, changes)
+}
+
+// Revisions lists the earlier versions of a published post, newest first,
+// each with the diff to the version that replaced it
+func (s *PostService) Revisions(ctx context.Context, postID uuid.UUID) ([]models.PostRevision, error) {
+	post, err := s.Posts.FindByID(ctx, postID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && post.Status != models.PostPublished) {
+		return nil, ErrPostNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.Posts.Revisions(ctx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	next := post.Content
+	for i := range revisions {
+		revisions[i].Diff = utils.DiffLines(revisions[i].Content, next)
+		next = revisions[i].Content
+	}
+	return revisions, nil
+}
+
+// RestoreRevision brings back an earlier version of the author's post, the
+// replaced text becomes a revision itself
+func (s *PostService) RestoreRevision(ctx context.Context, authorID, postID uuid.UUID, revisionID string) (models.Post, error) {
+	post, err := s.findOwned(ctx, authorID, postID)
+	if err != nil {
+		return post, err
+	}
+	if _, err := uuid.Parse(revisionID); err != nil {
+		return post, ErrRevisionNotFound
+	}
+	revision, err := s.Posts.FindRevision(ctx, post.ID, revisionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return post, ErrRevisionNotFound
+	} else if err != nil {
+		return post, err
+	}
+	return s.edit(ctx, post, map[string]string{"title": revision.Title, "content": revision.Content, "image": revision.Image})
+}
+
+// edit applies changes to the post the way every edit goes: the tags are
+// synced in the same transaction, then the new mentions are notified and
+// the text is screened
+func (s *PostService) edit(ctx context.Context, post models.Post, changes map[string]string) (models.Post, error) {
+	var mentioned []uuid.UUID
+	var trending []string
+	err := s.Tx.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		if err := s.Posts.Edit(ctx, &post, changes); err != nil {
+			return err
+		}
+		mentioned, trending, err = s.syncTags(ctx, post)
+		return err
+	})
+	if err != nil {
+		return post, titleError(err)
+	}
+	s.bumpTrending(ctx, trending)
+	s.notifyMentions(ctx, post, mentioned)
+	s.screen(ctx, post)
+	return post, nil
+}
+
+// Get returns a published post with its reactions and the post it shares.
+// When viewerID is its author it also says how often the post was saved.
+func (s *PostService) Get(ctx context.Context, postID uuid.UUID, viewerID *uuid.UUID) (models.Post, error) {
+	post, err := s.Posts.FindByID(ctx, postID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && post.Status != models.PostPublished) {
+		return models.Post{}, ErrPostNotFound
+	} else if err != nil {
+		return post, err
+	}
+
+	posts := []models.Post{post}
+	if err := s.attachReactionCounts(ctx, posts); err != nil {
+		return post, err
+	}
+	if err := s.AttachOriginals(ctx, posts); err != nil {
+		return post, err
+	}
+	post = posts[0]
+
+	if viewerID != nil && *viewerID == post.UserID {
+		bookmarks, err := s.Posts.CountBookmarks(ctx, post.ID)
+		if err != nil {
+			return post, err
+		}
+		post.BookmarksCount = &bookmarks
+	}
+	return post, nil
+}
+
+// List returns a page of published posts
+func (s *PostService) List(ctx context.Context, page, limit int) ([]models.Post, error) {
+	posts, err := s.Posts.ListPublished(ctx, (page-1)*limit, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReactionCounts(ctx, posts); err != nil {
+		return nil, err
+	}
+	return posts, s.AttachOriginals(ctx, posts)
+}
+
+// attachReactionCounts fills the per-type reaction counts with one grouped query for all posts
+func (s *PostService) attachReactionCounts(ctx context.Context, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	reactions, err := s.Posts.ReactionCounts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].ReactionCounts = reactions[posts[i].ID.String()]
+		if posts[i].ReactionCounts == nil {
+			posts[i].ReactionCounts = map[string]int64{}
+		}
+	}
+	return nil
+}
+
+// Drafts lists the author's drafts and scheduled posts
+func (s *PostService) Drafts(ctx context.Context, authorID uuid.UUID) ([]models.Post, error) {
+	return s.Posts.ListByStatus(ctx, authorID, []string{models.PostDraft, models.PostScheduled})
+}
+
+func (s *PostService) Schedule(ctx context.Context, authorID, postID uuid.UUID, publishAt time.Time) (models.Post, error) {
+	if !publishAt.After(time.Now()) {
+		return models.Post{}, ErrPublishAtInPast
+	}
+	return s.changeStatus(ctx, authorID, postID, []string{models.PostDraft, models.PostScheduled}, map[string]interface{}{
+		"status":     models.PostScheduled,
+		"publish_at": publishAt,
+	})
+}
+
+// Unschedule moves a scheduled post back to the drafts
+func (s *PostService) Unschedule(ctx context.Context, authorID, postID uuid.UUID) (models.Post, error) {
+	return s.changeStatus(ctx, authorID, postID, []string{models.PostScheduled}, map[string]interface{}{
+		"status":     models.PostDraft,
+		"publish_at": nil,
+	})
+}
+
+// Publish publishes a draft or scheduled post right away
+func (s *PostService) Publish(ctx context.Context, authorID, postID uuid.UUID) (models.Post, error) {
+	post, err := s.changeStatus(ctx, authorID, postID, []string{models.PostDraft, models.PostScheduled}, map[string]interface{}{
+		"status":       models.PostPublished,
+		"publish_at":   nil,
+		"published_at": time.Now(),
+	})
+	if err != nil {
+		return post, err
+	}
+
+	var mentioned []uuid.UUID
+	if s.Tags != nil {
+		if mentioned, err = s.Tags.Publish(ctx, post); err != nil {
+			logging.FromContext(ctx).Warn("tags: publish failed", zap.Stringer("post_id", post.ID), zap.Error(err))
+		}
+	}
+	s.fanOut(ctx, post)
+	s.notifyMentions(ctx, post, mentioned)
+	return post, nil
+}
+
+// Archive takes a published post off every listing without deleting it
+func (s *PostService) Archive(ctx context.Context, authorID, postID uuid.UUID) (models.Post, error) {
+	return s.changeStatus(ctx, authorID, postID, []string{models.PostPublished}, map[string]interface{}{
+		"status": models.PostArchived,
+	})
+}
+
+// Unarchive publishes an archived post again, it keeps its original published_at
+func (s *PostService) Unarchive(ctx context.Context, authorID, postID uuid.UUID) (models.Post, error) {
+	return s.changeStatus(ctx, authorID, postID, []string{models.PostArchived}, map[string]interface{}{
+		"status": models.PostPublished,
+	})
+}
+
+// Archived lists the author's archived posts
+func (s *PostService) Archived(ctx context.Context, authorID uuid.UUID) ([]models.Post, error) {
+	return s.Posts.ListByStatus(ctx, authorID, []string{models.PostArchived})
+}
+
+// changeStatus applies updates to one of the author's posts if it is in one
+// of the from states, the status check is part of the UPDATE so it also holds
+// against the scheduler publishing the post concurrently
+func (s *PostService) changeStatus(ctx context.Context, authorID, postID uuid.UUID, from []string, updates map[string]interface{}) (models.Post, error) {
+	post, err := s.findOwned(ctx, authorID, postID)
+	if err != nil {
+		return post, err
+	}
+
+	updates["updated_at"] = time.Now()
+	changed, err := s.Posts.ChangeStatus(ctx, &post, from, updates)
+	if err != nil {
+		return post, err
+	}
+	if !changed {
+		return post, &StatusConflictError{Status: post.Status}
+	}
+	return post, nil
+}
+
+// Repost shares a published post with the user's followers. Reposting a
+// repost shares the post it shares.
+func (s *PostService) Repost(ctx context.Context, userID, postID uuid.UUID) (models.Post, error) {
+	original, err := s.findShareable(ctx, userID, postID)
+	if err != nil {
+		return models.Post{}, err
+	}
+
+	now := time.Now()
+	repost := models.Post{
+		UserID:      userID,
+		Status:      models.PostPublished,
+		PublishedAt: &now,
+		RepostOfID:  &original.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.Posts.Create(ctx, &repost); err != nil {
+		var conflict *repository.ConflictError
+		if errors.As(err, &conflict) && conflict.Field == "repost" {
+			return repost, ErrAlreadyReposted
+		}
+		return repost, err
+	}
+	metrics.PostsCreated.WithLabelValues(repost.Status).Inc()
+
+	s.incrShares(ctx, original.ID, 1)
+	s.fanOut(ctx, repost)
+	if s.Notifications != nil {
+		s.Notifications.Notify(ctx, notifications.Event{
+			Type:        models.NotificationRepost,
+			RecipientID: original.UserID,
+			ActorID:     userID,
+			PostID:      &original.ID,
+		})
+	}
+
+	repost.Original = &original
+	return repost, nil
+}
+
+// Unrepost takes the user's repost of the post back. It doesn't go through
+// the trash, reactions, comments, bookmarks and so on go with the repost.
+func (s *PostService) Unrepost(ctx context.Context, userID, postID uuid.UUID) error {
+	// Undoing from a repost in the feed means undoing the repost of its original
+	if target, err := s.Posts.FindWithTrashed(ctx, postID); err == nil && target.RepostOfID != nil {
+		postID = *target.RepostOfID
+	} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	reposts, err := s.Posts.RepostIDs(ctx, userID, postID)
+	if err != nil {
+		return err
+	}
+	var purged int64
+	if len(reposts) > 0 {
+		if purged, err = s.Trash.PurgePosts(ctx, reposts); err != nil {
+			return err
+		}
+	}
+	if purged == 0 {
+		return ErrNotReposted
+	}
+	s.incrShares(ctx, postID, -purged)
+	return nil
+}
+
+// Quote publishes a new post with the user's comment on top of a shared one
+func (s *PostService) Quote(ctx context.Context, userID, postID uuid.UUID, input models.QuotePostInput) (models.Post, error) {
+	original, err := s.findShareable(ctx, userID, postID)
+	if err != nil {
+		return models.Post{}, err
+	}
+
+	now := time.Now()
+	quote := models.Post{
+		UserID:      userID,
+		Content:     input.Content,
+		Image:       input.Image,
+		Status:      models.PostPublished,
+		PublishedAt: &now,
+		QuoteOfID:   &original.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	var mentioned []uuid.UUID
+	var trending []string
+	err = s.Tx.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		if err := s.Posts.Create(ctx, &quote); err != nil {
+			return err
+		}
+		mentioned, trending, err = s.syncTags(ctx, quote)
+		return err
+	})
+	if err != nil {
+		return quote, err
+	}
+	metrics.PostsCreated.WithLabelValues(quote.Status).Inc()
+	s.bumpTrending(ctx, trending)
+
+	s.incrShares(ctx, original.ID, 1)
+	s.fanOut(ctx, quote)
+	if s.Notifications != nil {
+		s.Notifications.Notify(ctx, notifications.Event{
+			Type:        models.NotificationQuote,
+			RecipientID: original.UserID,
+			ActorID:     userID,
+			PostID:      &quote.ID,
+		})
+	}
+	s.notifyMentions(ctx, quote, mentioned)
+	s.screen(ctx, quote)
+
+	quote.Original = &original
+	return quote, nil
+}
+
+// findShareable loads the published post to repost or quote, a plain repost
+// is swapped for the post it shares
+func (s *PostService) findShareable(ctx context.Context, userID, postID uuid.UUID) (models.Post, error) {
+	post, err := s.Posts.FindByID(ctx, postID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && post.Status != models.PostPublished) {
+		return models.Post{}, ErrPostNotFound
+	} else if err != nil {
+		return post, err
+	}
+	if post.RepostOfID != nil {
+		post, err = s.Posts.FindByID(ctx, *post.RepostOfID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && post.Status != models.PostPublished) {
+			return models.Post{}, ErrOriginalUnavailable
+		} else if err != nil {
+			return post, err
+		}
+	}
+
+	if blocked, err := s.Blocks.BlockedBetween(ctx, userID, post.UserID); err != nil {
+		return post, err
+	} else if blocked {
+		return post, ErrShareBlocked
+	}
+	return post, nil
+}
+
+// Delete moves the author's post to the trash, the purge job removes it for good later
+func (s *PostService) Delete(ctx context.Context, authorID, postID uuid.UUID) error {
+	post, err := s.findOwned(ctx, authorID, postID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.Posts.Delete(ctx, post)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPostNotFound
+	}
+
+	// A repost or quote no longer counts towards the post it shared
+	if post.RepostOfID != nil {
+		s.incrShares(ctx, *post.RepostOfID, -1)
+	}
+	if post.QuoteOfID != nil {
+		s.incrShares(ctx, *post.QuoteOfID, -1)
+	}
+	return nil
+}
+
+// Trashed lists the user's deleted posts that can still be restored
+func (s *PostService) Trashed(ctx context.Context, userID uuid.UUID) ([]models.Post, error) {
+	return s.Posts.ListTrashed(ctx, userID, s.Trash.Cutoff())
+}
+
+// Restore takes the user's post out of the trash
+func (s *PostService) Restore(ctx context.Context, userID, postID uuid.UUID) (models.Post, error) {
+	restored, err := s.Posts.Restore(ctx, userID, postID, s.Trash.Cutoff())
+	var conflict *repository.ConflictError
+	if errors.As(err, &conflict) {
+		return models.Post{}, ErrRestoreConflict
+	} else if err != nil {
+		return models.Post{}, err
+	}
+	if !restored {
+		return models.Post{}, ErrNotInTrash
+	}
+
+	post, err := s.Posts.FindByID(ctx, postID)
+	if err != nil {
+		return post, err
+	}
+	// A repost or quote counts towards the post it shares again
+	if post.RepostOfID != nil {
+		s.incrShares(ctx, *post.RepostOfID, 1)
+	}
+	if post.QuoteOfID != nil {
+		s.incrShares(ctx, *post.QuoteOfID, 1)
+	}
+	return post, nil
+}
+
+// AttachOriginals embeds the shared post into every repost and quote
+func (s *PostService) AttachOriginals(ctx context.Context, posts []models.Post) error {
+	return AttachOriginals(ctx, s.Posts, posts)
+}
+
+// AttachOriginals embeds the shared post into every repost and quote. An
+// original that was deleted or unpublished is flagged instead of failing the list.
+func AttachOriginals(ctx context.Context, repo repository.PostRepository, posts []models.Post) error {
+	var ids []uuid.UUID
+	for _, post := range posts {
+		if post.RepostOfID != nil {
+			ids = append(ids, *post.RepostOfID)
+		}
+		if post.QuoteOfID != nil {
+			ids = append(ids, *post.QuoteOfID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	originals, err := repo.FindPublished(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID]*models.Post, len(originals))
+	for i := range originals {
+		byID[originals[i].ID] = &originals[i]
+	}
+
+	for i := range posts {
+		originalID := posts[i].RepostOfID
+		if originalID == nil {
+			originalID = posts[i].QuoteOfID
+		}
+		if originalID == nil {
+			continue
+		}
+		if original, ok := byID[*originalID]; ok {
+			posts[i].Original = original
+		} else {
+			posts[i].OriginalUnavailable = true
+		}
+	}
+	return nil
+}
+
+// findOwned loads a post of the author's, somebody else's post is not found either
+func (s *PostService) findOwned(ctx context.Context, authorID, postID uuid.UUID) (models.Post, error) {
+	post, err := s.Posts.FindByID(ctx, postID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && post.UserID != authorID) {
+		return models.Post{}, ErrPostNotFound
+	}
+	return post, err
+}
+
+func (s *PostService) syncTags(ctx context.Context, post models.Post) ([]uuid.UUID, []string, error) {
+	if s.Tags == nil {
+		return nil, nil, nil
+	}
+	return s.Tags.SyncPost(ctx, post)
+}
+
+// bumpTrending must run after the transaction that synced the tags committed
+func (s *PostService) bumpTrending(ctx context.Context, names []string) {
+	if s.Tags == nil {
+		return
+	}
+	s.Tags.BumpTrending(ctx, names)
+}
+
+// incrShares moves the reposts counter of a shared post
+func (s *PostService) incrShares(ctx context.Context, postID uuid.UUID, delta int64) {
+	if s.Counters != nil {
+		s.Counters.Incr(ctx, postID, counters.Reposts, delta)
+	}
+}
+
+func (s *PostService) fanOut(ctx context.Context, post models.Post) {
+	if s.Feed == nil {
+		return
+	}
+	if err := s.Feed.FanOut(ctx, post); err != nil {
+		logging.FromContext(ctx).Warn("feed: fan-out failed", zap.Stringer("post_id", post.ID), zap.Error(err))
+	}
+}
+
+// notifyMentions tells the users newly @mentioned in a post
+func (s *PostService) notifyMentions(ctx context.Context, post models.Post, mentioned []uuid.UUID) {
+	if len(mentioned) == 0 || s.Notifications == nil {
+		return
+	}
+	s.Notifications.NotifyAll(ctx, notifications.Event{
+		Type:    models.NotificationMention,
+		ActorID: post.UserID,
+		PostID:  &post.ID,
+	}, mentioned)
+}
+
+// screen runs a new or edited post through the moderation filter
+func (s *PostService) screen(ctx context.Context, post models.Post) {
+	if s.Moderation != nil {
+		s.Moderation.Screen(ctx, models.ReportTargetPost, post.ID.String(), post.UserID, post.Title+"\n"+post.Content)
+	}
+}
+
+// titleError turns a clash on the unique post titles into ErrTitleTaken
+func titleError(err error) error {
+	var conflict *repository.ConflictError
+	if errors.As(err, &conflict) && conflict.Field == "title" {
+		return ErrTitleTaken
+	}
+	return err
+}
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/trung/backend-engineerpro/counters"
+	"github.com/trung/backend-engineerpro/metrics"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/repository/memory"
+)
+
+func newPostService(t *testing.T) (*PostService, *memory.Store, *recorder) {
+	t.Helper()
+	store := memory.NewStore()
+	rec := &recorder{}
+	return NewPostService(store.Posts(), store.Blocks(), store.Transactor(), nil, rec, rec, rec, nil, store.Purger(time.Hour)), store, rec
+}
+
+func postInput(title string) models.CreatePostRequest {
+	return models.CreatePostRequest{Title: title, Content: "content of " + title, Image: "image.png"}
+}
+
+func TestCreatePost(t *testing.T) {
+	ctx := context.Background()
+	posts, _, rec := newPostService(t)
+	author := uuid.New()
+
+	post, err := posts.Create(ctx, author, postInput("First"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Status != models.PostPublished || post.PublishedAt == nil || post.UserID != author {
+		t.Errorf("created %+v", post)
+	}
+	if len(rec.fannedOut) != 1 || rec.fannedOut[0] != post.ID {
+		t.Errorf("fanned out %v", rec.fannedOut)
+	}
+
+	if _, err := posts.Create(ctx, uuid.New(), postInput("First")); !errors.Is(err, ErrTitleTaken) {
+		t.Errorf("same title: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	scheduled := postInput("Later")
+	scheduled.Status = models.PostScheduled
+	scheduled.PublishAt = &past
+	if _, err := posts.Create(ctx, author, scheduled); !errors.Is(err, ErrScheduleNeedsFuture) {
+		t.Errorf("scheduled in the past: %v", err)
+	}
+
+	// Drafts are not shown to anyone, the author included, until published
+	draft := postInput("Draft")
+	draft.Status = models.PostDraft
+	created, err := posts.Create(ctx, author, draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.fannedOut) != 1 {
+		t.Errorf("draft fanned out")
+	}
+	if _, err := posts.Get(ctx, created.ID, &author); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("get draft: %v", err)
+	}
+}
+
+func TestUpdatePost(t *testing.T) {
+	ctx := context.Background()
+	posts, _, _ := newPostService(t)
+	author := uuid.New()
+	post, err := posts.Create(ctx, author, postInput("First"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Create(ctx, author, postInput("Second")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := posts.Update(ctx, uuid.New(), post.ID, models.UpdatePost{Title: "Stolen"}); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("someone else's post: %v", err)
+	}
+	if _, err := posts.Update(ctx, author, post.ID, models.UpdatePost{Title: "Second"}); !errors.Is(err, ErrTitleTaken) {
+		t.Errorf("taken title: %v", err)
+	}
+
+	updated, err := posts.Update(ctx, author, post.ID, models.UpdatePost{Content: "edited"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != "First" || updated.Content != "edited" || !updated.Edited {
+		t.Errorf("updated %+v", updated)
+	}
+}
+
+func TestPostRevisions(t *testing.T) {
+	ctx := context.Background()
+	posts, _, rec := newPostService(t)
+	author := uuid.New()
+	post, err := posts.Create(ctx, author, postInput("First"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Update(ctx, author, post.ID, models.UpdatePost{Title: "Renamed", Content: "bad words"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Create(ctx, author, postInput("First")); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := posts.Revisions(ctx, post.ID)
+	if err != nil || len(revisions) != 1 || revisions[0].Title != "First" || len(revisions[0].Diff) == 0 {
+		t.Fatalf("revisions %+v, %v", revisions, err)
+	}
+
+	if _, err := posts.RestoreRevision(ctx, uuid.New(), post.ID, revisions[0].ID); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("someone else's post: %v", err)
+	}
+	if _, err := posts.RestoreRevision(ctx, author, post.ID, "not-a-uuid"); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("malformed revision id: %v", err)
+	}
+	// The old title went to another post in the meantime
+	if _, err := posts.RestoreRevision(ctx, author, post.ID, revisions[0].ID); !errors.Is(err, ErrTitleTaken) {
+		t.Errorf("restore a taken title: %v", err)
+	}
+
+	if _, err := posts.Update(ctx, author, post.ID, models.UpdatePost{Content: "clean"}); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err = posts.Revisions(ctx, post.ID)
+	if err != nil || len(revisions) != 2 {
+		t.Fatalf("revisions %+v, %v", revisions, err)
+	}
+	// A restore is an edit like any other, it goes through the word filter
+	screened := len(rec.screened)
+	restored, err := posts.RestoreRevision(ctx, author, post.ID, revisions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Content != "bad words" || len(rec.screened) != screened+1 || rec.screened[screened] != "Renamed\nbad words" {
+		t.Errorf("restored %+v, screened %q", restored, rec.screened)
+	}
+}
+
+func TestPostStatus(t *testing.T) {
+	ctx := context.Background()
+	posts, _, _ := newPostService(t)
+	author := uuid.New()
+	draft := postInput("Draft")
+	draft.Status = models.PostDraft
+	post, err := posts.Create(ctx, author, draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := posts.Schedule(ctx, author, post.ID, time.Now().Add(-time.Minute)); !errors.Is(err, ErrPublishAtInPast) {
+		t.Errorf("schedule in the past: %v", err)
+	}
+	if _, err := posts.Schedule(ctx, author, post.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if drafts, err := posts.Drafts(ctx, author); err != nil || len(drafts) != 1 || drafts[0].Status != models.PostScheduled {
+		t.Errorf("drafts %+v, %v", drafts, err)
+	}
+
+	// A published post can't go back to being scheduled
+	if post, err = posts.Publish(ctx, author, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if post.Status != models.PostPublished || post.PublishAt != nil || post.PublishedAt == nil {
+		t.Errorf("published %+v", post)
+	}
+	var conflict *StatusConflictError
+	if _, err := posts.Unschedule(ctx, author, post.ID); !errors.As(err, &conflict) || conflict.Status != models.PostPublished {
+		t.Errorf("unschedule published post: %v", err)
+	}
+
+	if _, err := posts.Archive(ctx, author, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Get(ctx, post.ID, nil); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("get archived post: %v", err)
+	}
+	if archived, err := posts.Archived(ctx, author); err != nil || len(archived) != 1 {
+		t.Errorf("archived %+v, %v", archived, err)
+	}
+	if _, err := posts.Unarchive(ctx, author, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Get(ctx, post.ID, nil); err != nil {
+		t.Errorf("get unarchived post: %v", err)
+	}
+}
+
+func TestGetPost(t *testing.T) {
+	ctx := context.Background()
+	posts, store, _ := newPostService(t)
+	author := uuid.New()
+	post, err := posts.Create(ctx, author, postInput("First"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.AddReaction(models.Reaction{PostID: post.ID, UserID: uuid.New(), Type: models.ReactionLike})
+	store.AddReaction(models.Reaction{PostID: post.ID, UserID: uuid.New(), Type: models.ReactionLike})
+	store.AddReaction(models.Reaction{PostID: post.ID, UserID: uuid.New(), Type: models.ReactionSad})
+	store.AddBookmark(models.Bookmark{PostID: post.ID, UserID: uuid.New()})
+
+	// Only the author learns how often the post was saved
+	viewer := uuid.New()
+	got, err := posts.Get(ctx, post.ID, &viewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ReactionCounts[models.ReactionLike] != 2 || got.ReactionCounts[models.ReactionSad] != 1 || got.BookmarksCount != nil {
+		t.Errorf("viewer got %+v", got)
+	}
+	got, err = posts.Get(ctx, post.ID, &author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.BookmarksCount == nil || *got.BookmarksCount != 1 {
+		t.Errorf("author got bookmarks %v", got.BookmarksCount)
+	}
+
+	list, err := posts.List(ctx, 1, 10)
+	if err != nil || len(list) != 1 || list[0].ReactionCounts[models.ReactionLike] != 2 {
+		t.Errorf("listed %+v, %v", list, err)
+	}
+
+	if err := posts.Delete(ctx, uuid.New(), post.ID); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("delete someone else's post: %v", err)
+	}
+	if err := posts.Delete(ctx, author, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Get(ctx, post.ID, &author); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("get deleted post: %v", err)
+	}
+}
+
+func TestRepostAndQuote(t *testing.T) {
+	ctx := context.Background()
+	posts, store, rec := newPostService(t)
+	author, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	post, err := posts.Create(ctx, author, postInput("Shared"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := testutil.ToFloat64(metrics.PostsCreated.WithLabelValues(models.PostPublished))
+
+	repost, err := posts.Repost(ctx, alice, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Repost(ctx, alice, post.ID); !errors.Is(err, ErrAlreadyReposted) {
+		t.Errorf("reposted twice: %v", err)
+	}
+
+	// Quoting the repost quotes the post it shares
+	quote, err := posts.Quote(ctx, bob, repost.ID, models.QuotePostInput{Content: "look at this"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.QuoteOfID == nil || *quote.QuoteOfID != post.ID || quote.Original == nil {
+		t.Errorf("quote %+v", quote)
+	}
+	if got := testutil.ToFloat64(metrics.PostsCreated.WithLabelValues(models.PostPublished)) - created; got != 2 {
+		t.Errorf("posts created went up by %v, want 2", got)
+	}
+	if len(rec.events) != 2 || rec.events[0].Type != models.NotificationRepost || rec.events[1].Type != models.NotificationQuote {
+		t.Errorf("notified %+v", rec.events)
+	}
+
+	if _, err := posts.Repost(ctx, alice, uuid.New()); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("missing post: %v", err)
+	}
+	if err := store.Blocks().Block(ctx, author, bob, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Repost(ctx, bob, post.ID); !errors.Is(err, ErrShareBlocked) {
+		t.Errorf("blocked: %v", err)
+	}
+
+	if err := posts.Delete(ctx, author, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Quote(ctx, bob, repost.ID, models.QuotePostInput{Content: "again"}); !errors.Is(err, ErrOriginalUnavailable) {
+		t.Errorf("deleted original: %v", err)
+	}
+}
+
+func TestRestorePost(t *testing.T) {
+	ctx := context.Background()
+	posts, store, _ := newPostService(t)
+	counts := counted{}
+	posts.Counters = counts
+	author, alice := uuid.New(), uuid.New()
+	post, err := posts.Create(ctx, author, postInput("Restored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repost, err := posts.Repost(ctx, alice, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := posts.Delete(ctx, alice, repost.ID); err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := posts.Trashed(ctx, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != repost.ID {
+		t.Errorf("trash %+v", trashed)
+	}
+
+	// The repost clashes with the one made after it was deleted
+	again, err := posts.Repost(ctx, alice, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.Restore(ctx, alice, repost.ID); !errors.Is(err, ErrRestoreConflict) {
+		t.Errorf("conflicting restore: %v", err)
+	}
+	if err := posts.Delete(ctx, alice, again.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := posts.Restore(ctx, author, repost.ID); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("someone else's post: %v", err)
+	}
+	restored, err := posts.Restore(ctx, alice, repost.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID != repost.ID || restored.DeletedAt.Valid {
+		t.Errorf("restored %+v", restored)
+	}
+	if counts[post.ID][counters.Reposts] != 1 {
+		t.Errorf("reposts counted %v", counts[post.ID])
+	}
+	if _, err := posts.Restore(ctx, alice, repost.ID); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("restored twice: %v", err)
+	}
+
+	// Past the retention the post is gone for good
+	if err := posts.Delete(ctx, alice, repost.ID); err != nil {
+		t.Fatal(err)
+	}
+	posts.Trash = store.Purger(0)
+	if _, err := posts.Restore(ctx, alice, repost.ID); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("restored past retention: %v", err)
+	}
+}
+
+func TestUnrepost(t *testing.T) {
+	ctx := context.Background()
+	posts, store, _ := newPostService(t)
+	counts := counted{}
+	posts.Counters = counts
+	author, alice := uuid.New(), uuid.New()
+	post, err := posts.Create(ctx, author, postInput("Taken back"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repost, err := posts.Repost(ctx, alice, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.AddReaction(models.Reaction{PostID: repost.ID, UserID: author, Type: "like"})
+
+	if err := posts.Unrepost(ctx, author, post.ID); !errors.Is(err, ErrNotReposted) {
+		t.Errorf("not reposted: %v", err)
+	}
+	// Undoing from the repost itself undoes the repost of its original
+	if err := posts.Unrepost(ctx, alice, repost.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Posts().FindWithTrashed(ctx, repost.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("repost went to the trash: %v", err)
+	}
+	reactions, err := store.Posts().ReactionCounts(ctx, []uuid.UUID{repost.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reactions) != 0 {
+		t.Errorf("reactions left %v", reactions)
+	}
+	if counts[post.ID][counters.Reposts] != 0 {
+		t.Errorf("reposts counted %v", counts[post.ID])
+	}
+	if err := posts.Unrepost(ctx, alice, post.ID); !errors.Is(err, ErrNotReposted) {
+		t.Errorf("unreposted twice: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/counters"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/notifications"
+	"github.com/trung/backend-engineerpro/repository"
+)
+
+// ReactionService manages the reactions on posts and comments, a user has at
+// most one on each
+type ReactionService struct {
+	Posts         repository.PostRepository
+	Reactions     repository.ReactionRepository
+	Notifications Notifier
+	Counters      CounterStore
+}
+
+func NewReactionService(Posts repository.PostRepository, Reactions repository.ReactionRepository, Notifications Notifier, Counters CounterStore) *ReactionService {
+	return &ReactionService{
+		Posts:         Posts,
+		Reactions:     Reactions,
+		Notifications: Notifications,
+		Counters:      Counters,
+	}
+}
+
+// SetPostReaction adds or replaces the user's reaction, setting the same one twice is a no-op
+func (s *ReactionService) SetPostReaction(ctx context.Context, userID, postID uuid.UUID, reactionType string) (models.Reaction, error) {
+	post, err := s.findPost(ctx, postID)
+	if err != nil {
+		return models.Reaction{}, err
+	}
+
+	now := time.Now()
+	reaction := models.Reaction{
+		PostID:    postID,
+		UserID:    userID,
+		Type:      reactionType,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	// Insert first so we know whether the counter has to move
+	added, err := s.Reactions.AddPostReaction(ctx, &reaction)
+	if err != nil {
+		return reaction, err
+	}
+	if !added {
+		return reaction, s.Reactions.UpdatePostReaction(ctx, &reaction)
+	}
+	s.incrReactions(ctx, postID, 1)
+	s.notifyLike(ctx, userID, post)
+	return reaction, nil
+}
+
+// RemovePostReaction succeeds whether or not the user had reacted
+func (s *ReactionService) RemovePostReaction(ctx context.Context, userID, postID uuid.UUID) error {
+	removed, err := s.Reactions.RemovePostReaction(ctx, postID, userID)
+	if err != nil {
+		return err
+	}
+	if removed {
+		s.incrReactions(ctx, postID, -1)
+	}
+	return nil
+}
+
+// ToggleLike is kept for older clients, it removes any reaction or adds a "like"
+func (s *ReactionService) ToggleLike(ctx context.Context, userID, postID uuid.UUID) error {
+	post, err := s.findPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.Reactions.RemovePostReaction(ctx, postID, userID)
+	if err != nil {
+		return err
+	}
+	if removed {
+		s.incrReactions(ctx, postID, -1)
+		return nil
+	}
+
+	now := time.Now()
+	like := models.Reaction{
+		PostID:    postID,
+		UserID:    userID,
+		Type:      models.ReactionLike,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	added, err := s.Reactions.AddPostReaction(ctx, &like)
+	if err != nil || !added {
+		return err
+	}
+	s.incrReactions(ctx, postID, 1)
+	s.notifyLike(ctx, userID, post)
+	return nil
+}
+
+// PostReactors lists who reacted to a post, newest first, optionally for one type
+func (s *ReactionService) PostReactors(ctx context.Context, postID uuid.UUID, reactionType string, after *repository.Cursor, limit int) ([]models.ReactorResponse, error) {
+	return s.Reactions.PostReactors(ctx, postID, reactionType, after, limit)
+}
+
+// SetCommentReaction adds or replaces the user's reaction to a comment
+func (s *ReactionService) SetCommentReaction(ctx context.Context, userID uuid.UUID, comment models.Comment, reactionType string) (models.CommentReaction, error) {
+	now := time.Now()
+	reaction := models.CommentReaction{
+		CommentID: comment.ID,
+		UserID:    userID,
+		Type:      reactionType,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return reaction, s.Reactions.SetCommentReaction(ctx, &reaction)
+}
+
+func (s *ReactionService) RemoveCommentReaction(ctx context.Context, userID uuid.UUID, comment models.Comment) error {
+	return s.Reactions.RemoveCommentReaction(ctx, comment.ID, userID)
+}
+
+// CommentReactors lists who reacted to a comment, newest first, optionally for one type
+func (s *ReactionService) CommentReactors(ctx context.Context, comment models.Comment, reactionType string, after *repository.Cursor, limit int) ([]models.ReactorResponse, error) {
+	return s.Reactions.CommentReactors(ctx, comment.ID, reactionType, after, limit)
+}
+
+func (s *ReactionService) findPost(ctx context.Context, postID uuid.UUID) (models.Post, error) {
+	post, err := s.Posts.FindByID(ctx, postID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return post, ErrPostNotFound
+	}
+	return post, err
+}
+
+func (s *ReactionService) incrReactions(ctx context.Context, postID uuid.UUID, delta int64) {
+	if s.Counters != nil {
+		s.Counters.Incr(ctx, postID, counters.Reactions, delta)
+	}
+}
+
+// notifyLike tells the post's author about a new reaction
+func (s *ReactionService) notifyLike(ctx context.Context, actorID uuid.UUID, post models.Post) {
+	if s.Notifications == nil {
+		return
+	}
+	s.Notifications.Notify(ctx, notifications.Event{
+		Type:        models.NotificationLike,
+		RecipientID: post.UserID,
+		ActorID:     actorID,
+		PostID:      &post.ID,
+	})
+}
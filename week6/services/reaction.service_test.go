@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/counters"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/repository/memory"
+)
+
+func newReactionService(t *testing.T) (*ReactionService, *memory.Store, *recorder, counted) {
+	t.Helper()
+	store := memory.NewStore()
+	rec := &recorder{}
+	counts := counted{}
+	return NewReactionService(store.Posts(), store.Reactions(), rec, counts), store, rec, counts
+}
+
+func TestPostReactions(t *testing.T) {
+	ctx := context.Background()
+	reactions, store, rec, counts := newReactionService(t)
+	author := createUser(t, store, "author")
+	alice := createUser(t, store, "alice")
+	post := createPost(t, store, author.ID)
+
+	if _, err := reactions.SetPostReaction(ctx, alice.ID, uuid.New(), models.ReactionLike); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("missing post: %v", err)
+	}
+
+	// Changing the reaction neither counts nor notifies again
+	if _, err := reactions.SetPostReaction(ctx, alice.ID, post.ID, models.ReactionLike); err != nil {
+		t.Fatal(err)
+	}
+	reaction, err := reactions.SetPostReaction(ctx, alice.ID, post.ID, models.ReactionHaha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reaction.Type != models.ReactionHaha || counts[post.ID][counters.Reactions] != 1 || len(rec.events) != 1 {
+		t.Errorf("reaction %+v, counted %v, notified %+v", reaction, counts[post.ID], rec.events)
+	}
+
+	reactors, err := reactions.PostReactors(ctx, post.ID, "", nil, 10)
+	if err != nil || len(reactors) != 1 || reactors[0].UserID != alice.ID || reactors[0].Type != models.ReactionHaha {
+		t.Errorf("reactors %+v, %v", reactors, err)
+	}
+	if reactors, err := reactions.PostReactors(ctx, post.ID, models.ReactionLike, nil, 10); err != nil || len(reactors) != 0 {
+		t.Errorf("likes %+v, %v", reactors, err)
+	}
+
+	// Removing twice only counts once
+	for i := 0; i < 2; i++ {
+		if err := reactions.RemovePostReaction(ctx, alice.ID, post.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counts[post.ID][counters.Reactions] != 0 {
+		t.Errorf("counted %v", counts[post.ID])
+	}
+}
+
+func TestToggleLike(t *testing.T) {
+	ctx := context.Background()
+	reactions, store, rec, counts := newReactionService(t)
+	author := createUser(t, store, "author")
+	alice := createUser(t, store, "alice")
+	post := createPost(t, store, author.ID)
+
+	if err := reactions.ToggleLike(ctx, alice.ID, uuid.New()); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("missing post: %v", err)
+	}
+	if err := reactions.ToggleLike(ctx, alice.ID, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if counts[post.ID][counters.Reactions] != 1 || len(rec.events) != 1 || rec.events[0].RecipientID != author.ID {
+		t.Errorf("counted %v, notified %+v", counts[post.ID], rec.events)
+	}
+
+	// Any reaction is taken back, not just a like
+	if _, err := reactions.SetPostReaction(ctx, alice.ID, post.ID, models.ReactionSad); err != nil {
+		t.Fatal(err)
+	}
+	if err := reactions.ToggleLike(ctx, alice.ID, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if reactors, err := reactions.PostReactors(ctx, post.ID, "", nil, 10); err != nil || len(reactors) != 0 {
+		t.Errorf("reactors %+v, %v", reactors, err)
+	}
+	if counts[post.ID][counters.Reactions] != 0 {
+		t.Errorf("counted %v", counts[post.ID])
+	}
+}
+
+func TestCommentReactors(t *testing.T) {
+	ctx := context.Background()
+	reactions, store, _, _ := newReactionService(t)
+	comment := models.Comment{ID: uuid.NewString()}
+
+	var users []models.User
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user := createUser(t, store, name)
+		users = append(users, user)
+		if _, err := reactions.SetCommentReaction(ctx, user.ID, comment, models.ReactionLove); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := reactions.SetCommentReaction(ctx, users[0].ID, comment, models.ReactionAngry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Newest first, alice changing her reaction doesn't move her up
+	page, err := reactions.CommentReactors(ctx, comment, "", nil, 2)
+	if err != nil || len(page) != 2 || page[0].UserID != users[2].ID || page[1].UserID != users[1].ID {
+		t.Fatalf("first page %+v, %v", page, err)
+	}
+	last := page[1]
+	page, err = reactions.CommentReactors(ctx, comment, "", &repository.Cursor{CreatedAt: last.ReactedAt, ID: last.ID}, 2)
+	if err != nil || len(page) != 1 || page[0].UserID != users[0].ID || page[0].Type != models.ReactionAngry {
+		t.Errorf("second page %+v, %v", page, err)
+	}
+
+	if err := reactions.RemoveCommentReaction(ctx, users[0].ID, comment); err != nil {
+		t.Fatal(err)
+	}
+	if page, err := reactions.CommentReactors(ctx, comment, models.ReactionAngry, nil, 10); err != nil || len(page) != 0 {
+		t.Errorf("angry reactors %+v, %v", page, err)
+	}
+}
@@ -0,0 +1,57 @@
+// Package services holds the business rules for auth, users, posts, comments
+// and reactions. They work on the repository interfaces, so they run the same
+// against Postgres and the in-memory fakes. Errors are sentinels whose text
+// is the message the API returns, the controllers pick the status code.
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/notifications"
+)
+
+// The side effects of a change. Each is optional, a nil one is skipped.
+
+// FeedUpdater is implemented by *feed.Feed
+type FeedUpdater interface {
+	FanOut(ctx context.Context, post models.Post) error
+	Invalidate(ctx context.Context, userID uuid.UUID)
+}
+
+// Notifier is implemented by *notifications.Service
+type Notifier interface {
+	Notify(ctx context.Context, event notifications.Event)
+	NotifyAll(ctx context.Context, event notifications.Event, recipients []uuid.UUID)
+}
+
+// Screener is implemented by *moderation.Service
+type Screener interface {
+	Screen(ctx context.Context, targetType, targetID string, authorID uuid.UUID, text string)
+}
+
+// CounterStore is implemented by *counters.Store
+type CounterStore interface {
+	Incr(ctx context.Context, postID uuid.UUID, field string, delta int64)
+}
+
+// TagIndexer is implemented by tags.Indexer. SyncPost, Publish and
+// SyncComment return the users mentioned for the first time and join the
+// transaction ctx carries, the tags the syncs return go to BumpTrending after commit.
+type TagIndexer interface {
+	SyncPost(ctx context.Context, post models.Post) ([]uuid.UUID, []string, error)
+	Publish(ctx context.Context, post models.Post) ([]uuid.UUID, error)
+	SyncComment(ctx context.Context, comment models.Comment, previousContent string) ([]uuid.UUID, []string, error)
+	BumpTrending(ctx context.Context, names []string)
+}
+
+// Trash is implemented by *trash.Purger, unlike the side effects above the
+// services that restore from the trash need it
+type Trash interface {
+	// Cutoff is the oldest deleted_at that can still be restored
+	Cutoff() time.Time
+	// PurgePosts hard deletes the posts and what hangs off them, skipping the trash
+	PurgePosts(ctx context.Context, ids []uuid.UUID) (int64, error)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/notifications"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/utils"
+)
+
+var (
+	ErrUserNotFound     = errors.New("User not found")
+	ErrFollowSelf       = errors.New("You cannot follow yourself")
+	ErrFollowBlocked    = errors.New("You cannot follow this user")
+	ErrAlreadyFollowing = errors.New("You are already following this user")
+	ErrNotFollowing     = errors.New("You are not following this user")
+	ErrBlockSelf        = errors.New("You cannot block yourself")
+	ErrNotBlocked       = errors.New("You have not blocked this user")
+)
+
+// UserService manages profiles and the follow and block relations between users
+type UserService struct {
+	Users         repository.UserRepository
+	Follows       repository.FollowRepository
+	Blocks        repository.BlockRepository
+	Mentions      repository.MentionRepository
+	Tx            repository.Transactor
+	Feed          FeedUpdater
+	Notifications Notifier
+}
+
+func NewUserService(Users repository.UserRepository, Follows repository.FollowRepository, Blocks repository.BlockRepository, Mentions repository.MentionRepository, Tx repository.Transactor, Feed FeedUpdater, Notifications Notifier) *UserService {
+	return &UserService{
+		Users:         Users,
+		Follows:       Follows,
+		Blocks:        Blocks,
+		Mentions:      Mentions,
+		Tx:            Tx,
+		Feed:          Feed,
+		Notifications: Notifications,
+	}
+}
+
+func (s *UserService) UpdateProfile(ctx context.Context, user models.User, input models.UpdateProfileInput) (models.User, error) {
+	if input.Name != "" {
+		user.Name = input.Name
+	}
+
+	if input.Username != "" {
+		username := strings.ToLower(input.Username)
+		if !utils.ValidUsername(username) {
+			return user, ErrInvalidUsername
+		}
+		if existing, err := s.Users.FindByUsername(ctx, username); err == nil && existing.ID != user.ID {
+			return user, ErrUsernameTaken
+		}
+		user.Username = username
+	}
+
+	if input.Age != 0 {
+		user.Age = input.Age
+	}
+
+	if input.Email != "" {
+		// Emails are stored lowercase, see SignUp
+		email := strings.ToLower(input.Email)
+		if existing, err := s.Users.FindByEmail(ctx, email); err == nil && existing.ID != user.ID {
+			return user, ErrEmailTaken
+		}
+		user.Email = email
+	}
+
+	if input.ProfileImage != "" {
+		user.ProfileImage = input.ProfileImage
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := s.Users.Update(ctx, &user); err != nil {
+		return user, takenError(err)
+	}
+	return user, nil
+}
+
+func (s *UserService) Follow(ctx context.Context, followerID, userID uuid.UUID) error {
+	user, err := s.Users.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	if user.ID == followerID {
+		return ErrFollowSelf
+	}
+
+	if blocked, err := s.Blocks.BlockedBetween(ctx, followerID, user.ID); err != nil {
+		return err
+	} else if blocked {
+		return ErrFollowBlocked
+	}
+
+	if following, err := s.Follows.IsFollowing(ctx, followerID, user.ID); err != nil {
+		return err
+	} else if following {
+		return ErrAlreadyFollowing
+	}
+
+	if err := s.Follows.Follow(ctx, followerID, user.ID, time.Now()); err != nil {
+		var conflict *repository.ConflictError
+		if errors.As(err, &conflict) {
+			return ErrAlreadyFollowing
+		}
+		return err
+	}
+
+	s.invalidateFeed(ctx, followerID)
+	if s.Notifications != nil {
+		s.Notifications.Notify(ctx, notifications.Event{
+			Type:        models.NotificationFollow,
+			RecipientID: user.ID,
+			ActorID:     followerID,
+		})
+	}
+	return nil
+}
+
+func (s *UserService) Unfollow(ctx context.Context, followerID, userID uuid.UUID) error {
+	if _, err := s.Users.FindByID(ctx, userID); errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+
+	removed, err := s.Follows.Unfollow(ctx, followerID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotFollowing
+	}
+	s.invalidateFeed(ctx, followerID)
+	return nil
+}
+
+// Block stops two users from following or messaging each other, any follow
+// between them is removed
+func (s *UserService) Block(ctx context.Context, blockerID, userID uuid.UUID) error {
+	if _, err := s.Users.FindByID(ctx, userID); errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	if userID == blockerID {
+		return ErrBlockSelf
+	}
+
+	err := s.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.Blocks.Block(ctx, blockerID, userID, time.Now()); err != nil {
+			return err
+		}
+		if _, err := s.Follows.Unfollow(ctx, blockerID, userID); err != nil {
+			return err
+		}
+		_, err := s.Follows.Unfollow(ctx, userID, blockerID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidateFeed(ctx, blockerID)
+	s.invalidateFeed(ctx, userID)
+	return nil
+}
+
+func (s *UserService) Unblock(ctx context.Context, blockerID, userID uuid.UUID) error {
+	removed, err := s.Blocks.Unblock(ctx, blockerID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+// FindBlocked lists the users blockerID blocked
+func (s *UserService) FindBlocked(ctx context.Context, blockerID uuid.UUID) ([]models.UserSummary, error) {
+	return s.Blocks.FindBlocked(ctx, blockerID)
+}
+
+// FindMentions lists where the user was @mentioned, newest first, after the
+// cursor if there is one
+func (s *UserService) FindMentions(ctx context.Context, userID uuid.UUID, after *repository.Cursor, limit int) ([]models.Mention, error) {
+	return s.Mentions.FindForUser(ctx, userID, after, limit)
+}
+
+func (s *UserService) invalidateFeed(ctx context.Context, userID uuid.UUID) {
+	if s.Feed != nil {
+		s.Feed.Invalidate(ctx, userID)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/notifications"
+	"github.com/trung/backend-engineerpro/repository/memory"
+)
+
+// recorder stands in for the feed and the notifications and remembers the calls
+type recorder struct {
+	events      []notifications.Event
+	invalidated []uuid.UUID
+	fannedOut   []uuid.UUID
+	screened    []string
+}
+
+func (r *recorder) Notify(ctx context.Context, event notifications.Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) NotifyAll(ctx context.Context, event notifications.Event, recipients []uuid.UUID) {
+	for _, id := range recipients {
+		event.RecipientID = id
+		r.events = append(r.events, event)
+	}
+}
+
+func (r *recorder) FanOut(ctx context.Context, post models.Post) error {
+	r.fannedOut = append(r.fannedOut, post.ID)
+	return nil
+}
+
+func (r *recorder) Screen(ctx context.Context, targetType, targetID string, authorID uuid.UUID, text string) {
+	r.screened = append(r.screened, text)
+}
+
+func (r *recorder) Invalidate(ctx context.Context, userID uuid.UUID) {
+	r.invalidated = append(r.invalidated, userID)
+}
+
+func newUserService(t *testing.T) (*UserService, *memory.Store, *recorder) {
+	t.Helper()
+	store := memory.NewStore()
+	rec := &recorder{}
+	users := NewUserService(store.Users(), store.Follows(), store.Blocks(), store.Mentions(), store.Transactor(), rec, rec)
+	return users, store, rec
+}
+
+func createUser(t *testing.T, store *memory.Store, name string) models.User {
+	t.Helper()
+	user := models.User{Name: name, Username: name, Email: name + "@example.com", Role: models.RoleUser}
+	if err := store.Users().Create(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestFollow(t *testing.T) {
+	ctx := context.Background()
+	users, store, rec := newUserService(t)
+	alice := createUser(t, store, "alice")
+	bob := createUser(t, store, "bob")
+
+	if err := users.Follow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 1 || rec.events[0].Type != models.NotificationFollow || rec.events[0].RecipientID != bob.ID {
+		t.Errorf("notified %+v", rec.events)
+	}
+	if len(rec.invalidated) != 1 || rec.invalidated[0] != alice.ID {
+		t.Errorf("invalidated feeds %v", rec.invalidated)
+	}
+
+	tests := []struct {
+		name             string
+		follower, target uuid.UUID
+		err              error
+	}{
+		{"again", alice.ID, bob.ID, ErrAlreadyFollowing},
+		{"self", alice.ID, alice.ID, ErrFollowSelf},
+		{"missing user", alice.ID, uuid.New(), ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := users.Follow(ctx, tt.follower, tt.target); !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	if err := users.Unfollow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Unfollow(ctx, alice.ID, bob.ID); !errors.Is(err, ErrNotFollowing) {
+		t.Errorf("unfollow twice: %v", err)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	ctx := context.Background()
+	users, store, _ := newUserService(t)
+	alice := createUser(t, store, "alice")
+	bob := createUser(t, store, "bob")
+
+	for _, pair := range [][2]uuid.UUID{{alice.ID, bob.ID}, {bob.ID, alice.ID}} {
+		if err := users.Follow(ctx, pair[0], pair[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := users.Block(ctx, alice.ID, alice.ID); !errors.Is(err, ErrBlockSelf) {
+		t.Errorf("block self: %v", err)
+	}
+	if err := users.Block(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both follows are gone and neither side can follow again
+	for _, pair := range [][2]uuid.UUID{{alice.ID, bob.ID}, {bob.ID, alice.ID}} {
+		if following, err := store.Follows().IsFollowing(ctx, pair[0], pair[1]); err != nil || following {
+			t.Errorf("%v still follows %v", pair[0], pair[1])
+		}
+		if err := users.Follow(ctx, pair[0], pair[1]); !errors.Is(err, ErrFollowBlocked) {
+			t.Errorf("follow after block: %v", err)
+		}
+	}
+
+	blocked, err := users.FindBlocked(ctx, alice.ID)
+	if err != nil || len(blocked) != 1 || blocked[0].ID != bob.ID {
+		t.Errorf("blocked %+v, %v", blocked, err)
+	}
+
+	if err := users.Unblock(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Unblock(ctx, alice.ID, bob.ID); !errors.Is(err, ErrNotBlocked) {
+		t.Errorf("unblock twice: %v", err)
+	}
+	if err := users.Follow(ctx, bob.ID, alice.ID); err != nil {
+		t.Errorf("follow after unblock: %v", err)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	users, store, _ := newUserService(t)
+	alice := createUser(t, store, "alice")
+	createUser(t, store, "bob")
+
+	if _, err := users.UpdateProfile(ctx, alice, models.UpdateProfileInput{Username: "Bob"}); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("taken username: %v", err)
+	}
+	if _, err := users.UpdateProfile(ctx, alice, models.UpdateProfileInput{Email: "BOB@example.com"}); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("taken email: %v", err)
+	}
+	if _, err := users.UpdateProfile(ctx, alice, models.UpdateProfileInput{Username: "a"}); !errors.Is(err, ErrInvalidUsername) {
+		t.Errorf("invalid username: %v", err)
+	}
+
+	updated, err := users.UpdateProfile(ctx, alice, models.UpdateProfileInput{Name: "Alice", Username: "Alice_2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := store.Users().FindByID(ctx, alice.ID)
+	if err != nil || stored.Name != "Alice" || stored.Username != "alice_2" || stored.Email != alice.Email {
+		t.Errorf("stored %+v, returned %+v, %v", stored, updated, err)
+	}
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "uploads")
+	store, err := NewLocalStore(dir, "http://localhost:8000/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "images/user/photo.png"
+	if err := store.Put(ctx, key, strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "images", "user", "photo.png"))
+	if err != nil || string(data) != "png" {
+		t.Fatalf("stored file is %q, %v", data, err)
+	}
+	if url := store.URL(key); url != "http://localhost:8000/uploads/images/user/photo.png" {
+		t.Errorf("url is %s", url)
+	}
+
+	// Nothing but the file itself is left behind
+	entries, err := os.ReadDir(filepath.Join(dir, "images", "user"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("upload dir holds %v, %v", entries, err)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "images", "user", "photo.png")); !os.IsNotExist(err) {
+		t.Errorf("file still there after delete: %v", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing file: %v", err)
+	}
+}
+
+func TestLocalStoreKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "/", "../secret", "images/../../secret"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("put %q: got no error", key)
+		}
+		if err := store.Delete(ctx, key); err == nil {
+			t.Errorf("delete %q: got no error", key)
+		}
+	}
+}
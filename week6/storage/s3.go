@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store talks to any S3 compatible service, AWS S3 in production and a
+// MinIO container locally (see docker-compose.yml).
+type S3Store struct {
+	Client    *minio.Client
+	Bucket    string
+	PublicURL string
+}
+
+type S3Options struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	UseSSL    bool
+	// PublicURL is the base URL objects are served from, defaults to the endpoint
+	PublicURL string
+}
+
+func NewS3Store(ctx context.Context, opts S3Options) (*S3Store, error) {
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create s3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("check bucket %s: %w", opts.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, opts.Bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("create bucket %s: %w", opts.Bucket, err)
+		}
+	}
+
+	// URL hands out plain object links, so anonymous clients must be able to
+	// read them. A policy someone already put on the bucket is left alone.
+	policy, err := client.GetBucketPolicy(ctx, opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("get bucket policy %s: %w", opts.Bucket, err)
+	}
+	if policy == "" {
+		if err := client.SetBucketPolicy(ctx, opts.Bucket, publicReadPolicy(opts.Bucket)); err != nil {
+			return nil, fmt.Errorf("set bucket policy %s: %w", opts.Bucket, err)
+		}
+	}
+
+	publicURL := opts.PublicURL
+	if publicURL == "" {
+		scheme := "http"
+		if opts.UseSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s/%s", scheme, opts.Endpoint, opts.Bucket)
+	}
+
+	return &S3Store{Client: client, Bucket: opts.Bucket, PublicURL: strings.TrimRight(publicURL, "/")}, nil
+}
+
+// publicReadPolicy lets anyone get objects, but not list or change the bucket
+func publicReadPolicy(bucket string) string {
+	return fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::%s/*"]}]}`, bucket)
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	_, err := s.Client.PutObject(ctx, s.Bucket, key, body, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := s.Client.RemoveObject(ctx, s.Bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3Store) URL(key string) string {
+	return s.PublicURL + "/" + key
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// TestS3Store runs against the MinIO container from docker-compose when
+// TEST_S3_ENDPOINT is set (e.g. localhost:9000), in a bucket of its own
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_S3_ENDPOINT is not set")
+	}
+	accessKey, secretKey := os.Getenv("TEST_S3_ACCESS_KEY"), os.Getenv("TEST_S3_SECRET_KEY")
+	if accessKey == "" {
+		accessKey, secretKey = "minioadmin", "minioadmin"
+	}
+
+	ctx := context.Background()
+	bucket := "test-" + uuid.NewString()[:8]
+	store, err := NewS3Store(ctx, S3Options{Endpoint: endpoint, AccessKey: accessKey, SecretKey: secretKey, Bucket: bucket})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for object := range store.Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Recursive: true}) {
+			store.Client.RemoveObject(ctx, bucket, object.Key, minio.RemoveObjectOptions{})
+		}
+		store.Client.RemoveBucket(ctx, bucket)
+	})
+
+	// A second store on the same bucket keeps the policy that is there
+	if _, err := NewS3Store(ctx, S3Options{Endpoint: endpoint, AccessKey: accessKey, SecretKey: secretKey, Bucket: bucket}); err != nil {
+		t.Fatalf("reopen bucket: %v", err)
+	}
+
+	key := "images/user/photo.png"
+	if err := store.Put(ctx, key, strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	url := store.URL(key)
+	if url != "http://"+endpoint+"/"+bucket+"/"+key {
+		t.Errorf("url is %s", url)
+	}
+
+	// The url works without credentials
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(body) != "png" || res.Header.Get("Content-Type") != "image/png" {
+		t.Fatalf("get %s: %d %s %q", url, res.StatusCode, res.Header.Get("Content-Type"), body)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	res, err = http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	// Anonymous readers can't list the bucket, so a missing object is a 403 rather than a 404
+	if res.StatusCode == http.StatusOK {
+		t.Errorf("get after delete: %d", res.StatusCode)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing object: %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/initializers"
+	"github.com/trung/backend-engineerpro/logging"
+	"github.com/trung/backend-engineerpro/models"
+	"github.com/trung/backend-engineerpro/repository"
+	"github.com/trung/backend-engineerpro/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Trending scores are kept in one sorted set per hour and summed over the window
+const (
+	bucketFormat = "2006010215"
+	MaxWindow    = 7 * 24 * time.Hour
+	unionTTL     = time.Minute
+)
+
+func bucketKey(t time.Time) string {
+	return "trending:tags:" + t.UTC().Format(bucketFormat)
+}
+
+// Service extracts #hashtags and @mentions from posts and comments and keeps
+// the tag, post_tags and mentions tables plus the trending counters in sync.
+type Service struct {
+	DB    *gorm.DB
+	Redis *redis.Client
+}
+
+func NewService(DB *gorm.DB, Redis *redis.Client) *Service {
+	return &Service{DB: DB, Redis: Redis}
+}
+
+// SyncPost makes the post's tags and mentions match its content. It returns
+// the users that were mentioned for the first time and the tags that count
+// towards trending, for BumpTrending once tx has committed.
+func (s *Service) SyncPost(ctx context.Context, tx *gorm.DB, post models.Post) ([]uuid.UUID, []string, error) {
+	names := utils.ParseHashtags(post.Title + "\n" + post.Content)
+
+	tagIDs, err := s.ensureTags(tx, names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var existing []string
+	if err := tx.Model(&models.PostTag{}).Where("post_id = ?", post.ID).Pluck("tag_id", &existing).Error; err != nil {
+		return nil, nil, err
+	}
+
+	wanted := make(map[string]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		wanted[id] = true
+	}
+	var stale []string
+	had := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		had[id] = true
+		if !wanted[id] {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) > 0 {
+		if err := tx.Where("post_id = ? AND tag_id IN ?", post.ID, stale).Delete(&models.PostTag{}).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	now := time.Now()
+	var added []string
+	for i, id := range tagIDs {
+		if had[id] {
+			continue
+		}
+		postTag := models.PostTag{PostID: post.ID, TagID: id, CreatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&postTag).Error; err != nil {
+			return nil, nil, err
+		}
+		added = append(added, names[i])
+	}
+
+	// Drafts keep their tags but don't trend or notify anyone until published
+	if post.Status != models.PostPublished {
+		return nil, nil, nil
+	}
+	mentioned, err := s.recordMentions(tx, post.Title+"\n"+post.Content, post.UserID, post.ID, nil)
+	return mentioned, added, err
+}
+
+// Publish counts all of a freshly published post's tags towards trending and
+// records its mentions, the tags themselves were synced while it was a draft.
+func (s *Service) Publish(ctx context.Context, tx *gorm.DB, post models.Post) ([]uuid.UUID, error) {
+	var names []string
+	err := tx.Table("post_tags").Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Where("post_tags.post_id = ?", post.ID).Pluck("tags.name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	s.BumpTrending(ctx, names)
+	return s.recordMentions(tx, post.Title+"\n"+post.Content, post.UserID, post.ID, nil)
+}
+
+// Indexer runs SyncPost, Publish and SyncComment in the transaction the
+// context carries, see repository.Transactor
+type Indexer struct {
+	Tags *Service
+}
+
+func (i Indexer) SyncPost(ctx context.Context, post models.Post) ([]uuid.UUID, []string, error) {
+	return i.Tags.SyncPost(ctx, repository.DB(ctx, i.Tags.DB), post)
+}
+
+func (i Indexer) Publish(ctx context.Context, post models.Post) ([]uuid.UUID, error) {
+	return i.Tags.Publish(ctx, repository.DB(ctx, i.Tags.DB), post)
+}
+
+func (i Indexer) SyncComment(ctx context.Context, comment models.Comment, previousContent string) ([]uuid.UUID, []string, error) {
+	return i.Tags.SyncComment(ctx, repository.DB(ctx, i.Tags.DB), comment, previousContent)
+}
+
+func (i Indexer) BumpTrending(ctx context.Context, names []string) {
+	i.Tags.BumpTrending(ctx, names)
+}
+
+// SyncComment records the comment's new mentions; its hashtags only count
+// towards trending, the new ones are returned for BumpTrending after commit.
+func (s *Service) SyncComment(ctx context.Context, tx *gorm.DB, comment models.Comment, previousContent string) ([]uuid.UUID, []string, error) {
+	before := map[string]bool{}
+	for _, name := range utils.ParseHashtags(previousContent) {
+		before[name] = true
+	}
+	var added []string
+	for _, name := range utils.ParseHashtags(comment.Content) {
+		if !before[name] {
+			added = append(added, name)
+		}
+	}
+
+	commentID := comment.ID
+	mentioned, err := s.recordMentions(tx, comment.Content, comment.UserID, comment.PostID, &commentID)
+	return mentioned, added, err
+}
+
+// ensureTags returns the ids of the named tags, creating the missing ones
+func (s *Service) ensureTags(tx *gorm.DB, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	newTags := make([]models.Tag, len(names))
+	for i, name := range names {
+		newTags[i] = models.Tag{Name: name, CreatedAt: now}
+	}
+	if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&newTags).Error; err != nil {
+		return nil, err
+	}
+
+	var found []models.Tag
+	if err := tx.Where("name IN ?", names).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string]string, len(found))
+	for _, tag := range found {
+		byName[tag.Name] = tag.ID
+	}
+
+	ids := make([]string, len(names))
+	for i, name := range names {
+		ids[i] = byName[name]
+	}
+	return ids, nil
+}
+
+func (s *Service) recordMentions(tx *gorm.DB, text string, authorID uuid.UUID, postID uuid.UUID, commentID *string) ([]uuid.UUID, error) {
+	usernames := utils.ParseMentions(text)
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	var users []models.User
+	if err := tx.Select("id").Where("username IN ? AND id <> ?", usernames, authorID).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	existing := tx.Model(&models.Mention{}).Where("post_id = ?", postID)
+	if commentID != nil {
+		existing = existing.Where("comment_id = ?", *commentID)
+	} else {
+		existing = existing.Where("comment_id IS NULL")
+	}
+	var already []uuid.UUID
+	if err := existing.Pluck("mentioned_user_id", &already).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[uuid.UUID]bool, len(already))
+	for _, id := range already {
+		seen[id] = true
+	}
+
+	now := time.Now()
+	var mentioned []uuid.UUID
+	for _, user := range users {
+		if seen[user.ID] {
+			continue
+		}
+		mention := models.Mention{
+			MentionedUserID: user.ID,
+			AuthorID:        authorID,
+			PostID:          postID,
+			CommentID:       commentID,
+			CreatedAt:       now,
+		}
+		if err := tx.Create(&mention).Error; err != nil {
+			return nil, err
+		}
+		mentioned = append(mentioned, user.ID)
+	}
+	return mentioned, nil
+}
+
+// BumpTrending counts one use of each tag in the current hour. It writes to
+// Redis, so call it only after the change that used the tags has committed.
+func (s *Service) BumpTrending(ctx context.Context, names []string) {
+	if len(names) == 0 || !initializers.RedisAvailable() {
+		return
+	}
+
+	key := bucketKey(time.Now())
+	_, err := s.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, name := range names {
+			pipe.ZIncrBy(ctx, key, 1, name)
+		}
+		pipe.Expire(ctx, key, MaxWindow+time.Hour)
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("tags: bump trending failed", zap.Error(err))
+	}
+}
+
+// Trending returns the most used tags over the last window. It reads the
+// hourly Redis buckets and falls back to counting post_tags in Postgres.
+func (s *Service) Trending(ctx context.Context, window time.Duration, limit int) ([]models.TrendingTag, error) {
+	if initializers.RedisAvailable() {
+		tags, err := s.trendingFromRedis(ctx, window, limit)
+		if err == nil {
+			return tags, nil
+		}
+		logging.FromContext(ctx).Warn("tags: trending from redis failed", zap.Error(err))
+	}
+
+	var tags []models.TrendingTag
+	err := s.DB.WithContext(ctx).Table("post_tags").
+		Select("tags.name AS name, count(*) AS score").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL AND posts.status = ?", models.PostPublished).
+		Where("posts.published_at > ?", time.Now().Add(-window)).
+		Group("tags.name").Order("score DESC, name").Limit(limit).
+		Scan(&tags).Error
+	return tags, err
+}
+
+func (s *Service) trendingFromRedis(ctx context.Context, window time.Duration, limit int) ([]models.TrendingTag, error) {
+	hours := int(window / time.Hour)
+	now := time.Now()
+
+	// The union of the buckets is cached for a minute
+	unionKey := fmt.Sprintf("trending:tags:union:%dh:%s", hours, now.UTC().Format(bucketFormat))
+	exists, err := s.Redis.Exists(ctx, unionKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		keys := make([]string, hours)
+		for i := range keys {
+			keys[i] = bucketKey(now.Add(-time.Duration(i) * time.Hour))
+		}
+		_, err := s.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZUnionStore(ctx, unionKey, &redis.ZStore{Keys: keys})
+			pipe.Expire(ctx, unionKey, unionTTL)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scores, err := s.Redis.ZRevRangeWithScores(ctx, unionKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]models.TrendingTag, len(scores))
+	for i, z := range scores {
+		tags[i] = models.TrendingTag{Name: fmt.Sprint(z.Member), Score: int64(z.Score)}
+	}
+	return tags, nil
+}
@@ -0,0 +1,131 @@
+package trash
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trung/backend-engineerpro/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const batchSize = 100
+
+// Purger hard deletes posts and comments that have been in the trash for
+// longer than Retention, together with the rows that hang off them.
+type Purger struct {
+	DB        *gorm.DB
+	Retention time.Duration
+}
+
+func NewPurger(DB *gorm.DB, Retention time.Duration) *Purger {
+	return &Purger{DB: DB, Retention: Retention}
+}
+
+// Cutoff is the oldest deleted_at that can still be restored
+func (p *Purger) Cutoff() time.Time {
+	return time.Now().Add(-p.Retention)
+}
+
+func (p *Purger) Purge(ctx context.Context) error {
+	db := p.DB.WithContext(ctx)
+	cutoff := p.Cutoff()
+	var posts, comments int
+
+	for {
+		var ids []uuid.UUID
+		if err := db.Unscoped().Model(&models.Post{}).Where("deleted_at < ?", cutoff).Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if _, err := p.PurgePosts(ctx, ids); err != nil {
+			return err
+		}
+		posts += len(ids)
+	}
+
+	for {
+		var ids []string
+		if err := db.Unscoped().Model(&models.Comment{}).Where("deleted_at < ?", cutoff).Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error { return purgeComments(tx, ids) }); err != nil {
+			return err
+		}
+		comments += len(ids)
+	}
+
+	if posts > 0 || comments > 0 {
+		zap.L().Info("trash: purged", zap.Int("posts", posts), zap.Int("comments", comments))
+	}
+	return nil
+}
+
+// PurgePosts hard deletes the posts right away, skipping the trash, and
+// returns how many of them were still there
+func (p *Purger) PurgePosts(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	var purged int64
+	err := p.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) (err error) {
+		purged, err = purgePosts(tx, ids)
+		return err
+	})
+	return purged, err
+}
+
+func purgePosts(tx *gorm.DB, ids []uuid.UUID) (int64, error) {
+	var commentIDs []string
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("post_id IN ?", ids).Pluck("id", &commentIDs).Error; err != nil {
+		return 0, err
+	}
+	if err := purgeComments(tx, commentIDs); err != nil {
+		return 0, err
+	}
+
+	if err := purgeNotifications(tx, "post_id", ids); err != nil {
+		return 0, err
+	}
+	if err := tx.Where("target_type = ? AND target_id IN ?", models.ReportTargetPost, ids).Delete(&models.Report{}).Error; err != nil {
+		return 0, err
+	}
+	for _, model := range []interface{}{&models.Reaction{}, &models.PostRevision{}, &models.PostTag{}, &models.Mention{}, &models.Bookmark{}} {
+		if err := tx.Where("post_id IN ?", ids).Delete(model).Error; err != nil {
+			return 0, err
+		}
+	}
+	result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Post{})
+	return result.RowsAffected, result.Error
+}
+
+func purgeComments(tx *gorm.DB, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := purgeNotifications(tx, "comment_id", ids); err != nil {
+		return err
+	}
+	if err := tx.Where("target_type = ? AND target_id IN ?", models.ReportTargetComment, ids).Delete(&models.Report{}).Error; err != nil {
+		return err
+	}
+	for _, model := range []interface{}{&models.CommentReaction{}, &models.CommentRevision{}, &models.Mention{}} {
+		if err := tx.Where("comment_id IN ?", ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Comment{}).Error
+}
+
+// purgeNotifications drops the notifications pointing at the purged posts or comments
+func purgeNotifications(tx *gorm.DB, column string, ids interface{}) error {
+	notificationIDs := tx.Model(&models.Notification{}).Select("id").Where(column+" IN ?", ids)
+	if err := tx.Where("notification_id IN (?)", notificationIDs).Delete(&models.NotificationActor{}).Error; err != nil {
+		return err
+	}
+	return tx.Where(column+" IN ?", ids).Delete(&models.Notification{}).Error
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+const testCooldown = 20 * time.Millisecond
+
+func TestCircuitBreaker(t *testing.T) {
+	// Steps: "allow" and "reject" call Allow expecting it to pass or not,
+	// "fail", "succeed" and "trip" report to the breaker, "wait" sits out the cooldown
+	tests := []struct {
+		name  string
+		steps []string
+		state BreakerState
+	}{
+		{"starts closed", []string{"allow"}, BreakerClosed},
+		{"failures below the threshold stay closed", []string{"fail", "fail", "allow"}, BreakerClosed},
+		{"a success resets the failures", []string{"fail", "fail", "succeed", "fail", "fail", "allow"}, BreakerClosed},
+		{"opens at the threshold", []string{"fail", "fail", "fail", "reject"}, BreakerOpen},
+		{"rejects until the cooldown passed", []string{"fail", "fail", "fail", "reject", "reject"}, BreakerOpen},
+		{"half-open after the cooldown", []string{"fail", "fail", "fail", "wait", "allow"}, BreakerHalfOpen},
+		{"only one probe at a time", []string{"fail", "fail", "fail", "wait", "allow", "reject"}, BreakerHalfOpen},
+		{"a good probe closes", []string{"fail", "fail", "fail", "wait", "allow", "succeed", "allow", "allow"}, BreakerClosed},
+		{"a failed probe opens again", []string{"fail", "fail", "fail", "wait", "allow", "fail", "reject"}, BreakerOpen},
+		{"reopening restarts the cooldown", []string{"fail", "fail", "fail", "wait", "allow", "fail", "wait", "allow"}, BreakerHalfOpen},
+		{"trip opens straight away", []string{"trip", "reject"}, BreakerOpen},
+		{"a tripped breaker probes after the cooldown", []string{"trip", "wait", "allow", "succeed"}, BreakerClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(3, testCooldown)
+			for i, step := range tt.steps {
+				switch step {
+				case "allow":
+					if err := cb.Allow(); err != nil {
+						t.Fatalf("step %d: Allow returned %v in state %s", i, err, cb.State())
+					}
+				case "reject":
+					if err := cb.Allow(); err != ErrCircuitOpen {
+						t.Fatalf("step %d: Allow returned %v in state %s, want ErrCircuitOpen", i, err, cb.State())
+					}
+				case "fail":
+					cb.Failure()
+				case "succeed":
+					cb.Success()
+				case "trip":
+					cb.Trip()
+				case "wait":
+					time.Sleep(testCooldown + 5*time.Millisecond)
+				}
+			}
+			if state := cb.State(); state != tt.state {
+				t.Errorf("state is %s, want %s", state, tt.state)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerStateChanges(t *testing.T) {
+	type change struct{ from, to BreakerState }
+	changes := make(chan change, 8)
+	cb := NewCircuitBreaker(1, testCooldown)
+	cb.OnStateChange = func(from, to BreakerState) { changes <- change{from, to} }
+
+	cb.Failure()
+	cb.Failure()
+	time.Sleep(testCooldown + 5*time.Millisecond)
+	if err := cb.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	cb.Success()
+
+	// Repeated failures while open don't report again
+	want := []change{{BreakerClosed, BreakerOpen}, {BreakerOpen, BreakerHalfOpen}, {BreakerHalfOpen, BreakerClosed}}
+	got := map[change]bool{}
+	for range want {
+		select {
+		case c := <-changes:
+			got[c] = true
+		case <-time.After(time.Second):
+			t.Fatalf("got changes %v, want %v", got, want)
+		}
+	}
+	for _, c := range want {
+		if !got[c] {
+			t.Errorf("missing change %s -> %s, got %v", c.from, c.to, got)
+		}
+	}
+	select {
+	case c := <-changes:
+		t.Errorf("unexpected change %s -> %s", c.from, c.to)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+var ErrUnsupportedImage = errors.New("unsupported image type, use jpeg, png or gif")
+
+// Anything bigger than this is rejected before decoding to avoid decompression bombs
+const maxImagePixels = 40_000_000
+
+type ProcessedImage struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// SniffImageType looks at the file content, never at the client supplied header.
+func SniffImageType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return contentType, nil
+	}
+	return "", ErrUnsupportedImage
+}
+
+// ProcessImage decodes the upload, shrinks it to fit maxSize and re-encodes it.
+// Re-encoding drops EXIF and any other metadata the original carried. It
+// returns the image itself and a thumbnail that fits thumbSize.
+func ProcessImage(data []byte, maxSize, thumbSize int) (*ProcessedImage, *ProcessedImage, error) {
+	contentType, err := SniffImageType(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode image: %w", err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, nil, fmt.Errorf("image is too large: %dx%d", config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	full, err := encodeImage(resizeImage(src, maxSize), contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	thumb, err := encodeImage(resizeImage(src, thumbSize), contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return full, thumb, nil
+}
+
+// resizeImage scales img down so its longest side is at most size, keeping the ratio
+func resizeImage(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		height = height * size / width
+		width = size
+	} else {
+		width = width * size / height
+		height = size
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// encodeImage keeps jpeg as jpeg, everything else becomes png. Animated gifs
+// keep their first frame only.
+func encodeImage(img image.Image, contentType string) (*ProcessedImage, error) {
+	var buf bytes.Buffer
+	out := &ProcessedImage{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	switch contentType {
+	case "image/jpeg":
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+		out.ContentType, out.Extension = "image/jpeg", ".jpg"
+	default:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("encode png: %w", err)
+		}
+		out.ContentType, out.Extension = "image/png", ".png"
+	}
+
+	out.Data = buf.Bytes()
+	return out, nil
+}
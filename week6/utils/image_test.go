@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestProcessImage(t *testing.T) {
+	var jpegData, pngData, gifData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, testImage(800, 400), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(&pngData, testImage(300, 600)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifData, testImage(100, 50), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		data          []byte
+		contentType   string
+		width, height int
+		thumbW        int
+		thumbH        int
+	}{
+		{"jpeg stays jpeg", jpegData.Bytes(), "image/jpeg", 400, 200, 100, 50},
+		{"png keeps the ratio", pngData.Bytes(), "image/png", 200, 400, 50, 100},
+		{"gif becomes png, small images keep their size", gifData.Bytes(), "image/png", 100, 50, 100, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			full, thumb, err := ProcessImage(tt.data, 400, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, got := range []struct {
+				img           *ProcessedImage
+				width, height int
+			}{{full, tt.width, tt.height}, {thumb, tt.thumbW, tt.thumbH}} {
+				if got.img.ContentType != tt.contentType {
+					t.Errorf("content type is %s, want %s", got.img.ContentType, tt.contentType)
+				}
+				if got.img.Width != got.width || got.img.Height != got.height {
+					t.Errorf("size is %dx%d, want %dx%d", got.img.Width, got.img.Height, got.width, got.height)
+				}
+				config, _, err := image.DecodeConfig(bytes.NewReader(got.img.Data))
+				if err != nil {
+					t.Fatalf("decode output: %v", err)
+				}
+				if config.Width != got.width || config.Height != got.height {
+					t.Errorf("encoded size is %dx%d, want %dx%d", config.Width, config.Height, got.width, got.height)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessImageRejects(t *testing.T) {
+	// A png header claiming 10000x10000 pixels, rejected before decoding the pixels
+	var bomb bytes.Buffer
+	if err := png.Encode(&bomb, image.NewGray(image.Rect(0, 0, 10000, 10000))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]byte{
+		"not an image":    []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"),
+		"truncated png":   bomb.Bytes()[:64],
+		"too many pixels": bomb.Bytes(),
+	}
+	for name, data := range tests {
+		if _, _, err := ProcessImage(data, 400, 100); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+}
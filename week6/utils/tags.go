@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	hashtagPattern  = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#])#([\p{L}\p{N}_]{1,50})`)
+	mentionPattern  = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([A-Za-z0-9_]{3,30})`)
+	usernamePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+	digitsOnly      = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// ParseHashtags returns the distinct, lower cased #hashtags in text in the
+// order they first appear. Plain numbers like #1 are not tags.
+func ParseHashtags(text string) []string {
+	return uniqueMatches(hashtagPattern, text, func(tag string) bool { return !digitsOnly.MatchString(tag) })
+}
+
+// ParseMentions returns the distinct, lower cased @usernames in text.
+func ParseMentions(text string) []string {
+	return uniqueMatches(mentionPattern, text, func(string) bool { return true })
+}
+
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+func uniqueMatches(pattern *regexp.Regexp, text string, keep func(string) bool) []string {
+	var found []string
+	seen := map[string]bool{}
+	for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+		value := strings.ToLower(match[1])
+		if seen[value] || !keep(value) {
+			continue
+		}
+		seen[value] = true
+		found = append(found, value)
+	}
+	return found
+}